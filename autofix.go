@@ -0,0 +1,287 @@
+/*
+autofix.go safe rewrites of Yoda metadata used by readYmeta fix: canonical ORCID URIs, ISO 8601
+dates, vocabulary term correction and removal of empty list entries. The text normaliser
+(normalise.go) is always applied first so that the rewrites work on trimmed values.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const orcid_uri_prefix string = "https://orcid.org/"
+
+// an ORCID with optional URI prefix and optional separators
+var orcid_pattern = regexp.MustCompile(`^(?:https?://)?(?:www\.)?(?:orcid\.org/)?([0-9]{4})[- ]?([0-9]{4})[- ]?([0-9]{4})[- ]?([0-9]{3}[0-9Xx])$`)
+
+// date layouts accepted for conversion to ISO 8601
+var date_layouts = []string{
+	"2006-01-02",
+	"2006-1-2",
+	"2006/01/02",
+	"2006/1/2",
+	"2006.01.02",
+	"20060102",
+	"2 January 2006",
+	"2 Jan 2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+}
+
+// numeric day first layouts as used in the Netherlands. 03/04/2020 can also be read month first
+// (US), such dates are only read when the day is above 12 or equals the month
+var day_first_date_layouts = []string{
+	"02-01-2006",
+	"2-1-2006",
+	"02/01/2006",
+	"2/1/2006",
+	"02.01.2006",
+	"2.1.2006",
+}
+
+// a date field of the metadata
+type date_field struct {
+	Field string
+	Value *string
+}
+
+// the date fields of the metadata
+func metadata_date_fields(data *Yoda18Metadata) []date_field {
+	return []date_field{
+		{"Collected.Start_Date", &data.Collected.StartDate},
+		{"Collected.End_Date", &data.Collected.EndDate},
+		{"Covered_Period.Start_Date", &data.CoveredPeriod.StartDate},
+		{"Covered_Period.End_Date", &data.CoveredPeriod.EndDate},
+		{"Embargo_End_Date", &data.EmbargoEndDate},
+	}
+}
+
+// apply all safe rewrites to the metadata in place, returns the changes made
+func fix_metadata(data *Yoda18Metadata) []TextChange {
+	changes := normalise_metadata(data)
+
+	record := func(field string, old string, new string, reason string) string {
+		if old != new {
+			changes = append(changes, TextChange{Field: field, Old: old, New: new, Reasons: []string{reason}})
+		}
+		return new
+	}
+	vocab := func(field string, value string, fixed string, ok bool) string {
+		if ok {
+			return record(field, value, fixed, tr("fix.vocabulary"))
+		}
+		return value
+	}
+
+	// dates, ambiguous dates are left for check_ambiguous_dates
+	for _, d := range metadata_date_fields(data) {
+		*d.Value = record(d.Field, *d.Value, fix_date(*d.Value), tr("fix.date"))
+	}
+
+	// single valued vocabularies
+	if data.Language != "" {
+		v, ok := vocab_lookup_language(data.Language)
+		data.Language = vocab("Language", data.Language, v, ok)
+	}
+	if data.License != "" {
+		v, ok := vocab_lookup_license(data.License)
+		data.License = vocab("License", data.License, v, ok)
+	}
+	if data.DataType != "" {
+		v, ok := vocab_lookup(vocab_data_type, data.DataType)
+		data.DataType = vocab("Data_Type", data.DataType, v, ok)
+	}
+	if data.DataAccessRestriction != "" {
+		v, ok := vocab_lookup(vocab_data_access_restriction, data.DataAccessRestriction)
+		data.DataAccessRestriction = vocab("Data_Access_Restriction", data.DataAccessRestriction, v, ok)
+	}
+	if data.DataClassification != "" {
+		v, ok := vocab_lookup(vocab_data_classification, data.DataClassification)
+		data.DataClassification = vocab("Data_Classification", data.DataClassification, v, ok)
+	}
+
+	// people
+	for i := range data.Creator {
+		for k := range data.Creator[i].PersonIdentifier {
+			pid := &data.Creator[i].PersonIdentifier[k]
+			field := fmt.Sprintf("Creator[%d].Person_Identifier[%d]", i+1, k+1)
+			v, ok := vocab_lookup(vocab_name_identifier_scheme, pid.NameIdentifierScheme)
+			pid.NameIdentifierScheme = vocab(field+".Name_Identifier_Scheme", pid.NameIdentifierScheme, v, ok)
+			pid.NameIdentifier = record(field+".Name_Identifier", pid.NameIdentifier,
+				fix_person_identifier(pid.NameIdentifierScheme, pid.NameIdentifier), tr("fix.orcid"))
+		}
+	}
+	for i := range data.Contributor {
+		field := fmt.Sprintf("Contributor[%d]", i+1)
+		if data.Contributor[i].ContributorType != "" {
+			v, ok := vocab_lookup(vocab_contributor_type, data.Contributor[i].ContributorType)
+			data.Contributor[i].ContributorType = vocab(field+".Contributor_Type", data.Contributor[i].ContributorType, v, ok)
+		}
+		for k := range data.Contributor[i].PersonIdentifier {
+			pid := &data.Contributor[i].PersonIdentifier[k]
+			pfield := fmt.Sprintf("%s.Person_Identifier[%d]", field, k+1)
+			v, ok := vocab_lookup(vocab_name_identifier_scheme, pid.NameIdentifierScheme)
+			pid.NameIdentifierScheme = vocab(pfield+".Name_Identifier_Scheme", pid.NameIdentifierScheme, v, ok)
+			pid.NameIdentifier = record(pfield+".Name_Identifier", pid.NameIdentifier,
+				fix_person_identifier(pid.NameIdentifierScheme, pid.NameIdentifier), tr("fix.orcid"))
+		}
+	}
+
+	// related data packages
+	for i := range data.RelatedDatapackage {
+		rel := &data.RelatedDatapackage[i]
+		field := fmt.Sprintf("Related_Datapackage[%d]", i+1)
+		if rel.RelationType != "" {
+			v, ok := vocab_lookup_relation_type(rel.RelationType)
+			rel.RelationType = vocab(field+".Relation_Type", rel.RelationType, v, ok)
+		}
+		if rel.PersistentIdentifier.IdentifierScheme != "" {
+			v, ok := vocab_lookup(vocab_identifier_scheme, rel.PersistentIdentifier.IdentifierScheme)
+			rel.PersistentIdentifier.IdentifierScheme = vocab(field+".Persistent_Identifier.Identifier_Scheme",
+				rel.PersistentIdentifier.IdentifierScheme, v, ok)
+		}
+	}
+
+	// empty list entries last, the field paths above refer to the original positions
+	remove_empty_entries(reflect.ValueOf(data).Elem(), "", &changes)
+	return changes
+}
+
+// convert a date to YYYY-MM-DD, unknown formats and ambiguous dates are returned unchanged
+func fix_date(date string) string {
+	if t, ok, _ := read_date(date); ok {
+		return t.Format("2006-01-02")
+	}
+	return date
+}
+
+// read a date in one of the accepted layouts, ambiguous is true for a day first date that can
+// also be read month first, it is not read
+func read_date(date string) (t time.Time, ok bool, ambiguous bool) {
+	date = strings.TrimSpace(date)
+	if date == "" {
+		return t, false, false
+	}
+	for _, layout := range date_layouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return t, true, false
+		}
+	}
+	for _, layout := range day_first_date_layouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			if t.Day() <= 12 && t.Day() != int(t.Month()) {
+				return time.Time{}, false, true
+			}
+			return t, true, false
+		}
+	}
+	return t, false, false
+}
+
+// dates that can be read day first and month first
+func check_ambiguous_dates(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for _, d := range metadata_date_fields(&data) {
+		if _, _, ambiguous := read_date(*d.Value); ambiguous {
+			findings = append(findings, Finding{Field: d.Field, Message: tr("msg.ambiguous_date", d.Field, *d.Value)})
+		}
+	}
+	return findings
+}
+
+// return the 16 digit ORCID (0000-0000-0000-0000) if s is a valid ORCID in any notation
+func parse_orcid(s string) (string, bool) {
+	m := orcid_pattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", false
+	}
+	id := strings.ToUpper(strings.Join(m[1:], "-"))
+	return id, orcid_checksum_ok(id)
+}
+
+// ISO 7064 11,2 check digit used by ORCID and ISNI
+func orcid_checksum_ok(id string) bool {
+	digits := strings.ReplaceAll(id, "-", "")
+	total := 0
+	for _, c := range digits[:len(digits)-1] {
+		total = (total + int(c-'0')) * 2
+	}
+	check := (12 - total%11) % 11
+	want := byte('0' + check)
+	if check == 10 {
+		want = 'X'
+	}
+	return digits[len(digits)-1] == want
+}
+
+// ORCIDs are rewritten to https://orcid.org/0000-0000-0000-0000, other schemes are left alone
+func fix_person_identifier(scheme string, identifier string) string {
+	if scheme != "ORCID" {
+		return identifier
+	}
+	if id, ok := parse_orcid(identifier); ok {
+		return orcid_uri_prefix + id
+	}
+	return identifier
+}
+
+// remove list entries that only contain empty values, recursing into nested lists first
+func remove_empty_entries(v reflect.Value, field string, changes *[]TextChange) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if field != "" {
+				name = field + "." + name
+			}
+			remove_empty_entries(v.Field(i), name, changes)
+		}
+	case reflect.Slice:
+		kept := reflect.MakeSlice(v.Type(), 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			entry := fmt.Sprintf("%s[%d]", field, i+1)
+			remove_empty_entries(v.Index(i), entry, changes)
+			if value_is_blank(v.Index(i)) {
+				old, _ := json.Marshal(v.Index(i).Interface())
+				*changes = append(*changes, TextChange{Field: entry, Old: string(old), New: "", Reasons: []string{tr("fix.empty_entry")}})
+			} else {
+				kept = reflect.Append(kept, v.Index(i))
+			}
+		}
+		if kept.Len() != v.Len() {
+			v.Set(kept)
+		}
+	}
+}
+
+// a value is blank if all the strings it contains are blank and all numbers are zero
+func value_is_blank(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return is_blank(v.String())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !value_is_blank(v.Field(i)) {
+				return false
+			}
+		}
+		return true
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if !value_is_blank(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	}
+	return v.IsZero()
+}
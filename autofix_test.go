@@ -0,0 +1,67 @@
+package main
+
+import "testing"
+
+func TestFixDate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"2022-08-02", "2022-08-02"},
+		{"2022-8-2", "2022-08-02"},
+		{"2022/08/02", "2022-08-02"},
+		{"20220802", "2022-08-02"},
+		{"2 August 2022", "2022-08-02"},
+		{"Aug 2, 2022", "2022-08-02"},
+		{"2022-08-02T10:00:00Z", "2022-08-02"},
+		// day first only when the day cannot be a month
+		{"23/04/2020", "2020-04-23"},
+		{"23-4-2020", "2020-04-23"},
+		{"13.01.2021", "2021-01-13"},
+		{"04/04/2020", "2020-04-04"},
+		// 3 April or March 4, left alone
+		{"03/04/2020", "03/04/2020"},
+		{"1-12-2020", "1-12-2020"},
+		{"not a date", "not a date"},
+		{"2022-13-01", "2022-13-01"},
+	}
+	for _, tt := range tests {
+		if got := fix_date(tt.in); got != tt.want {
+			t.Errorf("fix_date(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAmbiguousDates(t *testing.T) {
+	data := test_metadata(t, `{"Collected": {"Start_Date": "03/04/2020", "End_Date": "23/04/2020"},
+		"Embargo_End_Date": "1.2.2030"}`)
+	findings := check_ambiguous_dates(data, nil, Profile{})
+	if len(findings) != 2 || findings[0].Field != "Collected.Start_Date" || findings[1].Field != "Embargo_End_Date" {
+		t.Errorf("unexpected findings: %v", findings)
+	}
+	fix_metadata(&data)
+	if data.Collected.StartDate != "03/04/2020" || data.Collected.EndDate != "2020-04-23" {
+		t.Errorf("dates after fix: %q, %q", data.Collected.StartDate, data.Collected.EndDate)
+	}
+}
+
+func TestParseOrcid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"0000-0001-7108-4545", "0000-0001-7108-4545", true},
+		{"https://orcid.org/0000-0003-3674-598X", "0000-0003-3674-598X", true},
+		{"orcid.org/0000 0003 3674 598x", "0000-0003-3674-598X", true},
+		{"0000-0001-7108-4546", "0000-0001-7108-4546", false},
+		{"1234", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parse_orcid(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parse_orcid(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
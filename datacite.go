@@ -0,0 +1,528 @@
+/*
+datacite.go the readYmeta import subcommand, converts a DataCite metadata record (kernel-4 XML or
+DataCite JSON, as exported by DataCite Fabrica or the REST API) into a Yoda metadata file.
+Usage: readYmeta import [-o <file>] <datacite.xml|datacite.json|->
+		Creators, contributors, subjects, dates, funding references, rights and related identifiers
+		are mapped onto the Yoda fields. DataCite information that has no place in Yoda or that does
+		not match a Yoda vocabulary is listed as a lossy mapping, the written yoda-metadata.json can
+		then be rendered and validated like any other metadata file.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// schema link of the Yoda metadata files written by the importer
+const yoda_schema_link string = "https://yoda.uu.nl/schemas/default-2/metadata.json"
+
+// a DataCite value that is a string, a number or an object with a name (publisher, affiliation)
+type datacite_value string
+
+func (v *datacite_value) UnmarshalJSON(raw []byte) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		*v = datacite_value(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		*v = datacite_value(n.String())
+		return nil
+	}
+	var named struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(raw, &named); err == nil {
+		*v = datacite_value(named.Name)
+		return nil
+	}
+	var list []datacite_value
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return fmt.Errorf("unexpected DataCite value %s", raw)
+	}
+	parts := make([]string, len(list))
+	for i, s := range list {
+		parts[i] = string(s)
+	}
+	*v = datacite_value(strings.Join(parts, "\n\n"))
+	return nil
+}
+
+func (v datacite_value) String() string {
+	return strings.TrimSpace(string(v))
+}
+
+// a creator or contributor, the XML name element is creatorName or contributorName
+type DataCitePerson struct {
+	Name            datacite_value           `json:"name" xml:"-"`
+	NameType        string                   `json:"nameType" xml:"-"`
+	CreatorName     DataCiteName             `json:"-" xml:"creatorName"`
+	ContributorName DataCiteName             `json:"-" xml:"contributorName"`
+	GivenName       string                   `json:"givenName" xml:"givenName"`
+	FamilyName      string                   `json:"familyName" xml:"familyName"`
+	NameIdentifiers []DataCiteNameIdentifier `json:"nameIdentifiers" xml:"nameIdentifier"`
+	Affiliation     []datacite_value         `json:"affiliation" xml:"affiliation"`
+	ContributorType string                   `json:"contributorType" xml:"contributorType,attr"`
+}
+
+type DataCiteName struct {
+	Value    string `xml:",chardata"`
+	NameType string `xml:"nameType,attr"`
+}
+
+type DataCiteNameIdentifier struct {
+	NameIdentifier       string `json:"nameIdentifier" xml:",chardata"`
+	NameIdentifierScheme string `json:"nameIdentifierScheme" xml:"nameIdentifierScheme,attr"`
+}
+
+type DataCiteResourceType struct {
+	ResourceType        string `json:"resourceType" xml:",chardata"`
+	ResourceTypeGeneral string `json:"resourceTypeGeneral" xml:"resourceTypeGeneral,attr"`
+}
+
+type DataCiteTitle struct {
+	Title     string `json:"title" xml:",chardata"`
+	TitleType string `json:"titleType" xml:"titleType,attr"`
+}
+
+type DataCiteSubject struct {
+	Subject       string `json:"subject" xml:",chardata"`
+	SubjectScheme string `json:"subjectScheme" xml:"subjectScheme,attr"`
+}
+
+type DataCiteDate struct {
+	Date            string `json:"date" xml:",chardata"`
+	DateType        string `json:"dateType" xml:"dateType,attr"`
+	DateInformation string `json:"dateInformation" xml:"dateInformation,attr"`
+}
+
+type DataCiteRelatedIdentifier struct {
+	RelatedIdentifier     string `json:"relatedIdentifier" xml:",chardata"`
+	RelatedIdentifierType string `json:"relatedIdentifierType" xml:"relatedIdentifierType,attr"`
+	RelationType          string `json:"relationType" xml:"relationType,attr"`
+}
+
+type DataCiteRights struct {
+	Rights           string `json:"rights" xml:",chardata"`
+	RightsURI        string `json:"rightsUri" xml:"rightsURI,attr"`
+	RightsIdentifier string `json:"rightsIdentifier" xml:"rightsIdentifier,attr"`
+}
+
+type DataCiteDescription struct {
+	Description     datacite_value `json:"description" xml:",chardata"`
+	DescriptionType string         `json:"descriptionType" xml:"descriptionType,attr"`
+}
+
+type DataCiteGeoLocation struct {
+	Place       string          `json:"geoLocationPlace" xml:"geoLocationPlace"`
+	Box         *DataCiteBox    `json:"geoLocationBox" xml:"geoLocationBox"`
+	Point       *struct{}       `json:"geoLocationPoint" xml:"geoLocationPoint"`
+	PolygonJSON json.RawMessage `json:"geoLocationPolygon" xml:"-"`
+	PolygonXML  []struct{}      `json:"-" xml:"geoLocationPolygon"`
+}
+
+type DataCiteBox struct {
+	West  datacite_value `json:"westBoundLongitude" xml:"westBoundLongitude"`
+	East  datacite_value `json:"eastBoundLongitude" xml:"eastBoundLongitude"`
+	South datacite_value `json:"southBoundLatitude" xml:"southBoundLatitude"`
+	North datacite_value `json:"northBoundLatitude" xml:"northBoundLatitude"`
+}
+
+type DataCiteFunding struct {
+	FunderName       string `json:"funderName" xml:"funderName"`
+	FunderIdentifier string `json:"funderIdentifier" xml:"funderIdentifier"`
+	AwardNumber      string `json:"awardNumber" xml:"awardNumber"`
+	AwardTitle       string `json:"awardTitle" xml:"awardTitle"`
+}
+
+// a DataCite record, the fields are read from the kernel-4 XML or from the DataCite JSON attributes
+type DataCiteRecord struct {
+	XMLName            xml.Name                    `json:"-" xml:"resource"`
+	DOI                string                      `json:"doi" xml:"-"`
+	Identifier         string                      `json:"-" xml:"identifier"`
+	Creators           []DataCitePerson            `json:"creators" xml:"creators>creator"`
+	Titles             []DataCiteTitle             `json:"titles" xml:"titles>title"`
+	Publisher          datacite_value              `json:"publisher" xml:"publisher"`
+	PublicationYear    datacite_value              `json:"publicationYear" xml:"publicationYear"`
+	Subjects           []DataCiteSubject           `json:"subjects" xml:"subjects>subject"`
+	Contributors       []DataCitePerson            `json:"contributors" xml:"contributors>contributor"`
+	Dates              []DataCiteDate              `json:"dates" xml:"dates>date"`
+	Language           string                      `json:"language" xml:"language"`
+	ResourceType       DataCiteResourceType        `json:"types" xml:"resourceType"`
+	RelatedIdentifiers []DataCiteRelatedIdentifier `json:"relatedIdentifiers" xml:"relatedIdentifiers>relatedIdentifier"`
+	Version            datacite_value              `json:"version" xml:"version"`
+	RightsList         []DataCiteRights            `json:"rightsList" xml:"rightsList>rights"`
+	Descriptions       []DataCiteDescription       `json:"descriptions" xml:"descriptions>description"`
+	GeoLocations       []DataCiteGeoLocation       `json:"geoLocations" xml:"geoLocations>geoLocation"`
+	FundingReferences  []DataCiteFunding           `json:"fundingReferences" xml:"fundingReferences>fundingReference"`
+}
+
+// parse a DataCite record, XML when the document starts with <, otherwise JSON (REST API or attributes only)
+func parse_datacite(raw []byte) (DataCiteRecord, error) {
+	var rec DataCiteRecord
+	raw = bytes.TrimPrefix(raw, []byte("\xef\xbb\xbf"))
+	if bytes.HasPrefix(bytes.TrimSpace(raw), []byte("<")) {
+		if err := xml.Unmarshal(raw, &rec); err != nil {
+			return rec, fmt.Errorf("DataCite XML: %w", err)
+		}
+		for i := range rec.Creators {
+			rec.Creators[i].Name = datacite_value(rec.Creators[i].CreatorName.Value)
+			rec.Creators[i].NameType = rec.Creators[i].CreatorName.NameType
+		}
+		for i := range rec.Contributors {
+			rec.Contributors[i].Name = datacite_value(rec.Contributors[i].ContributorName.Value)
+			rec.Contributors[i].NameType = rec.Contributors[i].ContributorName.NameType
+		}
+		rec.DOI = strings.TrimSpace(rec.Identifier)
+		return rec, nil
+	}
+	var doc struct {
+		Data *struct {
+			Attributes json.RawMessage `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return rec, fmt.Errorf("DataCite JSON: %w", err)
+	}
+	if doc.Data != nil {
+		raw = doc.Data.Attributes
+	}
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return rec, fmt.Errorf("DataCite JSON: %w", err)
+	}
+	return rec, nil
+}
+
+// append an empty entry to a list of the metadata and return it for filling in
+func append_entry[T any](list *[]T) *T {
+	var entry T
+	*list = append(*list, entry)
+	return &(*list)[len(*list)-1]
+}
+
+// split a DataCite date or date range (start/end)
+func datacite_date_range(date string) (string, string) {
+	start, end, _ := strings.Cut(strings.TrimSpace(date), "/")
+	return strings.TrimSpace(start), strings.TrimSpace(end)
+}
+
+// access rights of the info:eu-repo vocabulary used in rightsList
+var datacite_access_rights = map[string]string{
+	"info:eu-repo/semantics/openaccess":       "Open - freely retrievable",
+	"info:eu-repo/semantics/restrictedaccess": "Restricted - available upon request",
+	"info:eu-repo/semantics/embargoedaccess":  "Restricted - available upon request",
+	"info:eu-repo/semantics/closedaccess":     "Closed",
+}
+
+// map a DataCite record onto the Yoda metadata, the notes list what could not be mapped exactly
+func datacite_to_yoda(rec DataCiteRecord) (Yoda18MetadataV2, []string) {
+	var data Yoda18MetadataV2
+	var notes []string
+	lossy := func(format string, args ...interface{}) {
+		notes = append(notes, fmt.Sprintf(format, args...))
+	}
+
+	link := append_entry(&data.Links)
+	link.Rel, link.Href = "describedby", yoda_schema_link
+	if rec.DOI != "" {
+		lossy("identifier %s: Yoda assigns the DOI on publication, it is not part of the metadata", rec.DOI)
+	}
+
+	for _, t := range rec.Titles {
+		switch {
+		case t.TitleType == "" && data.Title == "":
+			data.Title = strings.TrimSpace(t.Title)
+		default:
+			lossy("title %q (%s): Yoda has one title, not kept", strings.TrimSpace(t.Title), t.TitleType)
+		}
+	}
+	for _, d := range rec.Descriptions {
+		text := d.Description.String()
+		switch {
+		case text == "":
+		case (d.DescriptionType == "Abstract" || d.DescriptionType == "") && data.Description == "":
+			data.Description = text
+		default:
+			if data.Remarks != "" {
+				data.Remarks += "\n\n"
+			}
+			data.Remarks += d.DescriptionType + ": " + text
+			lossy("description of type %s: written to Remarks", d.DescriptionType)
+		}
+	}
+	if rec.Publisher.String() != "" {
+		lossy("publisher %q: no Yoda field, not kept", rec.Publisher.String())
+	}
+	if rec.PublicationYear.String() != "" {
+		lossy("publication year %s: no Yoda field, not kept", rec.PublicationYear.String())
+	}
+	data.Version = rec.Version.String()
+
+	if rec.Language != "" {
+		lang, ok := vocab_lookup_language(rec.Language)
+		if !ok {
+			lossy("language %q: not in the Yoda language list", rec.Language)
+		}
+		data.Language = lang
+	}
+	if general := rec.ResourceType.ResourceTypeGeneral; general != "" {
+		if data_type, ok := vocab_lookup(vocab_data_type, general); ok {
+			data.DataType = data_type
+		} else {
+			data.DataType = "Other"
+			lossy("resource type %s: not a Yoda data type, written as Other", general)
+		}
+	}
+
+	// people
+	person_name := func(p DataCitePerson) (string, string) {
+		given, family := strings.TrimSpace(p.GivenName), strings.TrimSpace(p.FamilyName)
+		name := p.Name.String()
+		switch {
+		case given != "" || family != "":
+		case p.NameType == "Organizational":
+			family = name
+			lossy("%s is an organisation, written as the family name of a person", name)
+		case strings.Contains(name, ","):
+			family, given, _ = strings.Cut(name, ",")
+			family, given = strings.TrimSpace(family), strings.TrimSpace(given)
+		default:
+			family = name
+			lossy("%s: given and family name not given, written as the family name", name)
+		}
+		return given, family
+	}
+	identifier_scheme := func(who string, id DataCiteNameIdentifier) string {
+		scheme, ok := vocab_lookup(vocab_name_identifier_scheme, id.NameIdentifierScheme)
+		if !ok {
+			lossy("%s: identifier scheme %s is not a Yoda person identifier scheme", who, id.NameIdentifierScheme)
+		}
+		return scheme
+	}
+	affiliations := func(p DataCitePerson) []string {
+		var out []string
+		for _, a := range p.Affiliation {
+			if a.String() != "" {
+				out = append(out, a.String())
+			}
+		}
+		return out
+	}
+	for _, p := range rec.Creators {
+		c := append_entry(&data.Creator)
+		c.Name.GivenName, c.Name.FamilyName = person_name(p)
+		c.Affiliation = affiliations(p)
+		for _, id := range p.NameIdentifiers {
+			pid := append_entry(&c.PersonIdentifier)
+			pid.NameIdentifierScheme = identifier_scheme(p.Name.String(), id)
+			pid.NameIdentifier = strings.TrimSpace(id.NameIdentifier)
+		}
+	}
+	for _, p := range rec.Contributors {
+		c := append_entry(&data.Contributor)
+		c.Name.GivenName, c.Name.FamilyName = person_name(p)
+		c.Affiliation = affiliations(p)
+		for _, id := range p.NameIdentifiers {
+			pid := append_entry(&c.PersonIdentifier)
+			pid.NameIdentifierScheme = identifier_scheme(p.Name.String(), id)
+			pid.NameIdentifier = strings.TrimSpace(id.NameIdentifier)
+		}
+		if contributor_type, ok := vocab_lookup(vocab_contributor_type, p.ContributorType); ok {
+			c.ContributorType = contributor_type
+		} else {
+			c.ContributorType = "Other"
+			lossy("%s: contributor type %s is not a Yoda contributor type, written as Other", p.Name.String(), p.ContributorType)
+		}
+	}
+
+	// subjects are tags, fields of science are also disciplines
+	for _, s := range rec.Subjects {
+		subject := strings.TrimSpace(s.Subject)
+		if subject == "" {
+			continue
+		}
+		if strings.HasPrefix(subject, "FOS:") || strings.Contains(strings.ToLower(s.SubjectScheme), "fields of science") {
+			if discipline, ok := vocab_lookup_discipline(subject); ok {
+				data.Discipline = append(data.Discipline, discipline)
+				continue
+			}
+			lossy("subject %q: not a Yoda discipline, kept as a tag", subject)
+		}
+		data.Tag = append(data.Tag, subject)
+	}
+
+	for _, d := range rec.Dates {
+		start, end := datacite_date_range(d.Date)
+		switch d.DateType {
+		case "Collected":
+			data.Collected.StartDate, data.Collected.EndDate = start, end
+		case "Coverage":
+			data.CoveredPeriod.StartDate, data.CoveredPeriod.EndDate = start, end
+		case "Available":
+			data.EmbargoEndDate = start
+			lossy("date available %s: written as the end of the embargo", d.Date)
+		default:
+			lossy("date %s (%s): no Yoda field, not kept", d.Date, d.DateType)
+		}
+	}
+
+	// rights: access rights and the licence
+	for _, r := range rec.RightsList {
+		text := strings.TrimSpace(r.Rights)
+		if access, ok := datacite_access_rights[strings.ToLower(strings.TrimSpace(r.RightsURI))]; ok {
+			data.DataAccessRestriction = access
+			continue
+		}
+		license, ok := vocab_lookup_license(r.RightsIdentifier)
+		if !ok {
+			license, ok = vocab_lookup_license(text)
+		}
+		switch {
+		case ok && data.License == "":
+			data.License = license
+		case data.License == "":
+			data.License = "Custom"
+			lossy("rights %q (%s): not a Yoda licence, written as Custom", text, r.RightsURI)
+		default:
+			lossy("rights %q: Yoda has one licence, not kept", text)
+		}
+	}
+
+	for _, f := range rec.FundingReferences {
+		ref := append_entry(&data.FundingReference)
+		ref.FunderName = strings.TrimSpace(f.FunderName)
+		ref.AwardNumber = strings.TrimSpace(f.AwardNumber)
+		if f.FunderIdentifier != "" {
+			lossy("funder identifier %s of %s: no Yoda field, not kept", f.FunderIdentifier, ref.FunderName)
+		}
+		if f.AwardTitle != "" {
+			lossy("award title %q: no Yoda field, not kept", f.AwardTitle)
+		}
+	}
+
+	for _, r := range rec.RelatedIdentifiers {
+		relation, ok := vocab_lookup_relation_type(r.RelationType)
+		if !ok {
+			lossy("related identifier %s: relation type %s is not a Yoda relation type, not kept", r.RelatedIdentifier, r.RelationType)
+			continue
+		}
+		rel := append_entry(&data.RelatedDatapackage)
+		rel.RelationType = relation
+		rel.PersistentIdentifier.Identifier = strings.TrimSpace(r.RelatedIdentifier)
+		scheme, ok := vocab_lookup(vocab_identifier_scheme, r.RelatedIdentifierType)
+		if !ok {
+			lossy("related identifier %s: scheme %s is not a Yoda identifier scheme", r.RelatedIdentifier, r.RelatedIdentifierType)
+		}
+		rel.PersistentIdentifier.IdentifierScheme = scheme
+	}
+
+	for _, g := range rec.GeoLocations {
+		if place := strings.TrimSpace(g.Place); place != "" {
+			data.CoveredGeolocationPlace = append(data.CoveredGeolocationPlace, place)
+		}
+		if g.Box != nil {
+			coordinates := []datacite_value{g.Box.West, g.Box.East, g.Box.South, g.Box.North}
+			var values [4]float64
+			var err error
+			for i, c := range coordinates {
+				if values[i], err = strconv.ParseFloat(c.String(), 64); err != nil {
+					break
+				}
+			}
+			if err != nil {
+				lossy("geolocation box %v: not a valid box, not kept", coordinates)
+			} else {
+				box := append_entry(&data.GeoLocation)
+				box.GeoLocationBox = &YodaGeoLocationBox{WestBoundLongitude: values[0], EastBoundLongitude: values[1],
+					SouthBoundLatitude: values[2], NorthBoundLatitude: values[3]}
+				box.DescriptionSpatial = strings.TrimSpace(g.Place)
+			}
+		}
+		if g.Point != nil || len(g.PolygonXML) > 0 || (len(g.PolygonJSON) > 0 && string(g.PolygonJSON) != "null") {
+			where := ""
+			if place := strings.TrimSpace(g.Place); place != "" {
+				where = " of " + place
+			}
+			lossy("geolocation point or polygon%s: Yoda has bounding boxes only, not kept", where)
+		}
+	}
+	return data, notes
+}
+
+// readYmeta import: convert a DataCite XML or JSON record into a Yoda metadata file, spreadsheets
+// (.csv, .xlsx) are imported by import_sheet (spreadsheet.go)
+func import_command(args []string) {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	output_flag := fs.String("o", "", "Yoda metadata file, defaults to output/<file name>/yoda-metadata.json; for a spreadsheet the output directory, defaults to output")
+	profile_name := fs.String("profile", default_profile_name, "rule profile used to validate the imported metadata")
+	skip_errors := fs.Bool("skip-errors", false, "spreadsheet import: do not write the packages that have validation errors")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta import [options] <datacite xml or json file|spreadsheet (.csv, .xlsx)|->")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	profile, err := load_profile(*profile_name)
+	errcntrl(err)
+
+	fname := fs.Arg(0)
+	if is_sheet_file(fname) {
+		outdir := *output_flag
+		if outdir == "" {
+			outdir = "output"
+		}
+		import_sheet(fname, outdir, profile, *skip_errors)
+		return
+	}
+	var raw []byte
+	if fname == stdin_input_name {
+		raw, err = io.ReadAll(os.Stdin)
+		fname = "stdin"
+	} else {
+		raw, err = os.ReadFile(fname)
+	}
+	errcntrl(err)
+	rec, err := parse_datacite(raw)
+	errcntrl(err)
+	data, notes := datacite_to_yoda(rec)
+
+	output_file_name := *output_flag
+	if output_file_name == "" {
+		output_file_name = import_output_file(fname)
+	}
+	errcntrl(os.MkdirAll(filepath.Dir(output_file_name), os.ModePerm))
+	errcntrl(write_metadata_file(Yoda18Metadata(data), output_file_name))
+	REPORT_LANGUAGE = select_language(language_auto, Yoda18Metadata(data))
+	print_validation_summary(output_file_name, create_metadata_report(Yoda18Metadata(data), output_file_name, profile))
+
+	for _, n := range notes {
+		fmt.Println(" lossy:", n)
+	}
+	if len(notes) > 0 {
+		notes_file_name := output_file_name + ".import.txt"
+		errcntrl(write_string_to_file(strings.Join(notes, "\n")+"\n", notes_file_name))
+		fmt.Printf("%d lossy mappings written to: %s\n", len(notes), notes_file_name)
+	}
+	fmt.Println("Yoda metadata written to:", output_file_name)
+}
+
+// default output of a DataCite import, output/<file name>/yoda-metadata.json, the extension is kept
+// so that e.g. record.xml and record.json do not overwrite each other
+func import_output_file(fname string) string {
+	return filepath.Join("output", filepath.Base(fname), current_metadata_file_name)
+}
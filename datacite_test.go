@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDataciteExamples(t *testing.T) {
+	for _, name := range []string{"datacite-example.xml", "datacite-example.json"} {
+		raw, err := os.ReadFile(filepath.Join("test-data", "datacite", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec, err := parse_datacite(raw)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		data, _ := datacite_to_yoda(rec)
+		if data.Title == "" || len(data.Creator) == 0 || data.Creator[0].Name.FamilyName == "" {
+			t.Errorf("%s: title %q, creators %+v", name, data.Title, data.Creator)
+		}
+	}
+}
+
+func TestParseDatacite(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		title string
+		ok    bool
+	}{
+		{"attributes", `{"titles": [{"title": "T"}]}`, "T", true},
+		{"REST API response", `{"data": {"attributes": {"titles": [{"title": "T"}]}}}`, "T", true},
+		{"XML", `<resource><titles><title>T</title></titles></resource>`, "T", true},
+		{"XML with byte order mark", "\xef\xbb\xbf<resource><titles><title>T</title></titles></resource>", "T", true},
+		{"broken JSON", `{"titles": [`, "", false},
+		{"broken XML", `<resource><titles>`, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := parse_datacite([]byte(tt.raw))
+			if (err == nil) != tt.ok {
+				t.Fatalf("error %v, want ok %v", err, tt.ok)
+			}
+			if tt.ok && (len(rec.Titles) != 1 || rec.Titles[0].Title != tt.title) {
+				t.Errorf("titles %+v, want %q", rec.Titles, tt.title)
+			}
+		})
+	}
+}
+
+func TestDataciteToYoda(t *testing.T) {
+	tests := []struct {
+		name  string
+		raw   string
+		check func(data Yoda18MetadataV2) bool
+		notes int
+	}{
+		{"title and subtitle", `{"titles": [{"title": "T"}, {"title": "S", "titleType": "Subtitle"}]}`,
+			func(d Yoda18MetadataV2) bool { return d.Title == "T" }, 1},
+		{"abstract and methods", `{"descriptions": [{"description": "A", "descriptionType": "Abstract"}, {"description": "M", "descriptionType": "Methods"}]}`,
+			func(d Yoda18MetadataV2) bool { return d.Description == "A" && d.Remarks == "Methods: M" }, 1},
+		{"family, given", `{"creators": [{"name": "Lovelace, Ada"}]}`,
+			func(d Yoda18MetadataV2) bool {
+				return d.Creator[0].Name.GivenName == "Ada" && d.Creator[0].Name.FamilyName == "Lovelace"
+			}, 0},
+		{"given and family name", `{"creators": [{"name": "x", "givenName": "Ada", "familyName": "Lovelace"}]}`,
+			func(d Yoda18MetadataV2) bool {
+				return d.Creator[0].Name.GivenName == "Ada" && d.Creator[0].Name.FamilyName == "Lovelace"
+			}, 0},
+		{"organisation", `{"creators": [{"name": "VU", "nameType": "Organizational"}]}`,
+			func(d Yoda18MetadataV2) bool { return d.Creator[0].Name.FamilyName == "VU" }, 1},
+		{"unknown resource type", `{"types": {"resourceTypeGeneral": "Spaceship"}}`,
+			func(d Yoda18MetadataV2) bool { return d.DataType == "Other" }, 1},
+		{"publisher", `{"publisher": {"name": "Yoda"}}`,
+			func(d Yoda18MetadataV2) bool { return true }, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec, err := parse_datacite([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, notes := datacite_to_yoda(rec)
+			if !tt.check(data) {
+				t.Errorf("unexpected metadata %+v", data)
+			}
+			if len(notes) != tt.notes {
+				t.Errorf("%d lossy mappings, want %d: %s", len(notes), tt.notes, strings.Join(notes, "; "))
+			}
+		})
+	}
+}
+
+func TestImportOutputFile(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"test-data/datacite/datacite-example.xml", "output/datacite-example.xml/yoda-metadata.json"},
+		{"test-data/datacite/datacite-example.json", "output/datacite-example.json/yoda-metadata.json"},
+		{"stdin", "output/stdin/yoda-metadata.json"},
+	}
+	for _, tt := range tests {
+		if got := import_output_file(filepath.FromSlash(tt.input)); got != filepath.FromSlash(tt.want) {
+			t.Errorf("import_output_file(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
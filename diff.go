@@ -0,0 +1,502 @@
+/*
+diff.go the readYmeta diff subcommand, shows what changed in the metadata between two versions of
+a data package. Usage: readYmeta diff [-o <file>] [-theme <name|path>] [-lang <code>] <old input> <new input>
+		The comparison is semantic: Tag, Discipline and other lists are compared as sets, people are
+		matched by person identifier and then by name, funding by funder and related datapackages by
+		identifier. The change list is printed and written as a PDF and an HTML change report.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+// kind of change of a field
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeRemoved  ChangeKind = "removed"
+	ChangeModified ChangeKind = "modified"
+)
+
+// symbol of each kind of change in the change list
+var change_symbols = map[ChangeKind]string{ChangeAdded: "+", ChangeRemoved: "-", ChangeModified: "~"}
+
+// a change of one field, Old is empty for added and New for removed values
+type MetadataChange struct {
+	Kind  ChangeKind
+	Field string
+	Old   string
+	New   string
+}
+
+// symbol of the kind of change, for the change report template
+func (c MetadataChange) Symbol() string {
+	return change_symbols[c.Kind]
+}
+
+// html/template of the HTML change report
+//
+//go:embed templates/changes/changes.html.tmpl
+var changes_template string
+
+// the values the HTML change report is written with
+type ChangeReportData struct {
+	OldName   string
+	NewName   string
+	Old       Yoda18Metadata
+	New       Yoda18Metadata
+	Changes   []MetadataChange
+	Added     int
+	Removed   int
+	Modified  int
+	Language  string
+	Generator string
+	Generated string
+}
+
+// readYmeta diff: compare two metadata files, print the changes and write a PDF change report
+func diff_command(args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	output_flag := fs.String("o", "", "PDF change report, defaults to output/<new filename>.changes.pdf, the HTML report is written next to it")
+	theme := fs.String("theme", default_theme_name, "report theme, a builtin theme name or the path to a YAML/TOML theme file")
+	lang := fs.String("lang", language_auto, "report language: "+language_auto+" (the Language field of the new metadata) or one of "+
+		strings.Join(list_languages(), ", "))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta diff [options] <old yoda metadata input> <new yoda metadata input>")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	errcntrl(check_language(*lang))
+
+	old_source, err := open_input_source(fs.Arg(0))
+	errcntrl(err)
+	old_data, _, err := old_source.Read()
+	errcntrl(err)
+	new_source, err := open_input_source(fs.Arg(1))
+	errcntrl(err)
+	new_data, _, err := new_source.Read()
+	errcntrl(err)
+	REPORT_THEME, err = load_theme(*theme)
+	errcntrl(err)
+	apply_theme_fonts(REPORT_THEME, fs)
+	REPORT_LANGUAGE = select_language(*lang, new_data)
+
+	changes := compare_metadata(old_data, new_data)
+	for _, c := range changes {
+		fmt.Println(format_change(c))
+	}
+	counts := count_changes(changes)
+	fmt.Printf("%d changes: %d added, %d removed, %d modified\n", len(changes),
+		counts[ChangeAdded], counts[ChangeRemoved], counts[ChangeModified])
+
+	output_file_name := *output_flag
+	if output_file_name == "" {
+		output_file_name = filepath.Join("output", filepath.Base(new_source.OutputBase())+".changes.pdf")
+	}
+	errcntrl(os.MkdirAll(filepath.Dir(output_file_name), os.ModePerm))
+	doc, err := render_diff_report(changes, old_data, new_data, filepath.Base(fs.Arg(0)), filepath.Base(fs.Arg(1)))
+	errcntrl(err)
+	errcntrl(write_pdf_file(doc, new_data, output_file_name, false))
+	fmt.Println("Change report written to:", output_file_name)
+
+	html_file_name := strings.TrimSuffix(output_file_name, filepath.Ext(output_file_name)) + ".html"
+	html, err := render_diff_html(changes, old_data, new_data, filepath.Base(fs.Arg(0)), filepath.Base(fs.Arg(1)))
+	errcntrl(err)
+	errcntrl(os.WriteFile(html_file_name, []byte(html), 0644))
+	fmt.Println("HTML change report written to:", html_file_name)
+}
+
+// render the HTML change report
+func render_diff_html(changes []MetadataChange, old Yoda18Metadata, new Yoda18Metadata, old_name string, new_name string) (string, error) {
+	counts := count_changes(changes)
+	return ReportTemplate{Name: "changes.html", Source: changes_template}.execute(ChangeReportData{
+		OldName:   old_name,
+		NewName:   new_name,
+		Old:       old,
+		New:       new,
+		Changes:   changes,
+		Added:     counts[ChangeAdded],
+		Removed:   counts[ChangeRemoved],
+		Modified:  counts[ChangeModified],
+		Language:  REPORT_LANGUAGE,
+		Generator: "readYmeta v" + _MYVERSION_,
+		Generated: time.Now().Format(time.RFC3339),
+	}, MetadataReport{})
+}
+
+// a change as a line of the change list
+func format_change(c MetadataChange) string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %q", c.Field, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %q", c.Field, c.Old)
+	}
+	return fmt.Sprintf("~ %s: %q -> %q", c.Field, c.Old, c.New)
+}
+
+// the number of changes of each kind
+func count_changes(changes []MetadataChange) map[ChangeKind]int {
+	counts := map[ChangeKind]int{}
+	for _, c := range changes {
+		counts[c.Kind]++
+	}
+	return counts
+}
+
+// the changes from old to new, in the order of the fields in the report
+func compare_metadata(old Yoda18Metadata, new Yoda18Metadata) []MetadataChange {
+	var changes []MetadataChange
+	value := func(field string, a string, b string) {
+		changes = append(changes, diff_value(field, a, b)...)
+	}
+	set := func(field string, a []string, b []string) {
+		changes = append(changes, diff_set(field, a, b, strings.TrimSpace)...)
+	}
+
+	value("Title", old.Title, new.Title)
+	value("Description", old.Description, new.Description)
+	value("Version", old.Version, new.Version)
+	set("Tag", old.Tag, new.Tag)
+	changes = append(changes, diff_people(collect_role(old, "Creator"), collect_role(new, "Creator"))...)
+	changes = append(changes, diff_people(collect_role(old, "Contributor"), collect_role(new, "Contributor"))...)
+	set("Discipline", old.Discipline, new.Discipline)
+	value("Collected.Start_Date", old.Collected.StartDate, new.Collected.StartDate)
+	value("Collected.End_Date", old.Collected.EndDate, new.Collected.EndDate)
+	value("Covered_Period.Start_Date", old.CoveredPeriod.StartDate, new.CoveredPeriod.StartDate)
+	value("Covered_Period.End_Date", old.CoveredPeriod.EndDate, new.CoveredPeriod.EndDate)
+	set("Covered_Geolocation_Place", old.CoveredGeolocationPlace, new.CoveredGeolocationPlace)
+	set("GeoLocation", geolocation_values(old), geolocation_values(new))
+	changes = append(changes, diff_funding(old, new)...)
+	changes = append(changes, diff_related(old, new)...)
+	value("License", old.License, new.License)
+	value("Data_Type", old.DataType, new.DataType)
+	value("Data_Classification", old.DataClassification, new.DataClassification)
+	value("Data_Access_Restriction", old.DataAccessRestriction, new.DataAccessRestriction)
+	value("Language", old.Language, new.Language)
+	value("Retention_Period", retention_value(old.RetentionPeriod), retention_value(new.RetentionPeriod))
+	value("Retention_Information", old.RetentionInformation, new.RetentionInformation)
+	value("Embargo_End_Date", old.EmbargoEndDate, new.EmbargoEndDate)
+	value("Collection_Name", old.CollectionName, new.CollectionName)
+	value("Remarks", old.Remarks, new.Remarks)
+	set("links", link_values(old), link_values(new))
+	return changes
+}
+
+// change of a single value, surrounding whitespace is ignored
+func diff_value(field string, a string, b string) []MetadataChange {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	switch {
+	case a == b:
+		return nil
+	case a == "":
+		return []MetadataChange{{Kind: ChangeAdded, Field: field, New: b}}
+	case b == "":
+		return []MetadataChange{{Kind: ChangeRemoved, Field: field, Old: a}}
+	}
+	return []MetadataChange{{Kind: ChangeModified, Field: field, Old: a, New: b}}
+}
+
+// values added to and removed from a list, the order of the list does not matter, key tells equal values apart
+func diff_set(field string, a []string, b []string, key func(string) string) []MetadataChange {
+	var changes []MetadataChange
+	in := func(list []string, v string) bool {
+		for _, w := range list {
+			if key(w) == key(v) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range a {
+		if !is_blank(v) && !in(b, v) {
+			changes = append(changes, MetadataChange{Kind: ChangeRemoved, Field: field, Old: strings.TrimSpace(v)})
+		}
+	}
+	for _, v := range b {
+		if !is_blank(v) && !in(a, v) {
+			changes = append(changes, MetadataChange{Kind: ChangeAdded, Field: field, New: strings.TrimSpace(v)})
+		}
+	}
+	return changes
+}
+
+// the creators or the contributors of the metadata
+func collect_role(data Yoda18Metadata, role string) []Person {
+	var people []Person
+	for _, p := range collect_people(data) {
+		if p.Role == role {
+			people = append(people, p)
+		}
+	}
+	return people
+}
+
+// a person with its identifiers, as shown in the change list
+func person_value(p Person) string {
+	if len(p.Identifiers) == 0 {
+		return p.name()
+	}
+	return p.name() + " " + strings.Join(p.Identifiers, " ")
+}
+
+// two entries share a person identifier
+func same_person_identifier(a Person, b Person) bool {
+	for _, ida := range a.Identifiers {
+		for _, idb := range b.Identifiers {
+			if person_identifier_key(ida) == person_identifier_key(idb) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// people are matched by identifier first and then by name, matched people are compared field by field
+func diff_people(old []Person, new []Person) []MetadataChange {
+	var changes []MetadataChange
+	matched := make([]int, len(new))
+	used := map[int]bool{}
+	for i := range matched {
+		matched[i] = -1
+	}
+	for _, same := range []func(a Person, b Person) bool{
+		same_person_identifier,
+		func(a Person, b Person) bool { return person_names_match(a, b) != "" },
+	} {
+		for i := range new {
+			for j := range old {
+				if matched[i] < 0 && !used[j] && same(old[j], new[i]) {
+					matched[i] = j
+					used[j] = true
+				}
+			}
+		}
+	}
+
+	for j, p := range old {
+		if !used[j] {
+			changes = append(changes, MetadataChange{Kind: ChangeRemoved, Field: p.Field, Old: person_value(p)})
+		}
+	}
+	for i, p := range new {
+		if matched[i] < 0 {
+			changes = append(changes, MetadataChange{Kind: ChangeAdded, Field: p.Field, New: person_value(p)})
+			continue
+		}
+		o := old[matched[i]]
+		changes = append(changes, diff_value(p.Field+".Name", o.name(), p.name())...)
+		changes = append(changes, diff_value(p.Field+".Contributor_Type", o.ContributorType, p.ContributorType)...)
+		changes = append(changes, diff_set(p.Field+".Affiliation", o.Affiliations, p.Affiliations, strings.TrimSpace)...)
+		changes = append(changes, diff_set(p.Field+".Person_Identifier", o.Identifiers, p.Identifiers, person_identifier_key)...)
+	}
+	return changes
+}
+
+// funding references are matched by funder and award number, then by funder name
+func diff_funding(old Yoda18Metadata, new Yoda18Metadata) []MetadataChange {
+	var changes []MetadataChange
+	key := func(text string) string { return strings.ToLower(strings.TrimSpace(text)) }
+	// the old entry of every new entry: first the same funder and award, then the same funder
+	match := make([]int, len(new.FundingReference))
+	used := make([]bool, len(old.FundingReference))
+	for i := range match {
+		match[i] = -1
+	}
+	for _, same_award := range []bool{true, false} {
+		for i, n := range new.FundingReference {
+			for j, o := range old.FundingReference {
+				if match[i] >= 0 || used[j] || key(n.FunderName) != key(o.FunderName) {
+					continue
+				}
+				if !same_award || key(n.AwardNumber) == key(o.AwardNumber) {
+					match[i], used[j] = j, true
+				}
+			}
+		}
+	}
+	for j, o := range old.FundingReference {
+		if !used[j] {
+			changes = append(changes, MetadataChange{Kind: ChangeRemoved, Field: fmt.Sprintf("Funding_Reference[%d]", j+1),
+				Old: funding_value(o.FunderName, o.AwardNumber)})
+		}
+	}
+	for i, n := range new.FundingReference {
+		field := fmt.Sprintf("Funding_Reference[%d]", i+1)
+		if match[i] < 0 {
+			changes = append(changes, MetadataChange{Kind: ChangeAdded, Field: field, New: funding_value(n.FunderName, n.AwardNumber)})
+			continue
+		}
+		changes = append(changes, diff_value(field+".Award_Number", old.FundingReference[match[i]].AwardNumber, n.AwardNumber)...)
+	}
+	return changes
+}
+
+func funding_value(funder string, award string) string {
+	if is_blank(award) {
+		return strings.TrimSpace(funder)
+	}
+	return strings.TrimSpace(funder) + " (" + strings.TrimSpace(award) + ")"
+}
+
+// related datapackages are matched by identifier, identifiers in another notation of the same URL are equal
+func diff_related(old Yoda18Metadata, new Yoda18Metadata) []MetadataChange {
+	var changes []MetadataChange
+	key := func(scheme string, identifier string) string {
+		if u, ok := identifier_url(scheme, identifier); ok {
+			return u
+		}
+		return strings.ToLower(strings.TrimSpace(scheme)) + ":" + strings.TrimSpace(identifier)
+	}
+	value := func(scheme string, identifier string, title string) string {
+		return fmt.Sprintf("(%s) %s %s", strings.TrimSpace(scheme), strings.TrimSpace(identifier), strings.TrimSpace(title))
+	}
+	for _, o := range old.RelatedDatapackage {
+		found := false
+		for _, n := range new.RelatedDatapackage {
+			found = found || key(n.PersistentIdentifier.IdentifierScheme, n.PersistentIdentifier.Identifier) ==
+				key(o.PersistentIdentifier.IdentifierScheme, o.PersistentIdentifier.Identifier)
+		}
+		if !found {
+			changes = append(changes, MetadataChange{Kind: ChangeRemoved, Field: "Related_Datapackage",
+				Old: value(o.PersistentIdentifier.IdentifierScheme, o.PersistentIdentifier.Identifier, o.Title)})
+		}
+	}
+	for i, n := range new.RelatedDatapackage {
+		field := fmt.Sprintf("Related_Datapackage[%d]", i+1)
+		found := false
+		for _, o := range old.RelatedDatapackage {
+			if !found && key(n.PersistentIdentifier.IdentifierScheme, n.PersistentIdentifier.Identifier) ==
+				key(o.PersistentIdentifier.IdentifierScheme, o.PersistentIdentifier.Identifier) {
+				found = true
+				changes = append(changes, diff_value(field+".Relation_Type", o.RelationType, n.RelationType)...)
+				changes = append(changes, diff_value(field+".Title", o.Title, n.Title)...)
+			}
+		}
+		if !found {
+			changes = append(changes, MetadataChange{Kind: ChangeAdded, Field: field,
+				New: value(n.PersistentIdentifier.IdentifierScheme, n.PersistentIdentifier.Identifier, n.Title)})
+		}
+	}
+	return changes
+}
+
+// the bounding boxes as text
+func geolocation_values(data Yoda18Metadata) []string {
+	var values []string
+	for i, loc := range data.GeoLocation {
+		if box, ok := geo_box(data, i); ok {
+			values = append(values, strings.TrimSpace(strings.TrimSpace(loc.DescriptionSpatial)+" "+box.String()))
+		}
+	}
+	return values
+}
+
+// the links as text
+func link_values(data Yoda18Metadata) []string {
+	var values []string
+	for _, l := range data.Links {
+		values = append(values, strings.TrimSpace(l.Rel+" "+l.Href))
+	}
+	return values
+}
+
+func retention_value(years int) string {
+	if years == 0 {
+		return ""
+	}
+	return fmt.Sprint(years)
+}
+
+// colour of a kind of change in the change report, not one of the severity colours
+func change_colour(kind ChangeKind) color.Color {
+	switch kind {
+	case ChangeAdded:
+		return color.Color{Red: 0, Green: 120, Blue: 0}
+	case ChangeRemoved:
+		return color.Color{Red: 170, Green: 0, Blue: 0}
+	}
+	return color.Color{Red: 150, Green: 90, Blue: 0}
+}
+
+// an empty report in the current theme with the header text and the standard footer for fname
+func new_report_document(header string, fname string, ctime string, rowheight float64, colwidth uint) (pdf.Maroto, error) {
+	PDF_OUTLINE = nil
+	pending_bookmarks = nil
+	table_header_repeat = nil
+	pagesize, _ := theme_page_size(REPORT_THEME.PageSize)
+	doc := pdf.NewMaroto(consts.Portrait, pagesize)
+	doc.SetPageMargins(REPORT_THEME.margins())
+	doc, err := setup_fonts(doc, *font_flag, split_list_flag(*font_fallback_flag))
+	if err != nil {
+		return doc, err
+	}
+	pdf_write_header(doc, REPORT_THEME.fill_template(REPORT_THEME.Header, header, fname, ctime), REPORT_THEME.Logo, rowheight, colwidth)
+	pdf_write_footer(doc, REPORT_THEME.fill_template(REPORT_THEME.Footer, tr("footer", fname, ctime, _MYVERSION_), fname, ctime),
+		rowheight, colwidth)
+	return doc, nil
+}
+
+// render the PDF change report
+func render_diff_report(changes []MetadataChange, old Yoda18Metadata, new Yoda18Metadata, old_name string, new_name string) (pdf.Maroto, error) {
+	var ctime = time.Now().String()
+	var colwidth uint = 12
+	var rowheight float64 = 4
+	var empty_line_height float64 = 2
+
+	doc, err := new_report_document(tr("diff.header", old_name, new_name), new_name, ctime, rowheight, colwidth)
+	if err != nil {
+		return doc, err
+	}
+
+	counts := count_changes(changes)
+	pdf_write_row(doc, tr("diff.title", old_name, new_name), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_row(doc, tr("diff.version", old.Version, new.Version), rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_row(doc, tr("diff.summary", counts[ChangeAdded], counts[ChangeRemoved], counts[ChangeModified]),
+		rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	pdf_write_changes(doc, changes, rowheight, colwidth)
+	return doc, nil
+}
+
+// the changes as a table with the added, removed and modified values highlighted
+func pdf_write_changes(m pdf.Maroto, changes []MetadataChange, rowheight float64, colwidth uint) {
+	if len(changes) == 0 {
+		pdf_write_row(m, tr("diff.none"), rowheight, colwidth, consts.Italic, pdfBlack())
+		return
+	}
+	var rows [][]table_cell
+	for _, c := range changes {
+		colour := change_colour(c.Kind)
+		kind := table_cell{Lines: []string{change_symbols[c.Kind] + " " + tr("diff."+string(c.Kind))}, Colour: colour}
+		old_cell, new_cell := text_cell(c.Old), text_cell(c.New)
+		switch c.Kind {
+		case ChangeAdded:
+			new_cell.Colour = colour
+		case ChangeRemoved:
+			old_cell.Colour = colour
+		default:
+			new_cell.Colour = colour
+		}
+		rows = append(rows, []table_cell{kind, text_cell(c.Field), old_cell, new_cell})
+	}
+	pdf_write_table(m, []string{tr("diff.change"), tr("diff.field"), tr("diff.old"), tr("diff.new")}, []uint{2, 3, 3, 4}, rows, rowheight)
+}
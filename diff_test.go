@@ -0,0 +1,107 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompareMetadata(t *testing.T) {
+	for _, c := range []struct {
+		name    string
+		old     string
+		new     string
+		changes []MetadataChange
+	}{
+		{"same", `{"Title": "A", "Tag": ["x", "y"]}`, `{"Title": " A ", "Tag": ["y", "x"]}`, nil},
+		{"values", `{"Title": "A", "Version": "1", "License": "MIT License"}`, `{"Title": "B", "Description": "New", "License": ""}`,
+			[]MetadataChange{
+				{Kind: ChangeModified, Field: "Title", Old: "A", New: "B"},
+				{Kind: ChangeAdded, Field: "Description", New: "New"},
+				{Kind: ChangeRemoved, Field: "Version", Old: "1"},
+				{Kind: ChangeRemoved, Field: "License", Old: "MIT License"},
+			}},
+		{"lists", `{"Tag": ["x", "y"], "Discipline": ["a"]}`, `{"Tag": ["y", "z", " "], "Discipline": ["a"]}`,
+			[]MetadataChange{
+				{Kind: ChangeRemoved, Field: "Tag", Old: "x"},
+				{Kind: ChangeAdded, Field: "Tag", New: "z"},
+			}},
+		{"retention and dates", `{"Retention_Period": 10, "Collected": {"Start_Date": "2022-01-01"}}`,
+			`{"Retention_Period": 5, "Collected": {"Start_Date": "2022-01-01", "End_Date": "2022-12-31"}}`,
+			[]MetadataChange{
+				{Kind: ChangeAdded, Field: "Collected.End_Date", New: "2022-12-31"},
+				{Kind: ChangeModified, Field: "Retention_Period", Old: "10", New: "5"},
+			}},
+		{"people matched by identifier",
+			`{"Creator": [{"Name": {"Given_Name": "Jan", "Family_Name": "Jansen"}, "Affiliation": ["VU"],
+				"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]}]}`,
+			`{"Creator": [{"Name": {"Given_Name": "Johannes", "Family_Name": "Janssen"}, "Affiliation": ["UvA"],
+				"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "https://orcid.org/0000-0002-1825-0097"}]}]}`,
+			[]MetadataChange{
+				{Kind: ChangeModified, Field: "Creator[1].Name", Old: "Jan Jansen", New: "Johannes Janssen"},
+				{Kind: ChangeRemoved, Field: "Creator[1].Affiliation", Old: "VU"},
+				{Kind: ChangeAdded, Field: "Creator[1].Affiliation", New: "UvA"},
+			}},
+		{"people matched by name", `{"Contributor": [{"Name": {"Given_Name": "Ann", "Family_Name": "Smith"}, "Contributor_Type": "Editor"},
+				{"Name": {"Given_Name": "Bob", "Family_Name": "Jones"}}]}`,
+			`{"Contributor": [{"Name": {"Given_Name": "Carla", "Family_Name": "Diaz"}}, {"Name": {"Given_Name": "Ann", "Family_Name": "Smith"}, "Contributor_Type": "Other"}]}`,
+			[]MetadataChange{
+				{Kind: ChangeRemoved, Field: "Contributor[2]", Old: "Bob Jones"},
+				{Kind: ChangeAdded, Field: "Contributor[1]", New: "Carla Diaz"},
+				{Kind: ChangeModified, Field: "Contributor[2].Contributor_Type", Old: "Editor", New: "Other"},
+			}},
+		{"funding", `{"Funding_Reference": [{"Funder_Name": "NWO", "Award_Number": "1"}, {"Funder_Name": "ERC"}]}`,
+			`{"Funding_Reference": [{"Funder_Name": "nwo", "Award_Number": "2"}, {"Funder_Name": "ZonMw", "Award_Number": "3"}]}`,
+			[]MetadataChange{
+				{Kind: ChangeRemoved, Field: "Funding_Reference[2]", Old: "ERC"},
+				{Kind: ChangeModified, Field: "Funding_Reference[1].Award_Number", Old: "1", New: "2"},
+				{Kind: ChangeAdded, Field: "Funding_Reference[2]", New: "ZonMw (3)"},
+			}},
+		{"two grants of one funder, one removed", `{"Funding_Reference": [{"Funder_Name": "NWO", "Award_Number": "A"}, {"Funder_Name": "NWO", "Award_Number": "B"}]}`,
+			`{"Funding_Reference": [{"Funder_Name": "NWO", "Award_Number": "B"}]}`,
+			[]MetadataChange{{Kind: ChangeRemoved, Field: "Funding_Reference[1]", Old: "NWO (A)"}}},
+		{"two grants of one funder, one changed", `{"Funding_Reference": [{"Funder_Name": "NWO", "Award_Number": "A"}, {"Funder_Name": "NWO", "Award_Number": "B"}]}`,
+			`{"Funding_Reference": [{"Funder_Name": "NWO", "Award_Number": "A"}, {"Funder_Name": "NWO", "Award_Number": "C"}]}`,
+			[]MetadataChange{{Kind: ChangeModified, Field: "Funding_Reference[2].Award_Number", Old: "B", New: "C"}}},
+		{"related datapackages", `{"Related_Datapackage": [{"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.1"},
+				"Relation_Type": "IsPartOf: Current datapackage is part of", "Title": "One"}]}`,
+			`{"Related_Datapackage": [{"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "https://doi.org/10.5281/zenodo.1"},
+				"Relation_Type": "IsPartOf: Current datapackage is part of", "Title": "One"}]}`, nil},
+	} {
+		got := compare_metadata(test_metadata(t, c.old), test_metadata(t, c.new))
+		if !reflect.DeepEqual(got, c.changes) {
+			t.Errorf("%s: changes\n%+v\nwant\n%+v", c.name, got, c.changes)
+		}
+	}
+}
+
+func TestRenderDiffHtml(t *testing.T) {
+	changes := []MetadataChange{
+		{Kind: ChangeAdded, Field: "Tag", New: "<b>new</b>"},
+		{Kind: ChangeRemoved, Field: "Tag", Old: "old"},
+		{Kind: ChangeModified, Field: "Title", Old: "A", New: "B"},
+	}
+	out, err := render_diff_html(changes, Yoda18Metadata{Version: "1"}, Yoda18Metadata{Version: "2"}, "a.json", "b.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<h1>" + tr("diff.title", "a.json", "b.json") + "</h1>",
+		"<p>" + tr("diff.summary", 1, 1, 1) + "</p>",
+		`<td class="added">&lt;b&gt;new&lt;/b&gt;</td>`,
+		`<td class="removed">old</td><td></td>`,
+		`<td>A</td><td class="modified">B</td>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("change report does not contain %s", want)
+		}
+	}
+
+	out, err = render_diff_html(nil, Yoda18Metadata{}, Yoda18Metadata{}, "a.json", "b.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out, tr("diff.none")) {
+		t.Error("no changes are not reported")
+	}
+}
@@ -0,0 +1,176 @@
+/*
+fix.go the readYmeta fix subcommand, writes a corrected copy of a Yoda metadata file.
+Usage: readYmeta fix [-o <file>] [--in-place] <yoda metadata file>, the corrected file is written to
+		the output directory using the same name as the input file together with a unified diff
+		(<name>.json.diff) of the fixes, the original is only overwritten with --in-place. Values that cannot be
+		written back (keys that are not Yoda fields, empty values such as 0) are listed and stop
+		--in-place.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// readYmeta fix: apply the safe rewrites to a metadata file and write the result and a diff
+func fix_command(args []string) {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	output_flag := fs.String("o", "", "output file, defaults to output/<filename>.json")
+	in_place_flag := fs.Bool("in-place", false, "overwrite the original metadata file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta fix [options] <yoda metadata file>")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	if *in_place_flag && *output_flag != "" {
+		errcntrl(fmt.Errorf("-o and --in-place cannot be combined"))
+	}
+
+	fname := "yoda-metadata.json"
+	if fs.NArg() > 0 {
+		fname = fs.Arg(0)
+	} else {
+		fmt.Println("Filename argument not provided, using default: yoda-metadata.json")
+	}
+
+	input_file_name, input_file_path, output_file_path, err := get_input_file_path(fname)
+	errcntrl(err)
+
+	original, err := os.ReadFile(input_file_name)
+	errcntrl(err)
+	data, err := decode_metadata(original, input_file_name)
+	errcntrl(err)
+	lost, err := encoding_losses(original, data)
+	errcntrl(err)
+	if len(lost) > 0 {
+		fmt.Printf("%d values of %s cannot be written back and are left out:\n", len(lost), input_file_name)
+		for _, field := range lost {
+			fmt.Println(" ", field)
+		}
+		if *in_place_flag {
+			errcntrl(fmt.Errorf("not overwriting %s, values would be lost; write a copy with -o", input_file_name))
+		}
+	}
+
+	// the diff compares the file as it would be written without the fixes, so that it shows the
+	// fixes only and not the layout of the original
+	before, err := encode_metadata(data)
+	errcntrl(err)
+	changes := fix_metadata(&data)
+	for _, c := range changes {
+		fmt.Printf(" %s: %s\n   %q\n-> %q\n", c.Field, strings.Join(c.Reasons, ", "), c.Old, c.New)
+	}
+	for _, f := range check_ambiguous_dates(data, nil, Profile{}) {
+		fmt.Println(" not rewritten:", f.Message)
+	}
+	fixed, err := encode_metadata(data)
+	errcntrl(err)
+
+	output_file_name := *output_flag
+	switch {
+	case *in_place_flag:
+		output_file_name = input_file_path
+	case output_file_name == "":
+		output_file_name = filepath.Join(output_file_path, filepath.Base(input_file_name))
+	}
+	errcntrl(os.MkdirAll(filepath.Dir(output_file_name), os.ModePerm))
+	errcntrl(os.WriteFile(output_file_name, fixed, 0644))
+
+	diff := unified_diff(string(before), string(fixed), "a/"+filepath.ToSlash(input_file_name), "b/"+filepath.ToSlash(input_file_name))
+	diff_file_name := output_file_name + ".diff"
+	if *in_place_flag {
+		diff_file_name = filepath.Join(output_file_path, filepath.Base(input_file_name)+".diff")
+		errcntrl(os.MkdirAll(filepath.Dir(diff_file_name), os.ModePerm))
+	}
+	errcntrl(os.WriteFile(diff_file_name, []byte(diff), 0644))
+	fmt.Print(diff)
+
+	fmt.Printf("%d fixes applied, corrected metadata written to: %s\n", len(changes), output_file_name)
+	fmt.Println("Diff written to:", diff_file_name)
+}
+
+// the values of the original JSON that are lost when the metadata is written again: keys that are
+// not Yoda fields and non-empty values that are left out as zero values, as field paths
+func encoding_losses(original []byte, data Yoda18Metadata) ([]string, error) {
+	var before, after interface{}
+	if err := json.Unmarshal(original, &before); err != nil {
+		return nil, err
+	}
+	encoded, err := encode_metadata(data)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(encoded, &after); err != nil {
+		return nil, err
+	}
+	var lost []string
+	json_losses(before, after, "", &lost)
+	return lost, nil
+}
+
+// collect the paths of the values of before that are missing in after
+func json_losses(before interface{}, after interface{}, path string, lost *[]string) {
+	switch b := before.(type) {
+	case map[string]interface{}:
+		a, _ := after.(map[string]interface{})
+		keys := make([]string, 0, len(b))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := k
+			if path != "" {
+				field = path + "." + k
+			}
+			if v, ok := a[k]; ok {
+				json_losses(b[k], v, field, lost)
+			} else if !json_value_empty(b[k]) {
+				*lost = append(*lost, field)
+			}
+		}
+	case []interface{}:
+		a, _ := after.([]interface{})
+		for i, v := range b {
+			field := fmt.Sprintf("%s[%d]", path, i+1)
+			if i < len(a) {
+				json_losses(v, a[i], field, lost)
+			} else if !json_value_empty(v) {
+				*lost = append(*lost, field)
+			}
+		}
+	}
+}
+
+// a JSON value without content: null, "", or an object or list of such values
+func json_value_empty(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case map[string]interface{}:
+		for _, e := range x {
+			if !json_value_empty(e) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		for _, e := range x {
+			if !json_value_empty(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodingLosses(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{"nothing lost", `{"Title": "x", "Tag": ["a"], "Collected": {"Start_Date": "2020-01-01"}}`, nil},
+		{"empty values", `{"Title": "", "Tag": [], "Collected": {"Start_Date": ""}, "Funding_Reference": [{}]}`, nil},
+		{"unknown keys", `{"Title": "x", "Custom": "y", "Creator": [{"Name": {"Given_Name": "A", "Initials": "A."}}]}`,
+			[]string{"Creator[1].Name.Initials", "Custom"}},
+		{"zero value", `{"Retention_Period": 0}`, []string{"Retention_Period"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := test_metadata(t, tt.doc)
+			got, err := encoding_losses([]byte(tt.doc), data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// run readYmeta fix in dir
+func run_fix(t *testing.T, dir string, args ...string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(cwd) }()
+	fix_command(args)
+}
+
+func TestFixCommand(t *testing.T) {
+	// a layout the encoder does not write, so that a diff against the original would change every line
+	doc := `{"Title": "  Data  ", "Version": "1", "Tag": ["a", "b"], "Description": "text"}`
+	fixed := "\"Title\": \"Data\""
+	dir := t.TempDir()
+	work := filepath.Join(dir, "work")
+	if err := os.MkdirAll(work, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string) string {
+		fname := filepath.Join(dir, name)
+		if err := os.WriteFile(fname, []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return fname
+	}
+	read := func(fname string) string {
+		raw, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(raw)
+	}
+
+	for _, c := range []struct {
+		name   string
+		args   []string
+		input  string
+		output string
+		diff   string
+	}{
+		{"in place, absolute", []string{"--in-place", filepath.Join(dir, "abs.json")}, "abs.json", "abs.json", "work/output/abs.json.diff"},
+		{"in place, relative", []string{"--in-place", "../rel.json"}, "rel.json", "rel.json", "work/output/rel.json.diff"},
+		{"copy, relative", []string{"../copy.json"}, "copy.json", "work/output/copy.json", "work/output/copy.json.diff"},
+		{"copy, absolute", []string{filepath.Join(dir, "copyabs.json")}, "copyabs.json", "work/output/copyabs.json", "work/output/copyabs.json.diff"},
+	} {
+		input := write(c.input)
+		run_fix(t, work, c.args...)
+		if out := read(filepath.Join(dir, c.output)); !strings.Contains(out, fixed) {
+			t.Errorf("%s: %s is not fixed:\n%s", c.name, c.output, out)
+		}
+		if c.input != c.output && read(input) != doc {
+			t.Errorf("%s: the original is changed", c.name)
+		}
+		diff := read(filepath.Join(dir, c.diff))
+		var removed, added int
+		for _, line := range strings.Split(diff, "\n") {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			case strings.HasPrefix(line, "-"):
+				removed++
+			case strings.HasPrefix(line, "+"):
+				added++
+			}
+		}
+		if removed != 1 || added != 1 {
+			t.Errorf("%s: the diff changes %d and %d lines:\n%s", c.name, removed, added, diff)
+		}
+	}
+	// nothing is written outside the input and the output directory
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 {
+		t.Errorf("%d files in %s, want the 4 inputs and work", len(entries), dir)
+	}
+}
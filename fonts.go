@@ -0,0 +1,392 @@
+/*
+fonts.go Unicode fonts for the PDF report. The PDF core fonts only cover Latin-1, so names and
+titles in e.g. Polish, Turkish or Greek are embedded using a bundled TrueType font (DejaVu Sans
+Condensed). Fallback fonts can be added for scripts the main font does not cover (e.g. CJK), every
+character is written in the first of the main font and its fallbacks that has it. Arabic is
+shaped and right-to-left text is written in visual order (see rtl.go), the bundled font has no
+oblique Arabic so right-to-left text is written upright.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"golang.org/x/image/font/sfnt"
+)
+
+//go:embed fonts/*.ttf
+var builtin_fonts embed.FS
+
+// font names accepted by -font besides a path to a TTF file
+const font_name_builtin string = "dejavu"
+const font_name_core string = "core"
+
+// bundled font files per style
+var builtin_font_files = map[consts.Style]string{
+	consts.Normal:     "DejaVuSansCondensed.ttf",
+	consts.Bold:       "DejaVuSansCondensed-Bold.ttf",
+	consts.Italic:     "DejaVuSansCondensed-Oblique.ttf",
+	consts.BoldItalic: "DejaVuSansCondensed-BoldOblique.ttf",
+}
+
+// characters outside Latin-1 that the core fonts can render (Windows-1252)
+const cp1252_extra_chars string = "€‚ƒ„…†‡ˆ‰Š‹ŒŽ‘’“”•–—˜™š›œžŸ"
+
+// a registered font family and its glyph table
+type font_face struct {
+	family string
+	font   *sfnt.Font
+}
+
+// Maroto document that picks the font of every text from the main font and its fallbacks
+type fallback_maroto struct {
+	pdf.Maroto
+	faces []font_face
+}
+
+// register the main font and the fallback fonts with the document, name is "dejavu", "core" or a TTF path
+func setup_fonts(m pdf.Maroto, name string, fallbacks []string) (pdf.Maroto, error) {
+	var faces []font_face
+
+	// fonts are given as absolute paths, by default the PDF library resolves them relative to "."
+	if pm, ok := base_maroto(m); ok {
+		pm.Pdf.SetFontLocation("")
+	}
+
+	switch name {
+	case font_name_core, "":
+		if len(fallbacks) > 0 {
+			return m, fmt.Errorf("fallback fonts need a Unicode main font, not the core fonts")
+		}
+		return m, nil
+	case font_name_builtin:
+		dir, err := extract_builtin_fonts()
+		if err != nil {
+			return m, err
+		}
+		for style, file := range builtin_font_files {
+			m.AddUTF8Font(font_name_builtin, style, filepath.Join(dir, file))
+		}
+		face, err := load_font_face(font_name_builtin, filepath.Join(dir, builtin_font_files[consts.Normal]))
+		if err != nil {
+			return m, err
+		}
+		faces = append(faces, face)
+	default:
+		face, err := register_font_file(m, "main", name)
+		if err != nil {
+			return m, err
+		}
+		faces = append(faces, face)
+	}
+
+	for i, file := range fallbacks {
+		face, err := register_font_file(m, fmt.Sprintf("fallback%d", i+1), file)
+		if err != nil {
+			return m, err
+		}
+		faces = append(faces, face)
+	}
+
+	m.SetDefaultFontFamily(faces[0].family)
+	// the PDF library starts with a core font that is written to every new page, replace it
+	if pm, ok := base_maroto(m); ok {
+		pm.Pdf.SetFont(faces[0].family, "", fontsize)
+	}
+	return &fallback_maroto{Maroto: m, faces: faces}, nil
+}
+
+// register a single TTF file for all styles
+func register_font_file(m pdf.Maroto, family string, file string) (font_face, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return font_face{}, err
+	}
+	face, err := load_font_face(family, abs)
+	if err != nil {
+		return face, err
+	}
+	for _, style := range []consts.Style{consts.Normal, consts.Bold, consts.Italic, consts.BoldItalic} {
+		m.AddUTF8Font(family, style, abs)
+	}
+	return face, nil
+}
+
+// parse the glyph table of a font file
+func load_font_face(family string, file string) (font_face, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return font_face{}, err
+	}
+	f, err := sfnt.Parse(data)
+	if err != nil {
+		return font_face{}, fmt.Errorf("font \"%s\": %w", file, err)
+	}
+	return font_face{family: family, font: f}, nil
+}
+
+// write the bundled fonts to the user cache directory, the PDF library loads fonts from file
+func extract_builtin_fonts() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	dir = filepath.Join(dir, "readYmeta", "fonts-"+_MYVERSION_)
+	if err = os.MkdirAll(dir, os.ModePerm); err != nil {
+		return dir, err
+	}
+	for _, file := range builtin_font_files {
+		data, err := builtin_fonts.ReadFile("fonts/" + file)
+		if err != nil {
+			return dir, err
+		}
+		target := filepath.Join(dir, file)
+		if info, err := os.Stat(target); err == nil && info.Size() == int64(len(data)) {
+			continue
+		}
+		if err = os.WriteFile(target, data, 0644); err != nil {
+			return dir, err
+		}
+	}
+	return dir, nil
+}
+
+// does the font have a glyph for every printable character of text
+func (f font_face) covers(text string) bool {
+	var buf sfnt.Buffer
+	for _, r := range text {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			continue
+		}
+		if gi, err := f.font.GlyphIndex(&buf, r); err != nil || gi == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// the first font that can render the whole text, the main font if none can
+func (m *fallback_maroto) font_for(text string) string {
+	return m.faces[m.face_for(text, 0)].family
+}
+
+// index of the first font that can render the whole text, the preferred font when it can, the
+// main font if none can
+func (m *fallback_maroto) face_for(text string, preferred int) int {
+	if m.faces[preferred].covers(text) {
+		return preferred
+	}
+	for i, f := range m.faces {
+		if f.covers(text) {
+			return i
+		}
+	}
+	return 0
+}
+
+// part of a text that is written in one font
+type font_run struct {
+	family string
+	text   string
+}
+
+// split text into runs per font, a character stays in the font of the run before it when that
+// font has it, so that e.g. spaces and punctuation do not switch fonts
+func (m *fallback_maroto) text_runs(text string) []font_run {
+	var runs []font_run
+	face := 0
+	for _, r := range text {
+		face = m.face_for(string(r), face)
+		if n := len(runs); n > 0 && runs[n-1].family == m.faces[face].family {
+			runs[n-1].text += string(r)
+			continue
+		}
+		runs = append(runs, font_run{family: m.faces[face].family, text: string(r)})
+	}
+	return runs
+}
+
+// characters of text that none of the fonts can render
+func (m *fallback_maroto) missing_glyphs(text string) []rune {
+	var missing []rune
+	seen := map[rune]bool{}
+	for _, r := range text {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+		found := false
+		for _, f := range m.faces {
+			if f.covers(string(r)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, r)
+		}
+	}
+	return missing
+}
+
+// Text with the font family chosen per character, an explicit family is kept
+func (m *fallback_maroto) Text(text string, prop ...props.Text) {
+	p := props.Text{}
+	if len(prop) > 0 {
+		p = prop[0]
+	}
+	if p.Family != "" {
+		m.Maroto.Text(text, p)
+		return
+	}
+	runs := m.text_runs(text)
+	pm, ok := base_maroto(m)
+	var geometry column_geometry
+	if ok {
+		geometry, ok = maroto_column(pm)
+	}
+	if (len(runs) < 2 && !has_rtl(text)) || !ok {
+		p.Family = m.font_for(text)
+		m.Maroto.Text(rtl_line(text), p)
+		return
+	}
+	m.write_runs(pm, geometry, text, p)
+}
+
+// the position of the current column of a Maroto document
+type column_geometry struct {
+	x, width, row_height, offset_y float64
+}
+
+// Maroto does not export the position of the current column, it is read from its fields; ok is
+// false when a Maroto version does not have them
+func maroto_column(pm *pdf.PdfMaroto) (column_geometry, bool) {
+	col := reflect.ValueOf(pm).Elem()
+	var values [4]float64
+	for i, name := range []string{"xColOffset", "colWidth", "rowHeight", "offsetY"} {
+		f := col.FieldByName(name)
+		if !f.IsValid() || f.Kind() != reflect.Float64 {
+			return column_geometry{}, false
+		}
+		values[i] = f.Float()
+	}
+	return column_geometry{x: values[0], width: values[1], row_height: values[2], offset_y: values[3]}, true
+}
+
+// write text that needs more than one font or is written right to left, Maroto writes a text in a
+// single font and in logical order so the runs are placed here the way Maroto places a text in the
+// current column
+func (m *fallback_maroto) write_runs(pm *pdf.PdfMaroto, col column_geometry, text string, p props.Text) {
+	p.MakeValid(m.faces[0].family)
+	x, width := col.x, col.width
+	if p.Top > col.row_height {
+		p.Top = col.row_height
+	}
+	pm.Pdf.SetFont(m.faces[0].family, string(p.Style), p.Size)
+	_, lineheight := pm.Pdf.GetFontSize()
+	y := col.offset_y + p.Top + lineheight
+
+	lines := []string{text}
+	if !p.Extrapolate && strings.Contains(text, " ") && text_width(m, pm, text, p.Style, p.Size) >= width {
+		lines = wrap_text(m, text, width, p.Size, p.Style)
+	}
+
+	r, g, b := pm.Pdf.GetTextColor()
+	pm.Pdf.SetTextColor(p.Color.Red, p.Color.Green, p.Color.Blue)
+	left, top, _, _ := pm.Pdf.GetMargins()
+	for _, line := range lines {
+		dx := 0.0
+		switch p.Align {
+		case consts.Left:
+		case consts.Right:
+			dx = width - text_width(m, pm, line, p.Style, p.Size)
+		default:
+			dx = (width - text_width(m, pm, line, p.Style, p.Size)) / 2
+		}
+		pos := x + left + dx
+		for _, run := range m.text_runs(rtl_line(line)) {
+			pm.Pdf.SetFont(run.family, string(run_style(run, p.Style)), p.Size)
+			pm.Pdf.Text(pos, y+top, run.text)
+			pos += pm.Pdf.GetStringWidth(run.text)
+		}
+		y += lineheight + p.VerticalPadding
+	}
+	pm.Pdf.SetTextColor(r, g, b)
+	pm.Pdf.SetFont(m.faces[0].family, string(p.Style), p.Size)
+}
+
+// the style a run is written in, the oblique styles of the bundled font have no Arabic
+func run_style(run font_run, style consts.Style) consts.Style {
+	if run.family != font_name_builtin || !has_rtl(run.text) {
+		return style
+	}
+	switch style {
+	case consts.Italic:
+		return consts.Normal
+	case consts.BoldItalic:
+		return consts.Bold
+	}
+	return style
+}
+
+// the underlying Maroto implementation, used where the PDF library is needed directly
+func base_maroto(m pdf.Maroto) (*pdf.PdfMaroto, bool) {
+	if fm, ok := m.(*fallback_maroto); ok {
+		m = fm.Maroto
+	}
+	pm, ok := m.(*pdf.PdfMaroto)
+	return pm, ok
+}
+
+// width in mm of text in the given style and size, summed over the fonts the text is written in
+func text_width(m pdf.Maroto, pm *pdf.PdfMaroto, text string, style consts.Style, size float64) float64 {
+	fm, ok := m.(*fallback_maroto)
+	if !ok {
+		family := m.GetDefaultFontFamily()
+		pm.Pdf.SetFont(family, string(style), size)
+		if is_core_font(family) {
+			text = pm.Pdf.UnicodeTranslatorFromDescriptor("")(text)
+		}
+		return pm.Pdf.GetStringWidth(text)
+	}
+	width := 0.0
+	for _, run := range fm.text_runs(shape_arabic(text)) {
+		pm.Pdf.SetFont(run.family, string(run_style(run, style)), size)
+		width += pm.Pdf.GetStringWidth(run.text)
+	}
+	return width
+}
+
+// warn about characters in the metadata that cannot be rendered with the configured fonts
+func check_font_coverage(m pdf.Maroto, data Yoda18Metadata) {
+	var text strings.Builder
+	walk_metadata_strings(reflect.ValueOf(&data).Elem(), "", func(field string, s string) string {
+		text.WriteString(s)
+		return s
+	})
+	var missing []rune
+	if fm, ok := m.(*fallback_maroto); ok {
+		missing = fm.missing_glyphs(text.String())
+	} else {
+		for _, r := range text.String() {
+			if r > unicode.MaxLatin1 && !strings.ContainsRune(cp1252_extra_chars, r) && !strings.ContainsRune(string(missing), r) {
+				missing = append(missing, r)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("Warning: %d characters cannot be rendered with the selected fonts: %s (use -font-fallback <font.ttf>)\n",
+			len(missing), string(missing))
+	}
+}
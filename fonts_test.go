@@ -0,0 +1,117 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// Go Regular (no Arabic) as the main font with the bundled DejaVu as fallback
+func test_fallback_faces(t *testing.T) []font_face {
+	t.Helper()
+	main, err := sfnt.Parse(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := builtin_fonts.ReadFile("fonts/" + builtin_font_files[consts.Normal])
+	if err != nil {
+		t.Fatal(err)
+	}
+	fallback, err := sfnt.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []font_face{{family: "main", font: main}, {family: "fallback1", font: fallback}}
+}
+
+func TestTextRuns(t *testing.T) {
+	m := &fallback_maroto{faces: test_fallback_faces(t)}
+	tests := []struct {
+		text string
+		want []font_run
+	}{
+		{"", nil},
+		{"Zażółć", []font_run{{"main", "Zażółć"}}},
+		{"data اختبار", []font_run{{"main", "data "}, {"fallback1", "اختبار"}}},
+		{"اختبار data", []font_run{{"fallback1", "اختبار data"}}},
+		{"a ب c", []font_run{{"main", "a "}, {"fallback1", "ب c"}}},
+	}
+	for _, tt := range tests {
+		if got := m.text_runs(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("text_runs(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+	if got := m.missing_glyphs("data 中文"); string(got) != "中文" {
+		t.Errorf("missing_glyphs = %q, want 中文", string(got))
+	}
+}
+
+func TestMixedFontText(t *testing.T) {
+	main := filepath.Join(t.TempDir(), "Go-Regular.ttf")
+	if err := os.WriteFile(main, goregular.TTF, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := extract_builtin_fonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := setup_fonts(pdf.NewMaroto(consts.Portrait, consts.A4), main,
+		[]string{filepath.Join(dir, builtin_font_files[consts.Normal])})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pm, _ := base_maroto(m)
+	text := "Multilingual data اختبار البيانات"
+	latin := text_width(m, pm, "Multilingual data ", consts.Normal, 10)
+	arabic := text_width(m, pm, "اختبار البيانات", consts.Normal, 10)
+	if got := text_width(m, pm, text, consts.Normal, 10); got != latin+arabic {
+		t.Errorf("text_width = %f, want %f", got, latin+arabic)
+	}
+
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text(text, props.Text{Align: consts.Center})
+		})
+	})
+	if _, err = m.Output(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUnicodeReport(t *testing.T) {
+	data, err := read_metadata_file(filepath.Join("test-data", "yoda-metadata[unicode].json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := load_profile(default_profile_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "yoda-metadata[unicode].json"
+	doc, err := create_pdf_report(data, name, create_metadata_report(data, name, profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := pdf_file_bytes(doc, data, name, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) < 1000 || string(out[:5]) != "%PDF-" {
+		t.Errorf("no PDF written, %d bytes", len(out))
+	}
+	// the Chinese names need a fallback font, everything else is covered by the bundled font
+	fm, ok := doc.(*fallback_maroto)
+	if !ok {
+		t.Fatal("the report is not written with the bundled font")
+	}
+	if got := string(fm.missing_glyphs(data.Contributor[1].Name.FamilyName + data.Contributor[2].Name.FamilyName)); got != "王" {
+		t.Errorf("missing glyphs %q, want 王", got)
+	}
+}
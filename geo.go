@@ -0,0 +1,346 @@
+/*
+geo.go geolocation of the data package. The place names (Covered_Geolocation_Place) and the
+bounding boxes (GeoLocation.geoLocationBox) are written to the report with a static map of the
+covered area, drawn offline from a low-resolution world outline compiled into readYmeta. The
+boxes are also exported as GeoJSON (<name>.geojson) next to the PDF.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// coarse outline of the continents and large islands, GeoJSON polygons in degrees
+//
+//go:embed maps/world.geojson
+var world_outline_data []byte
+
+// the world outline as rings of (longitude, latitude) points
+var world_outline = load_world_outline()
+
+// height of the map in mm, the map is twice as wide as it is high
+const map_height float64 = 60
+
+// smallest latitude span of the map in degrees, so that a small area is shown with its surroundings
+const map_min_span float64 = 16
+
+// a bounding box in degrees, a box with West > East crosses the antimeridian
+type GeoBox struct {
+	West  float64
+	East  float64
+	South float64
+	North float64
+}
+
+// GeoJSON output, Coordinates holds a Polygon or a MultiPolygon
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Properties map[string]interface{} `json:"properties"`
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+}
+
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// read the world outline, it is part of the program so an error is fatal
+func load_world_outline() [][][2]float64 {
+	var outline struct {
+		Features []struct {
+			Geometry struct {
+				Coordinates [][][2]float64 `json:"coordinates"`
+			} `json:"geometry"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(world_outline_data, &outline); err != nil {
+		panic(fmt.Sprintf("world outline: %v", err))
+	}
+	var rings [][][2]float64
+	for _, f := range outline.Features {
+		rings = append(rings, f.Geometry.Coordinates...)
+	}
+	return rings
+}
+
+// bounding box of GeoLocation entry i, ok is false when the entry has no box
+func geo_box(data Yoda18Metadata, i int) (GeoBox, bool) {
+	b := data.GeoLocation[i].GeoLocationBox
+	if b == nil {
+		return GeoBox{}, false
+	}
+	return GeoBox{West: b.WestBoundLongitude, East: b.EastBoundLongitude, South: b.SouthBoundLatitude, North: b.NorthBoundLatitude}, true
+}
+
+// reason why a box is invalid, "" for a valid box
+func check_geo_box(b GeoBox) string {
+	switch {
+	case b.South < -90 || b.South > 90 || b.North < -90 || b.North > 90:
+		return tr("geo.invalid_latitude")
+	case b.West < -180 || b.West > 180 || b.East < -180 || b.East > 180:
+		return tr("geo.invalid_longitude")
+	case b.South > b.North:
+		return tr("geo.invalid_south_north")
+	}
+	return ""
+}
+
+// the valid bounding boxes of the metadata
+func valid_geo_boxes(data Yoda18Metadata) []GeoBox {
+	var boxes []GeoBox
+	for i := range data.GeoLocation {
+		if box, ok := geo_box(data, i); ok && check_geo_box(box) == "" {
+			boxes = append(boxes, box)
+		}
+	}
+	return boxes
+}
+
+func check_geolocation_boxes(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for i := range data.GeoLocation {
+		box, ok := geo_box(data, i)
+		if !ok {
+			continue
+		}
+		if reason := check_geo_box(box); reason != "" {
+			findings = append(findings, Finding{Field: fmt.Sprintf("GeoLocation[%d].geoLocationBox", i+1),
+				Message: tr("msg.geolocation_box", i+1, reason)})
+		}
+	}
+	return findings
+}
+
+// a coordinate with its hemisphere, e.g. 52.0907°N
+func format_coordinate(v float64, positive string, negative string) string {
+	if v < 0 {
+		return fmt.Sprintf("%.4f°%s", -v, tr(negative))
+	}
+	return fmt.Sprintf("%.4f°%s", v, tr(positive))
+}
+
+// a box as its south west and north east corners
+func (b GeoBox) String() string {
+	return tr("geo.box",
+		format_coordinate(b.South, "geo.north", "geo.south")+" "+format_coordinate(b.West, "geo.east", "geo.west"),
+		format_coordinate(b.North, "geo.north", "geo.south")+" "+format_coordinate(b.East, "geo.east", "geo.west"))
+}
+
+// the box as one or two (across the antimeridian) west to east boxes
+func (b GeoBox) parts() []GeoBox {
+	if b.West <= b.East {
+		return []GeoBox{b}
+	}
+	return []GeoBox{{West: b.West, East: 180, South: b.South, North: b.North}, {West: -180, East: b.East, South: b.South, North: b.North}}
+}
+
+// area shown on the map: the boxes with a margin around them, twice as wide as high and within the world
+func map_extent(boxes []GeoBox) GeoBox {
+	world := GeoBox{West: -180, East: 180, South: -90, North: 90}
+	var all []GeoBox
+	for _, b := range boxes {
+		all = append(all, b.parts()...)
+	}
+	if len(all) == 0 || len(all) > len(boxes) {
+		return world
+	}
+	ext := all[0]
+	for _, b := range all[1:] {
+		ext = GeoBox{West: math.Min(ext.West, b.West), East: math.Max(ext.East, b.East),
+			South: math.Min(ext.South, b.South), North: math.Max(ext.North, b.North)}
+	}
+	span := math.Max(math.Max(ext.North-ext.South, (ext.East-ext.West)/2)*2, map_min_span)
+	if span >= 180 {
+		return world
+	}
+	clamp := func(centre float64, half float64, limit float64) float64 {
+		return math.Min(math.Max(centre, -limit+half), limit-half)
+	}
+	lat := clamp((ext.South+ext.North)/2, span/2, 90)
+	lon := clamp((ext.West+ext.East)/2, span, 180)
+	return GeoBox{West: lon - span, East: lon + span, South: lat - span/2, North: lat + span/2}
+}
+
+// draw the world outline and the boxes in the area extent, in a map of w x h mm at x, y
+func pdf_draw_map(pm *pdf.PdfMaroto, boxes []GeoBox, extent GeoBox, x float64, y float64, w float64, h float64) {
+	project := func(lon float64, lat float64) (float64, float64) {
+		return x + (lon-extent.West)/(extent.East-extent.West)*w, y + (extent.North-lat)/(extent.North-extent.South)*h
+	}
+	fr, fg, fb := pm.Pdf.GetFillColor()
+	dr, dg, db := pm.Pdf.GetDrawColor()
+	lw := pm.Pdf.GetLineWidth()
+
+	// sea, land and the frame
+	pm.Pdf.SetFillColor(232, 241, 250)
+	pm.Pdf.Rect(x, y, w, h, "F")
+	pm.Pdf.ClipRect(x, y, w, h, false)
+	pm.Pdf.SetFillColor(205, 205, 205)
+	pm.Pdf.SetDrawColor(150, 150, 150)
+	pm.Pdf.SetLineWidth(0.1)
+	for _, ring := range world_outline {
+		var points []gofpdf.PointType
+		for _, p := range ring {
+			px, py := project(p[0], p[1])
+			points = append(points, gofpdf.PointType{X: px, Y: py})
+		}
+		pm.Pdf.Polygon(points, "FD")
+	}
+
+	// the covered area, boxes too small to see are drawn as a dot
+	c := theme_colour(REPORT_THEME.Colours.Highlight, pdfGreen())
+	pm.Pdf.SetDrawColor(c.Red, c.Green, c.Blue)
+	pm.Pdf.SetFillColor(c.Red, c.Green, c.Blue)
+	pm.Pdf.SetLineWidth(0.5)
+	for _, b := range boxes {
+		for _, part := range b.parts() {
+			x1, y1 := project(part.West, part.North)
+			x2, y2 := project(part.East, part.South)
+			if x2-x1 < 1 && y2-y1 < 1 {
+				pm.Pdf.Circle((x1+x2)/2, (y1+y2)/2, 0.8, "F")
+				continue
+			}
+			pm.Pdf.Rect(x1, y1, x2-x1, y2-y1, "D")
+		}
+	}
+	pm.Pdf.ClipEnd()
+
+	pm.Pdf.SetDrawColor(0, 0, 0)
+	pm.Pdf.SetLineWidth(0.2)
+	pm.Pdf.Rect(x, y, w, h, "D")
+	pm.Pdf.SetFillColor(fr, fg, fb)
+	pm.Pdf.SetDrawColor(dr, dg, db)
+	pm.Pdf.SetLineWidth(lw)
+}
+
+// geolocation section: place names, bounding boxes and a map of the covered area
+func pdf_write_geolocation(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, emptyrowheight float64) {
+	pdf_bookmark("label.geolocation", 0)
+	pdf_write_row(m, tr("label.geolocation"), rowheight, colwidth, consts.Bold, pdfBlack())
+	if len(data.CoveredGeolocationPlace) > 0 || len(data.GeoLocation) == 0 {
+		pdf_write_list(m, data.CoveredGeolocationPlace, rowheight, colwidth, consts.Normal, pdfBlack())
+	}
+	for i, loc := range data.GeoLocation {
+		label := strings.TrimSpace(loc.DescriptionSpatial)
+		if label == "" {
+			label = tr("geo.area", i+1)
+		}
+		box, ok := geo_box(data, i)
+		switch {
+		case !ok:
+			pdf_write_row_tuple_indent(m, label, "", rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+		case check_geo_box(box) != "":
+			pdf_write_row_tuple_indent(m, label, box.String()+" ("+check_geo_box(box)+")", rowheight, colwidth, consts.Normal, pdfErrorColour(), 1)
+		default:
+			pdf_write_row_tuple_indent(m, label, box.String(), rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+		}
+	}
+
+	boxes := valid_geo_boxes(data)
+	pm, ok := base_maroto(m)
+	if len(boxes) == 0 || !ok {
+		return
+	}
+	pdf_write_empty_row(m, emptyrowheight, colwidth)
+	m.Row(map_height, func() {})
+	left, _, _, _ := m.GetPageMargins()
+	extent := map_extent(boxes)
+	pdf_draw_map(pm, boxes, extent, left+grid_width(m, 1), pm.Pdf.GetY()-map_height, 2*map_height, map_height)
+	m.Row(rowheight, func() {
+		m.ColSpace(1)
+		m.Col(colwidth-1, func() {
+			m.Text(tr("geo.map_caption", format_coordinate(extent.South, "geo.north", "geo.south"), format_coordinate(extent.North, "geo.north", "geo.south"),
+				format_coordinate(extent.West, "geo.east", "geo.west"), format_coordinate(extent.East, "geo.east", "geo.west")),
+				props.Text{Size: fontsize - 2, Style: consts.Italic, Color: pdfBlack()})
+		})
+	})
+}
+
+// the boxes as a GeoJSON feature collection, nil when there are no valid boxes
+func geolocation_geojson(data Yoda18Metadata) *GeoJSONFeatureCollection {
+	collection := GeoJSONFeatureCollection{Type: "FeatureCollection", Features: []GeoJSONFeature{}}
+	for i, loc := range data.GeoLocation {
+		box, ok := geo_box(data, i)
+		if !ok || check_geo_box(box) != "" {
+			continue
+		}
+		// RFC 7946: a box across the antimeridian is split in two polygons
+		var polygons [][][][2]float64
+		for _, p := range box.parts() {
+			polygons = append(polygons, [][][2]float64{{{p.West, p.South}, {p.East, p.South}, {p.East, p.North}, {p.West, p.North}, {p.West, p.South}}})
+		}
+		geometry := GeoJSONGeometry{Type: "Polygon", Coordinates: polygons[0]}
+		if len(polygons) > 1 {
+			geometry = GeoJSONGeometry{Type: "MultiPolygon", Coordinates: polygons}
+		}
+		collection.Features = append(collection.Features, GeoJSONFeature{
+			Type: "Feature",
+			Properties: map[string]interface{}{
+				"index":       i + 1,
+				"description": strings.TrimSpace(loc.DescriptionSpatial),
+				"title":       data.Title,
+			},
+			Geometry: geometry,
+		})
+	}
+	if len(collection.Features) == 0 {
+		return nil
+	}
+	return &collection
+}
+
+// write the boxes as GeoJSON, nothing is written when there are no valid boxes
+func write_geojson(data Yoda18Metadata, fname string) error {
+	collection := geolocation_geojson(data)
+	if collection == nil {
+		return nil
+	}
+	out, err := json.MarshalIndent(collection, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fname, out, 0644)
+}
+
+// place names and boxes as Markdown
+func md_geolocation(data Yoda18Metadata) string {
+	var places []string
+	for _, p := range data.CoveredGeolocationPlace {
+		if strings.TrimSpace(p) != "" {
+			places = append(places, p)
+		}
+	}
+	if len(places) == 0 && len(data.GeoLocation) == 0 {
+		return ""
+	}
+	out := fmt.Sprintln("\n## " + tr("md.geolocation"))
+	for _, p := range places {
+		out += fmt.Sprintf("- %s: %s\n", tr("md.place"), p)
+	}
+	for i, loc := range data.GeoLocation {
+		if box, ok := geo_box(data, i); ok {
+			label := strings.TrimSpace(loc.DescriptionSpatial)
+			if label == "" {
+				label = tr("geo.area", i+1)
+			}
+			out += fmt.Sprintf("- %s: %s\n", label, box)
+		}
+	}
+	return out
+}
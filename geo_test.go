@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestGeoBoxPresence(t *testing.T) {
+	data := test_metadata(t, `{"GeoLocation": [
+		{"geoLocationBox": {"westBoundLongitude": 0, "eastBoundLongitude": 0, "southBoundLatitude": 0, "northBoundLatitude": 0}, "Description_Spatial": "Null Island"},
+		{"Description_Spatial": "no box"}]}`)
+	if box, ok := geo_box(data, 0); !ok || box != (GeoBox{}) {
+		t.Errorf("all-zero box: %v, %v", box, ok)
+	}
+	if _, ok := geo_box(data, 1); ok {
+		t.Error("a box for an entry without one")
+	}
+	out, err := json.Marshal(Yoda18MetadataV2(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(out), "geoLocationBox"); got != 1 {
+		t.Errorf("%d boxes written: %s", got, out)
+	}
+}
+
+func TestCheckGeoBox(t *testing.T) {
+	tests := []struct {
+		name string
+		box  GeoBox
+		want string
+	}{
+		{"valid", GeoBox{West: 4.7, East: 5.1, South: 52.2, North: 52.5}, ""},
+		{"zero", GeoBox{}, ""},
+		{"antimeridian", GeoBox{West: 170, East: -170, South: -20, North: -10}, ""},
+		{"latitude", GeoBox{West: 0, East: 1, South: 0, North: 91}, tr("geo.invalid_latitude")},
+		{"longitude", GeoBox{West: -181, East: 1, South: 0, North: 1}, tr("geo.invalid_longitude")},
+		{"south of north", GeoBox{West: 0, East: 1, South: 10, North: 5}, tr("geo.invalid_south_north")},
+	}
+	for _, tt := range tests {
+		if got := check_geo_box(tt.box); got != tt.want {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMapExtent(t *testing.T) {
+	world := GeoBox{West: -180, East: 180, South: -90, North: 90}
+	tests := []struct {
+		name  string
+		boxes []GeoBox
+		want  GeoBox
+	}{
+		{"no boxes", nil, world},
+		// a small area is shown with the smallest span around its centre
+		{"small", []GeoBox{{West: 4.7, East: 5.1, South: 52.2, North: 52.5}}, GeoBox{West: -11.1, East: 20.9, South: 44.35, North: 60.35}},
+		{"two boxes", []GeoBox{{West: 0, East: 10, South: 0, North: 10}, {West: 20, East: 30, South: 20, North: 30}},
+			GeoBox{West: -45, East: 75, South: -15, North: 45}},
+		// the map stays within the world
+		{"pole", []GeoBox{{West: 0, East: 10, South: 85, North: 89}}, GeoBox{West: -11, East: 21, South: 74, North: 90}},
+		{"large", []GeoBox{{West: -170, East: 170, South: -10, North: 10}}, world},
+		{"antimeridian", []GeoBox{{West: 170, East: -170, South: -20, North: -10}}, world},
+	}
+	near := func(a GeoBox, b GeoBox) bool {
+		return math.Abs(a.West-b.West) < 1e-9 && math.Abs(a.East-b.East) < 1e-9 &&
+			math.Abs(a.South-b.South) < 1e-9 && math.Abs(a.North-b.North) < 1e-9
+	}
+	for _, tt := range tests {
+		if got := map_extent(tt.boxes); !near(got, tt.want) {
+			t.Errorf("%s: %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGeolocationGeoJSON(t *testing.T) {
+	if geolocation_geojson(test_metadata(t, `{"GeoLocation": [{"Description_Spatial": "no box"}]}`)) != nil {
+		t.Error("GeoJSON without boxes")
+	}
+	data := test_metadata(t, `{"Title": "Boxes", "GeoLocation": [
+		{"geoLocationBox": {"westBoundLongitude": 0, "eastBoundLongitude": 0, "southBoundLatitude": 0, "northBoundLatitude": 0}},
+		{"Description_Spatial": "no box"},
+		{"geoLocationBox": {"westBoundLongitude": 170, "eastBoundLongitude": -170, "southBoundLatitude": -20, "northBoundLatitude": -10}, "Description_Spatial": " Fiji "},
+		{"geoLocationBox": {"westBoundLongitude": 0, "eastBoundLongitude": 1, "southBoundLatitude": 10, "northBoundLatitude": 5}}]}`)
+	out, err := json.Marshal(geolocation_geojson(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"type":"FeatureCollection","features":[` +
+		`{"type":"Feature","properties":{"description":"","index":1,"title":"Boxes"},` +
+		`"geometry":{"type":"Polygon","coordinates":[[[0,0],[0,0],[0,0],[0,0],[0,0]]]}},` +
+		`{"type":"Feature","properties":{"description":"Fiji","index":3,"title":"Boxes"},` +
+		`"geometry":{"type":"MultiPolygon","coordinates":[[[[170,-20],[180,-20],[180,-10],[170,-10],[170,-20]]],` +
+		`[[[-180,-20],[-170,-20],[-170,-10],[-180,-10],[-180,-20]]]]}}]}`
+	if string(out) != want {
+		t.Errorf("GeoJSON\n%s\nwant\n%s", out, want)
+	}
+}
@@ -2,12 +2,18 @@ module readYmeta
 
 go 1.18
 
-require github.com/johnfercher/maroto v0.37.0
+require (
+	github.com/BurntSushi/toml v1.2.1
+	github.com/johnfercher/maroto v0.37.0
+	github.com/jung-kurt/gofpdf v1.16.2
+	golang.org/x/image v0.5.0
+	golang.org/x/text v0.7.0
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/boombuler/barcode v1.0.1 // indirect
 	github.com/google/uuid v1.3.0 // indirect
-	github.com/jung-kurt/gofpdf v1.16.2 // indirect
 	github.com/ruudk/golang-pdf417 v0.0.0-20201230142125-a7e3863a1245 // indirect
 	github.com/stretchr/testify v1.8.0 // indirect
 )
@@ -0,0 +1,182 @@
+/*
+history.go the readYmeta history subcommand, a provenance report of a data package. Yoda keeps a copy
+of the metadata for every change, named yoda-metadata[<unix timestamp>][<user>].json, in the package
+folder. Usage: readYmeta history [-o <file>] [-theme <name|path>] [-lang <code>] <package directory>
+		The snapshots are ordered by timestamp, followed by the current yoda-metadata.json, and every
+		step is compared with the diff engine (diff.go) to show who changed what and when.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+// name of the current metadata file of a package
+const current_metadata_file_name string = "yoda-metadata.json"
+
+// yoda-metadata[<unix timestamp>][<user>].json, the user may include the iRODS zone (user#zone)
+var snapshot_pattern = regexp.MustCompile(`^yoda-metadata\[(\d+)\]\[([^\]]*)\]\.json$`)
+
+// a version of the metadata of a package, User is empty for the current file
+type MetadataSnapshot struct {
+	File    string
+	Time    time.Time
+	User    string
+	Current bool
+	Data    Yoda18Metadata
+}
+
+// readYmeta history: report the changes across all metadata snapshots of a package
+func history_command(args []string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	output_flag := fs.String("o", "", "PDF history report, defaults to output/<package directory>.history.pdf")
+	theme := fs.String("theme", default_theme_name, "report theme, a builtin theme name or the path to a YAML/TOML theme file")
+	lang := fs.String("lang", language_auto, "report language: "+language_auto+" (the Language field of the latest metadata) or one of "+
+		strings.Join(list_languages(), ", "))
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta history [options] <package directory>")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
+	errcntrl(check_language(*lang))
+
+	snapshots, err := scan_snapshots(dir)
+	errcntrl(err)
+	if len(snapshots) == 0 {
+		errcntrl(fmt.Errorf("no metadata snapshots or %s found in %s", current_metadata_file_name, dir))
+	}
+	REPORT_THEME, err = load_theme(*theme)
+	errcntrl(err)
+	apply_theme_fonts(REPORT_THEME, fs)
+	REPORT_LANGUAGE = select_language(*lang, snapshots[len(snapshots)-1].Data)
+
+	fmt.Printf("%s: %s\n", snapshot_label(snapshots[0]), snapshots[0].File)
+	for i := 1; i < len(snapshots); i++ {
+		changes := compare_metadata(snapshots[i-1].Data, snapshots[i].Data)
+		fmt.Printf("%s: %s, %d changes\n", snapshot_label(snapshots[i]), snapshots[i].File, len(changes))
+		for _, c := range changes {
+			fmt.Println("  " + format_change(c))
+		}
+	}
+
+	output_file_name := *output_flag
+	if output_file_name == "" {
+		abs, _ := filepath.Abs(dir)
+		output_file_name = filepath.Join("output", filepath.Base(abs)+".history.pdf")
+	}
+	errcntrl(os.MkdirAll(filepath.Dir(output_file_name), os.ModePerm))
+	doc, err := render_history_report(snapshots, dir)
+	errcntrl(err)
+	errcntrl(write_pdf_file(doc, snapshots[len(snapshots)-1].Data, output_file_name, false))
+	fmt.Println("History report written to:", output_file_name)
+}
+
+// the snapshots of a package directory ordered by timestamp, the current metadata file comes last
+func scan_snapshots(dir string) ([]MetadataSnapshot, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []MetadataSnapshot
+	for _, e := range entries {
+		m := snapshot_pattern.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		seconds, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", e.Name(), err)
+		}
+		data, err := read_metadata_file(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("snapshot %s: %w", e.Name(), err)
+		}
+		snapshots = append(snapshots, MetadataSnapshot{File: e.Name(), Time: time.Unix(seconds, 0), User: m[2], Data: data})
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool { return snapshots[i].Time.Before(snapshots[j].Time) })
+
+	current := filepath.Join(dir, current_metadata_file_name)
+	if info, err := os.Stat(current); err == nil && !info.IsDir() {
+		data, err := read_metadata_file(current)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", current, err)
+		}
+		snapshots = append(snapshots, MetadataSnapshot{File: current_metadata_file_name, Time: info.ModTime(), Current: true, Data: data})
+	}
+	return snapshots, nil
+}
+
+// when and by whom a snapshot was made
+func snapshot_label(s MetadataSnapshot) string {
+	when := s.Time.Format("2006-01-02 15:04")
+	switch {
+	case s.Current:
+		return tr("history.current", when)
+	case s.User == "":
+		return tr("history.by", when, tr("history.unknown_user"))
+	}
+	return tr("history.by", when, s.User)
+}
+
+// render the PDF history report: an overview of the snapshots and the changes of every step
+func render_history_report(snapshots []MetadataSnapshot, dir string) (pdf.Maroto, error) {
+	var ctime = time.Now().String()
+	var colwidth uint = 12
+	var rowheight float64 = 4
+	var empty_line_height float64 = 2
+
+	name := filepath.Base(dir)
+	if abs, err := filepath.Abs(dir); err == nil {
+		name = filepath.Base(abs)
+	}
+	doc, err := new_report_document(tr("history.header", name), name, ctime, rowheight, colwidth)
+	if err != nil {
+		return doc, err
+	}
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+	pdf_write_row(doc, tr("history.title", name), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_row(doc, tr("history.summary", len(snapshots), first.Time.Format("2006-01-02"), last.Time.Format("2006-01-02")),
+		rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+
+	// overview, one row per snapshot
+	var rows [][]table_cell
+	for i, s := range snapshots {
+		changes := "-"
+		if i > 0 {
+			changes = fmt.Sprint(len(compare_metadata(snapshots[i-1].Data, s.Data)))
+		}
+		rows = append(rows, []table_cell{text_cell(snapshot_label(s)), text_cell(s.File), text_cell(s.Data.Version), text_cell(changes)})
+	}
+	pdf_write_table(doc, []string{tr("history.snapshot"), tr("history.file"), tr("label.version"), tr("history.changes")},
+		[]uint{4, 4, 2, 2}, rows, rowheight)
+	pdf_write_empty_row(doc, empty_line_height*4, colwidth)
+
+	// the changes of every step
+	pdf_write_row(doc, tr("history.initial", snapshot_label(first), first.File), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	for i := 1; i < len(snapshots); i++ {
+		pdf_write_row(doc, tr("history.step", snapshot_label(snapshots[i]), snapshots[i].File), rowheight, colwidth, consts.Bold, pdfBlack())
+		pdf_write_changes(doc, compare_metadata(snapshots[i-1].Data, snapshots[i].Data), rowheight, colwidth)
+		pdf_write_empty_row(doc, empty_line_height*4, colwidth)
+	}
+	return doc, nil
+}
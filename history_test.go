@@ -0,0 +1,35 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestScanSnapshots(t *testing.T) {
+	snapshots, err := scan_snapshots(filepath.Join("test-data", "history"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		file string
+		user string
+	}{
+		{"yoda-metadata[1659427200][researcher#vu].json", "researcher#vu"},
+		{"yoda-metadata[1659513600][researcher#vu].json", "researcher#vu"},
+		{"yoda-metadata[1659600000][datamanager#vu].json", "datamanager#vu"},
+		{current_metadata_file_name, ""},
+	}
+	if len(snapshots) != len(want) {
+		t.Fatalf("got %d snapshots, want %d", len(snapshots), len(want))
+	}
+	for i, w := range want {
+		if snapshots[i].File != w.file || snapshots[i].User != w.user || snapshots[i].Current != (i == len(want)-1) {
+			t.Errorf("snapshot %d is %s by %q, want %s by %q", i, snapshots[i].File, snapshots[i].User, w.file, w.user)
+		}
+	}
+	for i := 1; i < len(snapshots); i++ {
+		if changes := compare_metadata(snapshots[i-1].Data, snapshots[i].Data); len(changes) == 0 {
+			t.Errorf("no changes between %s and %s", snapshots[i-1].File, snapshots[i].File)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+i18n.go localised reports. Every label of the PDF and Markdown reports and every validation message
+is looked up by key in a message catalogue (locales/<language>.yaml), messages missing from a
+catalogue fall back on English. The language is set with -lang or taken from the Language field of
+the metadata.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// message catalogues shipped with readYmeta
+//
+//go:embed locales/*.yaml
+var builtin_locales embed.FS
+
+const default_language string = "en"
+
+// -lang value that selects the language of the metadata
+const language_auto string = "auto"
+
+// language of the reports being written, set per metadata file
+var REPORT_LANGUAGE string = default_language
+
+// all catalogues by language code
+var message_catalogues = load_catalogues()
+
+// read the builtin catalogues, they are part of the program so an error is fatal
+func load_catalogues() map[string]map[string]string {
+	catalogues := map[string]map[string]string{}
+	entries, _ := builtin_locales.ReadDir("locales")
+	for _, e := range entries {
+		data, err := builtin_locales.ReadFile("locales/" + e.Name())
+		if err != nil {
+			panic(err)
+		}
+		messages := map[string]string{}
+		if err := yaml.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("message catalogue %s: %v", e.Name(), err))
+		}
+		catalogues[strings.TrimSuffix(e.Name(), ".yaml")] = messages
+	}
+	return catalogues
+}
+
+// language codes of the catalogues
+func list_languages() []string {
+	var codes []string
+	for code := range message_catalogues {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// localised message for key, formatted with args, the key itself when no catalogue has it
+func tr(key string, args ...interface{}) string {
+	msg, ok := message_catalogues[REPORT_LANGUAGE][key]
+	if !ok {
+		msg, ok = message_catalogues[default_language][key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// report language for lang, for "auto" the Yoda Language field ("nl - Dutch") is used if there is a catalogue for it
+func select_language(lang string, data Yoda18Metadata) string {
+	if lang != language_auto {
+		return lang
+	}
+	code := strings.ToLower(strings.TrimSpace(strings.SplitN(data.Language, "-", 2)[0]))
+	if _, ok := message_catalogues[code]; ok {
+		return code
+	}
+	return default_language
+}
+
+// check a -lang value
+func check_language(lang string) error {
+	if _, ok := message_catalogues[lang]; ok || lang == language_auto {
+		return nil
+	}
+	return fmt.Errorf("unknown language \"%s\", use %s or %s", lang, language_auto, strings.Join(list_languages(), ", "))
+}
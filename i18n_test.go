@@ -0,0 +1,116 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// the formatting verbs of a message in order, e.g. [%s %d]
+var message_verbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+func TestMessageCatalogues(t *testing.T) {
+	if got := list_languages(); !reflect.DeepEqual(got, []string{"en", "nl"}) {
+		t.Fatalf("languages %v", got)
+	}
+	en := message_catalogues[default_language]
+	for _, lang := range list_languages() {
+		messages := message_catalogues[lang]
+		if len(messages) == 0 {
+			t.Errorf("%s: empty catalogue", lang)
+		}
+		for key, msg := range en {
+			translated, ok := messages[key]
+			switch {
+			case !ok:
+				t.Errorf("%s: no message for %s", lang, key)
+			case !reflect.DeepEqual(message_verbs.FindAllString(translated, -1), message_verbs.FindAllString(msg, -1)):
+				t.Errorf("%s: %s %q does not have the verbs of %q", lang, key, translated, msg)
+			}
+		}
+		for key := range messages {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s: %s is not in the English catalogue", lang, key)
+			}
+		}
+	}
+}
+
+// every message key written literally in the code and the templates is in the English catalogue
+func TestMessageKeysUsed(t *testing.T) {
+	literal := regexp.MustCompile(`\btr\(?\s*"([a-z_]+\.[^"%]+)"`)
+	files, _ := filepath.Glob("*.go")
+	templates, _ := filepath.Glob(filepath.Join("templates", "*.tmpl"))
+	var missing []string
+	for _, fname := range append(files, templates...) {
+		if strings.HasSuffix(fname, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range literal.FindAllStringSubmatch(string(src), -1) {
+			if _, ok := message_catalogues[default_language][m[1]]; !ok {
+				missing = append(missing, fname+": "+m[1])
+			}
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("message keys not in the catalogue:\n%s", strings.Join(missing, "\n"))
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	saved := REPORT_LANGUAGE
+	defer func() { REPORT_LANGUAGE = saved }()
+	tests := []struct {
+		lang string
+		key  string
+		args []interface{}
+		want string
+	}{
+		{"en", "label.contributors", nil, "Contributors"},
+		{"en", "score.filled", []interface{}{2, 3}, "2 of 3"},
+		{"nl", "link.unresolved", nil, " [geen link]"},
+		// an unknown language falls back on English, an unknown key is returned as it is
+		{"xx", "label.contributors", nil, "Contributors"},
+		{"nl", "no.such.key", nil, "no.such.key"},
+	}
+	for _, tt := range tests {
+		REPORT_LANGUAGE = tt.lang
+		if got := tr(tt.key, tt.args...); got != tt.want {
+			t.Errorf("%s %s: %q, want %q", tt.lang, tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestSelectLanguage(t *testing.T) {
+	tests := []struct {
+		lang     string
+		language string
+		want     string
+	}{
+		{language_auto, "nl - Dutch", "nl"},
+		{language_auto, " NL - Nederlands", "nl"},
+		{language_auto, "en - English", "en"},
+		{language_auto, "de - German", default_language},
+		{language_auto, "", default_language},
+		{"nl", "en - English", "nl"},
+	}
+	for _, tt := range tests {
+		if got := select_language(tt.lang, Yoda18Metadata{Language: tt.language}); got != tt.want {
+			t.Errorf("select_language(%s, %q) = %s, want %s", tt.lang, tt.language, got, tt.want)
+		}
+	}
+	for lang, ok := range map[string]bool{"en": true, "nl": true, language_auto: true, "de": false} {
+		if err := check_language(lang); (err == nil) != ok {
+			t.Errorf("check_language(%s): %v", lang, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+/*
+icc.go a minimal sRGB ICC (v2) display profile, used as the output intent of PDF/A documents.
+The profile is generated rather than shipped: D50 adapted sRGB primaries and the sRGB tone curve.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+const srgb_profile_name string = "sRGB IEC61966-2.1"
+
+// number of entries of the tone curve table
+const srgb_curve_points int = 1024
+
+// an ICC tag: signature and data
+type icc_tag struct {
+	signature string
+	data      []byte
+}
+
+// build the sRGB ICC profile
+func srgb_icc_profile() []byte {
+	curve := icc_srgb_curve()
+	tags := []icc_tag{
+		{"desc", icc_desc(srgb_profile_name)},
+		{"cprt", icc_text("No copyright, use freely")},
+		{"wtpt", icc_xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", icc_xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", icc_xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", icc_xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	// tag data follows the header (128 bytes) and the tag table, aligned to 4 bytes
+	offset := 128 + 4 + 12*len(tags)
+	var table, body bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	for _, t := range tags {
+		table.WriteString(t.signature)
+		binary.Write(&table, binary.BigEndian, uint32(offset+body.Len()))
+		binary.Write(&table, binary.BigEndian, uint32(len(t.data)))
+		body.Write(t.data)
+		for body.Len()%4 != 0 {
+			body.WriteByte(0)
+		}
+	}
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint32(offset+body.Len()))
+	header.Write(make([]byte, 4))                               // preferred CMM
+	binary.Write(&header, binary.BigEndian, uint32(0x02100000)) // version 2.1
+	header.WriteString("mntrRGB XYZ ")
+	binary.Write(&header, binary.BigEndian, []uint16{2023, 1, 1, 0, 0, 0})
+	header.WriteString("acsp")
+	header.Write(make([]byte, 4+4+4+4+8+4)) // platform, flags, manufacturer, model, attributes, intent
+	header.Write(icc_xyz(0.9642, 1.0, 0.8249)[8:])
+	header.Write(make([]byte, 128-header.Len()))
+
+	return append(append(header.Bytes(), table.Bytes()...), body.Bytes()...)
+}
+
+// s15Fixed16 XYZ number tag
+func icc_xyz(x float64, y float64, z float64) []byte {
+	var b bytes.Buffer
+	b.WriteString("XYZ ")
+	b.Write(make([]byte, 4))
+	for _, v := range []float64{x, y, z} {
+		binary.Write(&b, binary.BigEndian, int32(math.Round(v*65536)))
+	}
+	return b.Bytes()
+}
+
+// text tag, null terminated ASCII
+func icc_text(s string) []byte {
+	return append([]byte("text\x00\x00\x00\x00"+s), 0)
+}
+
+// v2 text description tag, ASCII only with empty Unicode and ScriptCode parts
+func icc_desc(s string) []byte {
+	var b bytes.Buffer
+	b.WriteString("desc")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(len(s)+1))
+	b.WriteString(s)
+	b.WriteByte(0)
+	b.Write(make([]byte, 4+4+2+1+67))
+	return b.Bytes()
+}
+
+// sRGB tone curve sampled as a table
+func icc_srgb_curve() []byte {
+	var b bytes.Buffer
+	b.WriteString("curv")
+	b.Write(make([]byte, 4))
+	binary.Write(&b, binary.BigEndian, uint32(srgb_curve_points))
+	for i := 0; i < srgb_curve_points; i++ {
+		v := float64(i) / float64(srgb_curve_points-1)
+		if v <= 0.04045 {
+			v = v / 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(&b, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+	return b.Bytes()
+}
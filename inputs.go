@@ -0,0 +1,317 @@
+/*
+inputs.go the sources readYmeta reads metadata from. Besides a metadata file an input can be "-"
+(stdin, for use in pipelines), a data package directory, a .zip or .tar.gz package export or a
+file inside one (export.zip/research-x/yoda-metadata.json), or an iRODS collection (irods.go).
+In a directory or archive the yoda-metadata.json closest to the top is used.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// input name for reading the metadata from stdin
+const stdin_input_name string = "-"
+
+// the largest metadata file read from stdin or an archive
+const max_metadata_size int64 = 64 << 20
+
+// archive formats by file name extension
+var archive_extensions = []string{".zip", ".tar.gz", ".tgz"}
+
+// a source of Yoda metadata
+type input_source interface {
+	// read the metadata, name is the input as shown in the reports
+	Read() (data Yoda18Metadata, name string, err error)
+	// output file name without extension, relative to the output directory
+	OutputBase() string
+}
+
+// the input source for a command line argument
+func open_input_source(arg string) (input_source, error) {
+	switch {
+	case arg == stdin_input_name:
+		return stdin_source{}, nil
+	case is_irods_input(arg):
+		return open_irods_source(arg)
+	}
+	info, err := os.Stat(arg)
+	switch {
+	case err == nil && info.IsDir():
+		return directory_source{Dir: arg}, nil
+	case err == nil && archive_extension(arg) != "":
+		return archive_source{Archive: arg}, nil
+	case err == nil:
+		return file_source{File: arg}, nil
+	}
+	// a path inside an archive
+	if archive, member, ok := split_archive_path(arg); ok {
+		return archive_source{Archive: archive, Member: member}, nil
+	}
+	return file_source{File: arg}, nil
+}
+
+// the extension of an archive file name, empty for other files
+func archive_extension(fname string) string {
+	for _, ext := range archive_extensions {
+		if strings.HasSuffix(strings.ToLower(fname), ext) {
+			return ext
+		}
+	}
+	return ""
+}
+
+// split a path such as export.zip/research-x/yoda-metadata.json into the archive and the path inside it
+func split_archive_path(arg string) (string, string, bool) {
+	p := filepath.Clean(arg)
+	for {
+		dir := filepath.Dir(p)
+		if dir == p {
+			return "", "", false
+		}
+		if info, err := os.Stat(dir); err == nil && !info.IsDir() {
+			if archive_extension(dir) == "" {
+				return "", "", false
+			}
+			member, _ := filepath.Rel(dir, arg)
+			return dir, filepath.ToSlash(member), true
+		}
+		p = dir
+	}
+}
+
+// the metadata file among the names of a directory or archive: member itself or else the
+// yoda-metadata.json closest to the top below member
+func locate_metadata_file(names []string, member string, where string) (string, error) {
+	member = strings.Trim(path.Clean("/"+member), "/")
+	var candidates []string
+	for _, name := range names {
+		if member != "" && name == member {
+			return name, nil
+		}
+		if path.Base(name) == current_metadata_file_name && (member == "" || strings.HasPrefix(name, member+"/")) {
+			candidates = append(candidates, name)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no %s found in %s", current_metadata_file_name, path.Join(where, member))
+	}
+	depth := func(name string) int { return strings.Count(name, "/") }
+	sort.SliceStable(candidates, func(i, j int) bool { return depth(candidates[i]) < depth(candidates[j]) })
+	if len(candidates) > 1 && depth(candidates[0]) == depth(candidates[1]) {
+		return "", fmt.Errorf("%s contains more than one data package (%s, %s, ...), give the path of one, e.g. %s",
+			where, candidates[0], candidates[1], path.Join(where, path.Dir(candidates[0])))
+	}
+	return candidates[0], nil
+}
+
+// read a metadata file of at most max_metadata_size bytes
+func read_metadata_limited(r io.Reader) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, max_metadata_size+1))
+	if err == nil && int64(len(raw)) > max_metadata_size {
+		err = fmt.Errorf("larger than %d MB", max_metadata_size>>20)
+	}
+	return raw, err
+}
+
+// decode metadata read from a source
+func decode_metadata(raw []byte, name string) (Yoda18Metadata, error) {
+	var data Yoda18Metadata
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return data, fmt.Errorf("%s: %w", name, err)
+	}
+	return data, nil
+}
+
+// a metadata file, the output keeps the path of the input below the output directory
+type file_source struct {
+	File string
+}
+
+func (s file_source) Read() (Yoda18Metadata, string, error) {
+	data, err := read_metadata_file(s.File)
+	return data, s.File, err
+}
+
+func (s file_source) OutputBase() string {
+	return strings.TrimSuffix(s.File, filepath.Ext(s.File))
+}
+
+// metadata piped to stdin
+type stdin_source struct{}
+
+func (s stdin_source) Read() (Yoda18Metadata, string, error) {
+	raw, err := read_metadata_limited(os.Stdin)
+	if err != nil {
+		return Yoda18Metadata{}, "stdin", err
+	}
+	data, err := decode_metadata(raw, "stdin")
+	return data, "stdin", err
+}
+
+func (s stdin_source) OutputBase() string {
+	return "stdin"
+}
+
+// a data package directory
+type directory_source struct {
+	Dir string
+}
+
+func (s directory_source) Read() (Yoda18Metadata, string, error) {
+	var names []string
+	err := filepath.WalkDir(s.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && d.Name() == current_metadata_file_name {
+			rel, _ := filepath.Rel(s.Dir, p)
+			names = append(names, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return Yoda18Metadata{}, s.Dir, err
+	}
+	name, err := locate_metadata_file(names, "", s.Dir)
+	if err != nil {
+		return Yoda18Metadata{}, s.Dir, err
+	}
+	fname := filepath.Join(s.Dir, filepath.FromSlash(name))
+	data, err := read_metadata_file(fname)
+	return data, fname, err
+}
+
+func (s directory_source) OutputBase() string {
+	abs, err := filepath.Abs(s.Dir)
+	if err != nil {
+		return filepath.Base(s.Dir)
+	}
+	return filepath.Base(abs)
+}
+
+// a .zip or .tar.gz data package export, Member is a file or folder inside it
+type archive_source struct {
+	Archive string
+	Member  string
+}
+
+func (s archive_source) Read() (Yoda18Metadata, string, error) {
+	var files map[string][]byte
+	var err error
+	if archive_extension(s.Archive) == ".zip" {
+		files, err = read_zip_metadata_files(s.Archive, s.Member)
+	} else {
+		files, err = read_tar_metadata_files(s.Archive, s.Member)
+	}
+	if err != nil {
+		return Yoda18Metadata{}, s.Archive, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	name, err := locate_metadata_file(names, s.Member, s.Archive)
+	if err != nil {
+		return Yoda18Metadata{}, s.Archive, err
+	}
+	full := filepath.ToSlash(s.Archive) + "/" + name
+	data, err := decode_metadata(files[name], full)
+	return data, full, err
+}
+
+func (s archive_source) OutputBase() string {
+	base := filepath.Base(s.Archive)
+	base = base[:len(base)-len(archive_extension(base))]
+	if member := archive_entry_name(s.Member); member != "" {
+		// keep the path inside the archive, as for files
+		return filepath.Join(base, filepath.FromSlash(strings.TrimSuffix(member, path.Ext(member))))
+	}
+	return base
+}
+
+// the name of an archive entry as a clean relative path
+func archive_entry_name(name string) string {
+	return strings.Trim(path.Clean("/"+filepath.ToSlash(name)), "/")
+}
+
+// an archive entry that may hold the metadata: member itself or a yoda-metadata.json file
+func is_metadata_entry(name string, member string) bool {
+	return name == archive_entry_name(member) || path.Base(name) == current_metadata_file_name
+}
+
+// the contents of the metadata files of a zip archive by name
+func read_zip_metadata_files(archive string, member string) (map[string][]byte, error) {
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	files := make(map[string][]byte)
+	for _, f := range r.File {
+		name := archive_entry_name(f.Name)
+		if f.FileInfo().IsDir() || !is_metadata_entry(name, member) {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", archive, name, err)
+		}
+		raw, err := read_metadata_limited(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", archive, name, err)
+		}
+		files[name] = raw
+	}
+	return files, nil
+}
+
+// the contents of the metadata files of a gzipped tar archive by name
+func read_tar_metadata_files(archive string, member string) (map[string][]byte, error) {
+	f, err := os.Open(archive)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", archive, err)
+	}
+	defer gz.Close()
+	files := make(map[string][]byte)
+	tarball := tar.NewReader(gz)
+	for {
+		hdr, err := tarball.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", archive, err)
+		}
+		name := archive_entry_name(hdr.Name)
+		if hdr.Typeflag != tar.TypeReg || !is_metadata_entry(name, member) {
+			continue
+		}
+		raw, err := read_metadata_limited(tarball)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", archive, name, err)
+		}
+		files[name] = raw
+	}
+	return files, nil
+}
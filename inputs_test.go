@@ -0,0 +1,206 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the metadata of a data package with title
+func test_package_json(title string) []byte {
+	return []byte(`{"Title": "` + title + `"}`)
+}
+
+// write files (name -> contents) below dir
+func write_test_files(t *testing.T, dir string, files map[string][]byte) {
+	t.Helper()
+	for name, raw := range files {
+		fname := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fname, raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// write a .zip or .tar.gz archive of files (name -> contents)
+func write_test_archive(t *testing.T, fname string, files map[string][]byte) {
+	t.Helper()
+	f, err := os.Create(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if strings.HasSuffix(fname, ".zip") {
+		w := zip.NewWriter(f)
+		for name, raw := range files {
+			fw, err := w.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fw.Write(raw)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, raw := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(raw)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(raw)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInputSources(t *testing.T) {
+	dir := t.TempDir()
+	one := map[string][]byte{
+		"research-x/yoda-metadata.json":          test_package_json("top"),
+		"research-x/original/yoda-metadata.json": test_package_json("nested"),
+		"research-x/data.csv":                    []byte("a,b\n"),
+	}
+	two := map[string][]byte{
+		"research-x/yoda-metadata.json": test_package_json("x"),
+		"research-y/yoda-metadata.json": test_package_json("y"),
+	}
+	write_test_files(t, filepath.Join(dir, "package"), one)
+	write_test_files(t, filepath.Join(dir, "packages"), two)
+	for _, ext := range []string{".zip", ".tar.gz"} {
+		write_test_archive(t, filepath.Join(dir, "export"+ext), one)
+		write_test_archive(t, filepath.Join(dir, "exports"+ext), two)
+	}
+
+	tests := []struct {
+		arg   string
+		title string
+		base  string
+		err   string
+	}{
+		{"package", "top", "package", ""},
+		{"package/research-x/original", "nested", "original", ""},
+		{"packages", "", "packages", "more than one data package"},
+		{"export.zip", "top", "export", ""},
+		{"export.zip/research-x/original/yoda-metadata.json", "nested", filepath.Join("export", "research-x", "original", "yoda-metadata"), ""},
+		{"export.zip/research-x/original", "nested", filepath.Join("export", "research-x", "original"), ""},
+		{"exports.zip", "", "exports", "more than one data package"},
+		{"exports.zip/research-y", "y", filepath.Join("exports", "research-y"), ""},
+		{"export.tar.gz", "top", "export", ""},
+		{"export.tar.gz/research-x/original/yoda-metadata.json", "nested", filepath.Join("export", "research-x", "original", "yoda-metadata"), ""},
+		{"exports.tar.gz", "", "exports", "more than one data package"},
+		{"export.zip/research-z", "", filepath.Join("export", "research-z"), "no yoda-metadata.json found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			source, err := open_input_source(filepath.Join(dir, filepath.FromSlash(tt.arg)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _, err := source.Read()
+			switch {
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("error %v, want %q", err, tt.err)
+			case tt.err == "" && err != nil:
+				t.Errorf("error %v", err)
+			case data.Title != tt.title:
+				t.Errorf("title %q, want %q", data.Title, tt.title)
+			}
+			if got := source.OutputBase(); got != tt.base && !strings.HasSuffix(got, string(filepath.Separator)+tt.base) {
+				t.Errorf("output base %q, want %q", got, tt.base)
+			}
+		})
+	}
+}
+
+func TestStdinSource(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "stdin.json")
+	if err := os.WriteFile(fname, test_package_json("piped"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	stdin := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = stdin }()
+
+	source, err := open_input_source(stdin_input_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, name, err := source.Read()
+	if err != nil || data.Title != "piped" || name != "stdin" || source.OutputBase() != "stdin" {
+		t.Errorf("stdin: %q, %q, %v, output base %q", data.Title, name, err, source.OutputBase())
+	}
+}
+
+func TestSplitArchivePath(t *testing.T) {
+	dir := t.TempDir()
+	write_test_archive(t, filepath.Join(dir, "export.zip"), map[string][]byte{"a/yoda-metadata.json": test_package_json("a")})
+	write_test_files(t, dir, map[string][]byte{"notes.txt": []byte("notes")})
+	tests := []struct {
+		arg     string
+		archive string
+		member  string
+		ok      bool
+	}{
+		{"export.zip/a/yoda-metadata.json", "export.zip", "a/yoda-metadata.json", true},
+		{"export.zip/a", "export.zip", "a", true},
+		{"notes.txt/a", "", "", false},
+		{"missing.zip/a", "", "", false},
+		{"export.zip", "", "", false},
+	}
+	for _, tt := range tests {
+		archive, member, ok := split_archive_path(filepath.Join(dir, filepath.FromSlash(tt.arg)))
+		if ok != tt.ok || (ok && (archive != filepath.Join(dir, tt.archive) || member != tt.member)) {
+			t.Errorf("split_archive_path(%s) = %q, %q, %v, want %q, %q, %v", tt.arg, archive, member, ok, tt.archive, tt.member, tt.ok)
+		}
+	}
+}
+
+func TestLocateMetadataFile(t *testing.T) {
+	tests := []struct {
+		names  []string
+		member string
+		want   string
+		err    string
+	}{
+		{[]string{"a/b/yoda-metadata.json", "a/yoda-metadata.json"}, "", "a/yoda-metadata.json", ""},
+		{[]string{"a/yoda-metadata.json", "b/yoda-metadata.json"}, "", "", "more than one data package"},
+		{[]string{"a/yoda-metadata.json", "b/yoda-metadata.json"}, "b", "b/yoda-metadata.json", ""},
+		{[]string{"a/yoda-metadata.json", "a/other.json"}, "a/other.json", "a/other.json", ""},
+		{[]string{"a/data.csv"}, "", "", "no yoda-metadata.json found"},
+	}
+	for _, tt := range tests {
+		got, err := locate_metadata_file(tt.names, tt.member, "export.zip")
+		if got != tt.want || (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("locate_metadata_file(%v, %q) = %q, %v, want %q, %q", tt.names, tt.member, got, err, tt.want, tt.err)
+		}
+	}
+}
+
+func TestReadMetadataLimited(t *testing.T) {
+	big := strings.NewReader(strings.Repeat(" ", int(max_metadata_size)+1))
+	if _, err := read_metadata_limited(big); err == nil {
+		t.Error("no error for metadata larger than the limit")
+	}
+	if raw, err := read_metadata_limited(strings.NewReader("{}")); err != nil || string(raw) != "{}" {
+		t.Errorf("read %q, %v", raw, err)
+	}
+}
@@ -0,0 +1,370 @@
+/*
+irods.go reading Yoda metadata directly from iRODS. An input of the form irods:<collection> reads
+the yoda-metadata.json data object of the collection or, when there is none, the usr_* AVUs of the
+collection. Connection settings come from the iRODS environment file (~/.irods/irods_environment.json
+or $IRODS_ENVIRONMENT_FILE). The iRODS access goes through the irods_client interface, implemented
+with the iRODS icommands (iget, imeta), which have to be installed and on the PATH.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// prefix of an iRODS input, irods:/<zone>/home/<group>/<collection> or a path relative to the iRODS home
+const irods_prefix string = "irods:"
+
+// prefix of the user metadata attributes of a Yoda collection
+const irods_avu_prefix string = "usr_"
+
+// connection settings of an iRODS environment file
+type IrodsEnvironment struct {
+	Host                 string `json:"irods_host"`
+	Port                 int    `json:"irods_port"`
+	UserName             string `json:"irods_user_name"`
+	ZoneName             string `json:"irods_zone_name"`
+	Home                 string `json:"irods_home"`
+	Cwd                  string `json:"irods_cwd"`
+	AuthenticationScheme string `json:"irods_authentication_scheme"`
+	File                 string `json:"-"`
+}
+
+// an attribute, value, units triple of iRODS metadata
+type IrodsAVU struct {
+	Attribute string `json:"attribute"`
+	Value     string `json:"value"`
+	Units     string `json:"units"`
+}
+
+// access to an iRODS zone
+type irods_client interface {
+	// the contents of a data object, os.ErrNotExist when there is no such object
+	ReadDataObject(object_path string) ([]byte, error)
+	// the AVUs of a collection
+	CollectionAVUs(collection string) ([]IrodsAVU, error)
+}
+
+// the default environment file, $IRODS_ENVIRONMENT_FILE or ~/.irods/irods_environment.json
+func default_irods_environment_file() string {
+	if env := os.Getenv("IRODS_ENVIRONMENT_FILE"); env != "" {
+		return env
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".irods", "irods_environment.json")
+}
+
+// read the connection settings of an iRODS environment file
+func load_irods_environment(fname string) (IrodsEnvironment, error) {
+	var env IrodsEnvironment
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return env, fmt.Errorf("iRODS environment: %w", err)
+	}
+	if err = json.Unmarshal(raw, &env); err != nil {
+		return env, fmt.Errorf("iRODS environment %s: %w", fname, err)
+	}
+	env.File = fname
+	if env.Port == 0 {
+		env.Port = 1247
+	}
+	if env.Home == "" && env.ZoneName != "" && env.UserName != "" {
+		env.Home = "/" + env.ZoneName + "/home/" + env.UserName
+	}
+	if env.Cwd == "" {
+		env.Cwd = env.Home
+	}
+	return env, nil
+}
+
+// the connection settings as host:port/zone (user)
+func (env IrodsEnvironment) String() string {
+	return fmt.Sprintf("%s:%d/%s (%s)", env.Host, env.Port, env.ZoneName, env.UserName)
+}
+
+// an input refers to iRODS when it starts with irods:
+func is_irods_input(fname string) bool {
+	return strings.HasPrefix(fname, irods_prefix)
+}
+
+// the absolute collection path of an iRODS input, relative paths are taken from the iRODS working directory
+func irods_collection_path(fname string, env IrodsEnvironment) (string, error) {
+	p := strings.TrimPrefix(fname, irods_prefix)
+	// irods://<zone>/... is an absolute path as well
+	if strings.HasPrefix(p, "//") {
+		p = p[1:]
+	}
+	if !strings.HasPrefix(p, "/") {
+		if env.Cwd == "" {
+			return "", fmt.Errorf("%s: a relative iRODS path needs irods_home or irods_zone_name and irods_user_name in the iRODS environment", fname)
+		}
+		p = path.Join(env.Cwd, p)
+	}
+	p = path.Clean(p)
+	if path.Base(p) == current_metadata_file_name {
+		p = path.Dir(p)
+	}
+	return p, nil
+}
+
+// the iRODS client selected on the command line and the environment it connects to
+func new_irods_client() (irods_client, IrodsEnvironment, error) {
+	env_file := *irods_env_flag
+	if env_file == "" {
+		env_file = default_irods_environment_file()
+	}
+	env, err := load_irods_environment(env_file)
+	if err != nil {
+		return nil, env, err
+	}
+	if _, err := exec.LookPath("iget"); err != nil {
+		return nil, env, fmt.Errorf("reading from iRODS needs the iRODS icommands (iget, imeta) on the PATH: %w", err)
+	}
+	return icommands_irods_client{Env: env}, env, nil
+}
+
+// an iRODS collection as input source
+type irods_source struct {
+	Client     irods_client
+	Collection string
+}
+
+// connect to iRODS for an irods:<collection> input
+func open_irods_source(arg string) (input_source, error) {
+	client, env, err := new_irods_client()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Println("Using iRODS environment:", env.File, env)
+	collection, err := irods_collection_path(arg, env)
+	if err != nil {
+		return nil, err
+	}
+	return irods_source{Client: client, Collection: collection}, nil
+}
+
+func (s irods_source) Read() (Yoda18Metadata, string, error) {
+	data, name, err := read_irods_metadata(s.Client, s.Collection)
+	return data, irods_prefix + name, err
+}
+
+func (s irods_source) OutputBase() string {
+	return path.Base(s.Collection)
+}
+
+// read the metadata of an iRODS collection, from yoda-metadata.json or else from the usr_* AVUs
+func read_irods_metadata(client irods_client, collection string) (Yoda18Metadata, string, error) {
+	var data Yoda18Metadata
+	object := path.Join(collection, current_metadata_file_name)
+	raw, err := client.ReadDataObject(object)
+	if err == nil {
+		err = json.Unmarshal(raw, &data)
+		return data, object, err
+	}
+	if !os.IsNotExist(err) {
+		return data, object, err
+	}
+	avus, err := client.CollectionAVUs(collection)
+	if err != nil {
+		return data, collection, err
+	}
+	data, used, unknown, err := avus_to_metadata(avus)
+	for _, a := range unknown {
+		fmt.Printf("Ignoring iRODS attribute %s of %s\n", a, collection)
+	}
+	if err == nil && used == 0 {
+		err = fmt.Errorf("%s: no %s and no %s* metadata attributes", collection, current_metadata_file_name, irods_avu_prefix)
+	}
+	return data, collection + " (AVUs)", err
+}
+
+// the struct fields of the metadata by their AVU name, e.g. Creator_Name_Given_Name
+func metadata_avu_fields() map[string][]int {
+	fields := make(map[string][]int)
+	var walk func(t reflect.Type, name string, index []int)
+	walk = func(t reflect.Type, name string, index []int) {
+		for (t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr) && t.Elem().Kind() == reflect.Struct {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			fields[name] = index
+			return
+		}
+		for i := 0; i < t.NumField(); i++ {
+			tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			sub := tag
+			if name != "" {
+				sub = name + "_" + tag
+			}
+			walk(t.Field(i).Type, sub, append(append([]int{}, index...), i))
+		}
+	}
+	walk(reflect.TypeOf(Yoda18Metadata{}), "", nil)
+	return fields
+}
+
+var avu_units_index = regexp.MustCompile(`\d+`)
+
+// the largest list a units index may ask for, so a bad index does not allocate a huge list
+const avu_max_list_length = 10000
+
+// build the metadata from usr_* AVUs, the units hold the list indices along the path (e.g. 1_0 for
+// the first affiliation of the second creator), values of a string list without an index are appended.
+// Other attributes (e.g. org_*) are skipped, usr_* attributes that are not a metadata field are returned
+func avus_to_metadata(avus []IrodsAVU) (Yoda18Metadata, int, []string, error) {
+	var data Yoda18Metadata
+	var used int
+	var unknown []string
+	fields := metadata_avu_fields()
+	for _, a := range avus {
+		if !strings.HasPrefix(a.Attribute, irods_avu_prefix) {
+			continue
+		}
+		index, ok := fields[strings.TrimPrefix(a.Attribute, irods_avu_prefix)]
+		if !ok {
+			unknown = append(unknown, a.Attribute)
+			continue
+		}
+		var positions []int
+		for _, n := range avu_units_index.FindAllString(a.Units, -1) {
+			p, err := strconv.Atoi(n)
+			if err != nil {
+				p = avu_max_list_length
+			}
+			positions = append(positions, p)
+		}
+		if err := set_metadata_value(reflect.ValueOf(&data).Elem(), index, positions, a.Value); err != nil {
+			return data, used, unknown, fmt.Errorf("attribute %s: %w", a.Attribute, err)
+		}
+		used++
+	}
+	return data, used, unknown, nil
+}
+
+// set the field at index to value, growing the lists on the way to the positions given
+func set_metadata_value(v reflect.Value, index []int, positions []int, value string) error {
+	for _, i := range index {
+		v = v.Field(i)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		for v.Kind() == reflect.Slice {
+			p := v.Len()
+			if len(positions) > 0 {
+				p, positions = positions[0], positions[1:]
+			} else if v.Type().Elem().Kind() == reflect.Struct {
+				p = 0
+			}
+			if p >= avu_max_list_length {
+				return fmt.Errorf("list position %d is larger than %d", p, avu_max_list_length-1)
+			}
+			if p >= v.Len() {
+				v.Set(reflect.AppendSlice(v, reflect.MakeSlice(v.Type(), p-v.Len()+1, p-v.Len()+1)))
+			}
+			v = v.Index(p)
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(value)
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported field type %s", v.Type())
+	}
+	return nil
+}
+
+// iRODS client using the icommands, which connect with the same environment file
+type icommands_irods_client struct {
+	Env IrodsEnvironment
+}
+
+// run an icommand with the environment file of the client
+func (c icommands_irods_client) run(name string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command(name, args...)
+	cmd.Env = append(os.Environ(), "IRODS_ENVIRONMENT_FILE="+c.Env.File)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "does not exist") || strings.Contains(msg, "CAT_NO_ROWS_FOUND") {
+			return out, os.ErrNotExist
+		}
+		return out, fmt.Errorf("%s %s: %v %s", name, strings.Join(args, " "), err, msg)
+	}
+	return out, nil
+}
+
+// iget <object> - writes the data object to stdout
+func (c icommands_irods_client) ReadDataObject(object_path string) ([]byte, error) {
+	return c.run("iget", object_path, "-")
+}
+
+// imeta ls -C <collection>
+func (c icommands_irods_client) CollectionAVUs(collection string) ([]IrodsAVU, error) {
+	out, err := c.run("imeta", "ls", "-C", collection)
+	if err != nil {
+		return nil, err
+	}
+	return parse_imeta_output(out), nil
+}
+
+// the AVUs of imeta ls output, blocks of attribute:, value: and units: lines separated by ----
+func parse_imeta_output(out []byte) []IrodsAVU {
+	var avus []IrodsAVU
+	var avu *IrodsAVU
+	in_value := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(make([]byte, 64*1024), len(out)+1)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "attribute: "):
+			avus = append(avus, IrodsAVU{Attribute: strings.TrimPrefix(line, "attribute: ")})
+			avu = &avus[len(avus)-1]
+			in_value = false
+		case avu != nil && strings.HasPrefix(line, "value: "):
+			avu.Value = strings.TrimPrefix(line, "value: ")
+			in_value = true
+		case avu != nil && strings.HasPrefix(line, "units:"):
+			avu.Units = strings.TrimSpace(strings.TrimPrefix(line, "units:"))
+			in_value = false
+		case strings.HasPrefix(line, "----"):
+			in_value = false
+		case in_value:
+			// imeta writes the lines of a multi-line value as they are
+			avu.Value += "\n" + line
+		}
+	}
+	return avus
+}
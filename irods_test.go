@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// file with the AVUs of a collection in a local stand-in zone
+const irods_local_avu_file string = ".avus.json"
+
+// local stand-in for an iRODS zone: /<zone>/... is the directory <Root>/<zone>/..., data objects are
+// files and the AVUs of a collection are kept in the .avus.json file of its directory
+type local_irods_client struct {
+	Root string
+}
+
+func (c local_irods_client) local_path(irods_path string) string {
+	return filepath.Join(c.Root, filepath.FromSlash(path.Clean("/"+irods_path)))
+}
+
+func (c local_irods_client) ReadDataObject(object_path string) ([]byte, error) {
+	return os.ReadFile(c.local_path(object_path))
+}
+
+func (c local_irods_client) CollectionAVUs(collection string) ([]IrodsAVU, error) {
+	dir := c.local_path(collection)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("collection %s does not exist in %s", collection, c.Root)
+	}
+	var avus []IrodsAVU
+	raw, err := os.ReadFile(filepath.Join(dir, irods_local_avu_file))
+	if os.IsNotExist(err) {
+		return avus, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &avus)
+	return avus, err
+}
+
+const test_irods_home string = "/tempZone/home/research-demo"
+
+func TestReadIrodsMetadataJson(t *testing.T) {
+	client := local_irods_client{Root: filepath.Join("test-data", "irods")}
+	data, name, err := read_irods_metadata(client, test_irods_home+"/package-json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != test_irods_home+"/package-json/"+current_metadata_file_name {
+		t.Errorf("read from %s", name)
+	}
+	raw, err := os.ReadFile(filepath.Join("test-data", "irods", "tempZone", "home", "research-demo", "package-json", current_metadata_file_name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := test_metadata(t, string(raw)); !reflect.DeepEqual(data, want) {
+		t.Error("metadata differs from the data object")
+	}
+}
+
+func TestReadIrodsMetadataAvus(t *testing.T) {
+	client := local_irods_client{Root: filepath.Join("test-data", "irods")}
+	data, name, err := read_irods_metadata(client, test_irods_home+"/package-avu")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(name, "(AVUs)") {
+		t.Errorf("read from %s, not from the AVUs", name)
+	}
+	if data.Title != "Naturally Fermented Milk from Northern Senegal" || data.RetentionPeriod != 10 {
+		t.Errorf("title %q, retention period %d", data.Title, data.RetentionPeriod)
+	}
+	if len(data.Tag) != 5 || len(data.Contributor) != 3 || len(data.Contributor[0].Affiliation) != 4 {
+		t.Errorf("%d tags, %d contributors", len(data.Tag), len(data.Contributor))
+	}
+	if pid := data.Creator[0].PersonIdentifier[1]; pid.NameIdentifier != "D-2017-2010" {
+		t.Errorf("second identifier of the creator is %+v", pid)
+	}
+
+	if _, _, err = read_irods_metadata(client, test_irods_home+"/missing"); err == nil {
+		t.Error("no error for a missing collection")
+	}
+	empty := t.TempDir()
+	if err = os.MkdirAll(filepath.Join(empty, "zone", "empty"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err = read_irods_metadata(local_irods_client{Root: empty}, "/zone/empty"); err == nil {
+		t.Error("no error for a collection without metadata")
+	}
+}
+
+func TestAvusToMetadata(t *testing.T) {
+	data, used, unknown, err := avus_to_metadata([]IrodsAVU{
+		{Attribute: "usr_Title", Value: "Title"},
+		{Attribute: "usr_Tag", Value: "b", Units: "1"},
+		{Attribute: "usr_Tag", Value: "a", Units: "0"},
+		{Attribute: "usr_Discipline", Value: "first"},
+		{Attribute: "usr_Discipline", Value: "second"},
+		{Attribute: "usr_Creator_Affiliation", Value: "VU", Units: "1_0"},
+		{Attribute: "usr_Retention_Period", Value: " 10 "},
+		{Attribute: "usr_Unknown_Field", Value: "x"},
+		{Attribute: "org_status", Value: "SUBMITTED"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used != 7 || !reflect.DeepEqual(unknown, []string{"usr_Unknown_Field"}) {
+		t.Errorf("used %d, unknown %v", used, unknown)
+	}
+	if !reflect.DeepEqual(data.Tag, []string{"a", "b"}) || !reflect.DeepEqual(data.Discipline, []string{"first", "second"}) {
+		t.Errorf("tags %v, disciplines %v", data.Tag, data.Discipline)
+	}
+	if len(data.Creator) != 2 || !reflect.DeepEqual(data.Creator[1].Affiliation, []string{"VU"}) || data.RetentionPeriod != 10 {
+		t.Errorf("creators %+v, retention period %d", data.Creator, data.RetentionPeriod)
+	}
+
+	if _, _, _, err = avus_to_metadata([]IrodsAVU{{Attribute: "usr_Retention_Period", Value: "ten"}}); err == nil {
+		t.Error("no error for a retention period that is not a number")
+	}
+	for _, units := range []string{"999999999", "99999999999999999999", "0_10000"} {
+		if _, _, _, err = avus_to_metadata([]IrodsAVU{{Attribute: "usr_Creator_Affiliation", Value: "VU", Units: units}}); err == nil {
+			t.Errorf("no error for list position %s", units)
+		}
+	}
+}
+
+func TestParseImetaOutput(t *testing.T) {
+	out := "AVUs defined for collection /tempZone/home/research-demo/package:\n" +
+		"attribute: usr_Title\nvalue: A title\nunits:\n----\n" +
+		"attribute: usr_Description\nvalue: First line\n\nsecond line\nunits:\n----\n" +
+		"attribute: usr_Tag\nvalue: tag\nunits: 0\n"
+	want := []IrodsAVU{
+		{Attribute: "usr_Title", Value: "A title"},
+		{Attribute: "usr_Description", Value: "First line\n\nsecond line"},
+		{Attribute: "usr_Tag", Value: "tag", Units: "0"},
+	}
+	if got := parse_imeta_output([]byte(out)); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestIrodsCollectionPath(t *testing.T) {
+	env := IrodsEnvironment{Cwd: test_irods_home}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"irods:/tempZone/home/research-demo/package", test_irods_home + "/package"},
+		{"irods://tempZone/home/research-demo/package/", test_irods_home + "/package"},
+		{"irods:package", test_irods_home + "/package"},
+		{"irods:package/yoda-metadata.json", test_irods_home + "/package"},
+	}
+	for _, tt := range tests {
+		got, err := irods_collection_path(tt.input, env)
+		if err != nil || got != tt.want {
+			t.Errorf("irods_collection_path(%q) = %q, %v, want %q", tt.input, got, err, tt.want)
+		}
+	}
+	if _, err := irods_collection_path("irods:package", IrodsEnvironment{}); err == nil {
+		t.Error("no error for a relative path without an iRODS home")
+	}
+}
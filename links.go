@@ -0,0 +1,172 @@
+/*
+links.go resolvable URLs for identifiers and licences. Person and package identifiers are turned
+into URLs by scheme (ORCID, ISNI, DOI, Handle, ARK, URN, URL, ...) and written as clickable links
+in the PDF and the Markdown output, identifiers that cannot be resolved are marked.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+var (
+	doi_pattern    = regexp.MustCompile(`^(?i:(?:https?://)?(?:dx\.)?(?:doi\.org/)|doi:\s*)?(10\.\d{4,9}/\S+)$`)
+	isni_pattern   = regexp.MustCompile(`^(?i:(?:https?://)?(?:www\.)?isni\.org/isni/|isni:?\s*)?([0-9]{4}\s?[0-9]{4}\s?[0-9]{4}\s?[0-9]{3}[0-9Xx])$`)
+	handle_pattern = regexp.MustCompile(`^(?i:(?:https?://)?hdl\.handle\.net/|hdl:\s*)?([0-9]+(?:\.[0-9]+)*/\S+)$`)
+	ark_pattern    = regexp.MustCompile(`^(?i:(?:https?://[^/]+/)?)(ark:/?[0-9]{5}/\S+)$`)
+	urn_pattern    = regexp.MustCompile(`^(?i:(?:https?://nbn-resolving\.org/)?)((?i:urn:nbn:)\S+)$`)
+	arxiv_pattern  = regexp.MustCompile(`^(?i:(?:https?://arxiv\.org/abs/)|arxiv:\s*)?([0-9]{4}\.[0-9]{4,5}(?:v[0-9]+)?|[a-z\-]+(?:\.[A-Z]{2})?/[0-9]{7})$`)
+	pmid_pattern   = regexp.MustCompile(`^(?i:(?:https?://pubmed\.ncbi\.nlm\.nih\.gov/)|pmid:\s*)?([0-9]{1,9})/?$`)
+)
+
+// licence URLs
+var license_urls = map[string]string{
+	"Creative Commons Attribution 4.0 International Public License":                          "https://creativecommons.org/licenses/by/4.0/",
+	"Creative Commons Attribution-ShareAlike 4.0 International Public License":               "https://creativecommons.org/licenses/by-sa/4.0/",
+	"Creative Commons Attribution-NonCommercial 4.0 International Public License":            "https://creativecommons.org/licenses/by-nc/4.0/",
+	"Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International Public License": "https://creativecommons.org/licenses/by-nc-sa/4.0/",
+	"Creative Commons Attribution-NoDerivatives 4.0 International Public License":            "https://creativecommons.org/licenses/by-nd/4.0/",
+	"Creative Commons Zero v1.0 Universal":                                                   "https://creativecommons.org/publicdomain/zero/1.0/",
+	"Open Data Commons Attribution License (ODC-By) v1.0":                                    "https://opendatacommons.org/licenses/by/1-0/",
+	"Open Data Commons Open Database License (ODbL) v1.0":                                    "https://opendatacommons.org/licenses/odbl/1-0/",
+	"Open Data Commons Public Domain Dedication and License (PDDL) v1.0":                     "https://opendatacommons.org/licenses/pddl/1-0/",
+	"GNU General Public License v3.0":                                                        "https://www.gnu.org/licenses/gpl-3.0.html",
+	"MIT License":                                                                            "https://opensource.org/licenses/MIT",
+}
+
+// resolve an identifier to a URL using its scheme, ok is false if it cannot be resolved
+func identifier_url(scheme string, identifier string) (string, bool) {
+	id := strings.TrimSpace(identifier)
+	if id == "" {
+		return "", false
+	}
+	if s, ok := vocab_lookup(vocab_name_identifier_scheme, scheme); ok {
+		scheme = s
+	} else if s, ok := vocab_lookup(vocab_identifier_scheme, scheme); ok {
+		scheme = s
+	}
+
+	match := func(pattern *regexp.Regexp) (string, bool) {
+		if m := pattern.FindStringSubmatch(id); m != nil {
+			return m[1], true
+		}
+		return "", false
+	}
+	switch scheme {
+	case "ORCID":
+		if orcid, ok := parse_orcid(id); ok {
+			return orcid_uri_prefix + orcid, true
+		}
+	case "ISNI":
+		if isni, ok := match(isni_pattern); ok {
+			return "https://isni.org/isni/" + strings.ToUpper(strings.ReplaceAll(isni, " ", "")), true
+		}
+	case "DOI":
+		if doi, ok := match(doi_pattern); ok {
+			return "https://doi.org/" + doi, true
+		}
+	case "Handle":
+		if hdl, ok := match(handle_pattern); ok {
+			return "https://hdl.handle.net/" + hdl, true
+		}
+	case "ARK":
+		if ark, ok := match(ark_pattern); ok {
+			return "https://n2t.net/ark:/" + strings.TrimPrefix(strings.TrimPrefix(ark, "ark:"), "/"), true
+		}
+	case "URN":
+		if urn, ok := match(urn_pattern); ok {
+			return "https://nbn-resolving.org/" + urn, true
+		}
+	case "arXiv":
+		if arxiv, ok := match(arxiv_pattern); ok {
+			return "https://arxiv.org/abs/" + arxiv, true
+		}
+	case "PMID":
+		if pmid, ok := match(pmid_pattern); ok {
+			return "https://pubmed.ncbi.nlm.nih.gov/" + pmid + "/", true
+		}
+	case "URL", "PURL":
+		if u, err := url.Parse(id); err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" {
+			return u.String(), true
+		}
+	}
+	return "", false
+}
+
+// URL of a licence from the vocabulary
+func license_url(license string) (string, bool) {
+	if l, ok := vocab_lookup_license(license); ok {
+		u, found := license_urls[l]
+		return u, found
+	}
+	return "", false
+}
+
+// Maroto PDF color defintions
+func pdfLinkColour() color.Color {
+	return theme_colour(REPORT_THEME.Colours.Link, pdfLinkColourDefault())
+}
+
+// link colour when the theme does not set one
+func pdfLinkColourDefault() color.Color {
+	return color.Color{
+		Red:   0,
+		Green: 70,
+		Blue:  170,
+	}
+}
+
+// write an identifier row, linked to its URL or marked when it cannot be resolved
+func pdf_write_identifier_row(m pdf.Maroto, scheme string, identifier string, rowheight float64, colwidth uint, indent uint) {
+	line := "(" + scheme + ") " + identifier
+	link, ok := identifier_url(scheme, identifier)
+	if !ok {
+		pdf_write_row_indent(m, line+tr("link.unresolved"), rowheight, colwidth, consts.Normal, pdfWarningColour(), indent)
+		return
+	}
+	pdf_write_row_indent(m, line, rowheight, colwidth, consts.Normal, pdfLinkColour(), indent)
+	pdf_link_last_row(m, link, rowheight, colwidth, indent)
+}
+
+// write a labelled value that links to url, if there is one
+func pdf_write_labelled_link_row(m pdf.Maroto, label string, line string, link string, rowheight float64, colwidth uint, emptyrowheight float64, textcolour color.Color) {
+	if link == "" || is_blank(line) {
+		pdf_write_labelled_row(m, label, line, rowheight, colwidth, emptyrowheight, consts.Normal, textcolour)
+		return
+	}
+	pdf_write_row(m, label, rowheight, colwidth, consts.Bold, pdfBlack())
+	m.Row(rowheight, func() {
+		m.Col(colwidth, func() {
+			m.Text(line, props.Text{Size: fontsize, Style: consts.Normal, Color: pdfLinkColour()})
+		})
+	})
+	pdf_link_last_row(m, link, rowheight, colwidth, 0)
+	pdf_write_empty_row(m, emptyrowheight, colwidth)
+}
+
+// make the text column of the row just written a link
+func pdf_link_last_row(m pdf.Maroto, link string, rowheight float64, colwidth uint, indent uint) {
+	pm, ok := base_maroto(m)
+	if !ok {
+		return
+	}
+	left, _, _, _ := m.GetPageMargins()
+	pm.Pdf.LinkString(left+grid_width(m, indent), pm.Pdf.GetY()-rowheight, grid_width(m, colwidth-indent), rowheight, link)
+}
+
+// Markdown link for an identifier, the identifier marked as unresolved if it cannot be resolved
+func md_identifier(scheme string, identifier string) string {
+	if link, ok := identifier_url(scheme, identifier); ok {
+		return "[" + identifier + "](" + link + ")"
+	}
+	return identifier + tr("link.unresolved")
+}
@@ -0,0 +1,66 @@
+package main
+
+import "testing"
+
+func TestIdentifierUrl(t *testing.T) {
+	for _, c := range []struct {
+		scheme     string
+		identifier string
+		url        string
+	}{
+		{"ORCID", "0000-0002-1825-0097", "https://orcid.org/0000-0002-1825-0097"},
+		{"orcid", "https://orcid.org/0000-0002-1825-0097", "https://orcid.org/0000-0002-1825-0097"},
+		{"ORCID", "0000000218250097", "https://orcid.org/0000-0002-1825-0097"},
+		{"ORCID", "0000-0002-1825-0098", ""},
+		{"ISNI", "0000 0001 2103 2683", "https://isni.org/isni/0000000121032683"},
+		{"ISNI", "https://isni.org/isni/000000012103268x", "https://isni.org/isni/000000012103268X"},
+		{"DOI", "10.5281/zenodo.123", "https://doi.org/10.5281/zenodo.123"},
+		{"doi", "doi:10.5281/zenodo.123", "https://doi.org/10.5281/zenodo.123"},
+		{"DOI", "https://dx.doi.org/10.5281/zenodo.123", "https://doi.org/10.5281/zenodo.123"},
+		{"DOI", "zenodo.123", ""},
+		{"Handle", "hdl:11245/1.123", "https://hdl.handle.net/11245/1.123"},
+		{"ARK", "ark:/13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"ARK", "https://example.org/ark:13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"URN", "urn:nbn:nl:ui:13-abc", "https://nbn-resolving.org/urn:nbn:nl:ui:13-abc"},
+		{"arXiv", "arXiv:2101.00001v2", "https://arxiv.org/abs/2101.00001v2"},
+		{"PMID", "12345678", "https://pubmed.ncbi.nlm.nih.gov/12345678/"},
+		{"URL", " https://example.org/data ", "https://example.org/data"},
+		{"PURL", "http://purl.org/dc/terms/", "http://purl.org/dc/terms/"},
+		{"URL", "ftp://example.org/data", ""},
+		{"URL", "example.org/data", ""},
+		{"ISBN", "978-3-16-148410-0", ""},
+		{"Unknown", "https://example.org", ""},
+		{"DOI", "  ", ""},
+	} {
+		url, ok := identifier_url(c.scheme, c.identifier)
+		if url != c.url || ok != (c.url != "") {
+			t.Errorf("identifier_url(%q, %q) = %q, %v, want %q", c.scheme, c.identifier, url, ok, c.url)
+		}
+	}
+}
+
+func TestLicenseUrl(t *testing.T) {
+	for _, c := range []struct {
+		license string
+		url     string
+	}{
+		{"Creative Commons Attribution 4.0 International Public License", "https://creativecommons.org/licenses/by/4.0/"},
+		{"cc-by-sa-4.0", "https://creativecommons.org/licenses/by-sa/4.0/"},
+		{"Custom", ""},
+		{"", ""},
+	} {
+		url, ok := license_url(c.license)
+		if url != c.url || ok != (c.url != "") {
+			t.Errorf("license_url(%q) = %q, %v, want %q", c.license, url, ok, c.url)
+		}
+	}
+}
+
+func TestMdIdentifier(t *testing.T) {
+	if got := md_identifier("DOI", "10.5281/zenodo.123"); got != "[10.5281/zenodo.123](https://doi.org/10.5281/zenodo.123)" {
+		t.Errorf("md_identifier = %q", got)
+	}
+	if got := md_identifier("DOI", "zenodo.123"); got != "zenodo.123"+tr("link.unresolved") {
+		t.Errorf("md_identifier of an unresolved identifier = %q", got)
+	}
+}
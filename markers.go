@@ -0,0 +1,194 @@
+/*
+markers.go severity markers that do not depend on colour. Every row written in an error, warning
+or info colour gets a shape in the left margin (square, triangle, circle), explained by a legend on
+the first page, so the report can be read by colour-blind readers and in black and white. The
+validation findings are listed in an appendix with the page of the section they refer to.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// size of a marker and its distance from the text in mm
+const marker_size float64 = 2.4
+const marker_gap float64 = 1.5
+
+// severities from most to least severe, the order of the legend
+var marker_severities = []Severity{SeverityError, SeverityWarning, SeverityInfo}
+
+// report section (bookmark key) of the top level Yoda fields, used for the page numbers of findings
+var finding_sections = map[string]string{
+	"Title":                     "label.title",
+	"Description":               "label.description",
+	"Tag":                       "label.tags",
+	"Creator":                   "label.creators",
+	"Contributor":               "label.contributors",
+	"Discipline":                "label.disciplines",
+	"Collected":                 "label.collected",
+	"Covered_Period":            "label.covered_period",
+	"Covered_Geolocation_Place": "label.geolocation",
+	"GeoLocation":               "label.geolocation",
+	"Funding_Reference":         "label.funding",
+	"Related_Datapackage":       "label.related",
+	"Version":                   "section.licence_access",
+	"License":                   "section.licence_access",
+	"Data_Type":                 "section.licence_access",
+	"Data_Classification":       "section.licence_access",
+	"Data_Access_Restriction":   "section.licence_access",
+	"Language":                  "section.retention",
+	"Retention_Period":          "section.retention",
+	"Retention_Information":     "section.retention",
+	"Embargo_End_Date":          "section.retention",
+	"Remarks":                   "section.retention",
+}
+
+// colour of a severity in the current theme
+func severity_rgb(sev Severity) color.Color {
+	switch sev {
+	case SeverityError:
+		return theme_colour(REPORT_THEME.Colours.Error, pdfRed())
+	case SeverityWarning:
+		return theme_colour(REPORT_THEME.Colours.Warning, pdfBlue())
+	}
+	return theme_colour(REPORT_THEME.Colours.Info, pdfOrange())
+}
+
+// severity shown by a text colour, ok is false for normal text
+func colour_severity(c color.Color) (Severity, bool) {
+	for _, sev := range marker_severities {
+		if severity_rgb(sev) == c {
+			return sev, true
+		}
+	}
+	return "", false
+}
+
+// draw the marker of a severity with its top left corner at x, y
+func pdf_draw_marker(pm *pdf.PdfMaroto, sev Severity, x float64, y float64) {
+	c := severity_rgb(sev)
+	fr, fg, fb := pm.Pdf.GetFillColor()
+	dr, dg, db := pm.Pdf.GetDrawColor()
+	lw := pm.Pdf.GetLineWidth()
+	pm.Pdf.SetFillColor(c.Red, c.Green, c.Blue)
+	pm.Pdf.SetDrawColor(0, 0, 0)
+	pm.Pdf.SetLineWidth(0.2)
+	switch sev {
+	case SeverityError:
+		pm.Pdf.Rect(x, y, marker_size, marker_size, "FD")
+	case SeverityWarning:
+		pm.Pdf.Polygon([]gofpdf.PointType{{X: x + marker_size/2, Y: y}, {X: x + marker_size, Y: y + marker_size}, {X: x, Y: y + marker_size}}, "FD")
+	default:
+		pm.Pdf.Circle(x+marker_size/2, y+marker_size/2, marker_size/2, "FD")
+	}
+	pm.Pdf.SetFillColor(fr, fg, fb)
+	pm.Pdf.SetDrawColor(dr, dg, db)
+	pm.Pdf.SetLineWidth(lw)
+}
+
+// put a marker in the left margin of the row just written when its colour shows a severity
+func pdf_mark_row(m pdf.Maroto, textcolour color.Color, rowheight float64) {
+	sev, ok := colour_severity(textcolour)
+	if !ok {
+		return
+	}
+	pm, ok := base_maroto(m)
+	if !ok {
+		return
+	}
+	left, _, _, _ := m.GetPageMargins()
+	top := pm.Pdf.GetY() - rowheight
+	if rowheight > marker_size {
+		top += (rowheight - marker_size) / 2
+	}
+	pdf_draw_marker(pm, sev, left-marker_size-marker_gap, top)
+}
+
+// legend of the markers, written on the first page
+func pdf_write_severity_legend(m pdf.Maroto, findings int, rowheight float64, colwidth uint) {
+	pdf_write_row_base(m, tr("legend.heading"), rowheight, colwidth, consts.Bold, pdfBlack())
+	for _, sev := range marker_severities {
+		pdf_write_row_base(m, tr("legend."+string(sev)), rowheight, colwidth, consts.Normal, severity_rgb(sev))
+	}
+	if findings > 0 {
+		pdf_write_row_base(m, tr("legend.appendix", findings), rowheight, colwidth, consts.Italic, pdfBlack())
+	}
+}
+
+// bookmark of the report section a finding refers to, ok is false if the field is not in the report
+func finding_section(field string) (OutlineEntry, bool) {
+	top := field
+	if i := strings.IndexAny(top, ".["); i >= 0 {
+		top = top[:i]
+	}
+	key, ok := finding_sections[top]
+	if !ok {
+		return OutlineEntry{}, false
+	}
+	for _, e := range PDF_OUTLINE {
+		if e.Key == key {
+			return e, true
+		}
+	}
+	return OutlineEntry{}, false
+}
+
+// appendix with every validation finding, its marker and the page it refers to, linked to that page
+func pdf_write_findings(m pdf.Maroto, findings []Finding, profile_name string, rowheight float64, colwidth uint, emptyrowheight float64) {
+	if len(findings) == 0 {
+		return
+	}
+	counts := count_findings(findings)
+	m.AddPage()
+	pdf_bookmark("section.findings", 0)
+	pdf_write_row(m, tr("findings.heading", profile_name,
+		counts[SeverityError], counts[SeverityWarning], counts[SeverityInfo]), rowheight, colwidth, consts.Bold, pdfBlack())
+	m.Row(rowheight, func() {
+		m.Col(colwidth-1, func() {
+			m.Text(tr("findings.finding"), props.Text{Size: fontsize - 1, Style: consts.Italic, Color: pdfBlack()})
+		})
+		m.Col(1, func() {
+			m.Text(tr("findings.page"), props.Text{Size: fontsize - 1, Style: consts.Italic, Align: consts.Right, Color: pdfBlack()})
+		})
+	})
+	pm, ok := base_maroto(m)
+	for _, f := range findings {
+		colour := severity_rgb(f.Severity)
+		section, found := finding_section(f.Field)
+		lines := wrap_text(m, fmt.Sprintf("[%s] %s", tr("severity."+string(f.Severity)), f.Message), grid_width(m, colwidth-1), fontsize-1, consts.Normal)
+		for i, line := range lines {
+			number := ""
+			if i == 0 && found {
+				number = fmt.Sprint(section.Page)
+			}
+			m.Row(rowheight, func() {
+				m.Col(colwidth-1, func() {
+					m.Text(line, props.Text{Size: fontsize - 1, Extrapolate: true, Color: colour})
+				})
+				m.Col(1, func() {
+					m.Text(number, props.Text{Size: fontsize - 1, Align: consts.Right, Color: pdfBlack()})
+				})
+			})
+			if i == 0 {
+				pdf_mark_row(m, colour, rowheight)
+				if ok && found {
+					left, _, right, _ := m.GetPageMargins()
+					width, _ := m.GetPageSize()
+					link := pm.Pdf.AddLink()
+					pm.Pdf.SetLink(link, section.Y, section.Page)
+					pm.Pdf.Link(left, pm.Pdf.GetY()-rowheight, width-left-right, rowheight, link)
+				}
+			}
+		}
+	}
+	pdf_write_empty_row(m, emptyrowheight, colwidth)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+func TestColourSeverity(t *testing.T) {
+	saved := REPORT_THEME
+	defer func() { REPORT_THEME = saved }()
+	tests := []struct {
+		name    string
+		colours ThemeColours
+		colour  color.Color
+		want    Severity
+	}{
+		{"default error", ThemeColours{}, pdfRed(), SeverityError},
+		{"default warning", ThemeColours{}, pdfBlue(), SeverityWarning},
+		{"default info", ThemeColours{}, pdfOrange(), SeverityInfo},
+		{"text", ThemeColours{}, pdfBlack(), ""},
+		{"link", ThemeColours{}, pdfLinkColour(), ""},
+		{"theme error", ThemeColours{Error: "#cc0000"}, color.Color{Red: 204}, SeverityError},
+		{"default error in a theme with its own", ThemeColours{Error: "#cc0000"}, pdfRed(), ""},
+	}
+	for _, tt := range tests {
+		REPORT_THEME = Theme{Colours: tt.colours}
+		sev, ok := colour_severity(tt.colour)
+		if sev != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: %q, %v, want %q", tt.name, sev, ok, tt.want)
+		}
+	}
+}
+
+func TestMarkRow(t *testing.T) {
+	saved := REPORT_THEME
+	defer func() { REPORT_THEME = saved }()
+	REPORT_THEME = Theme{}
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	pm, _ := base_maroto(m)
+	pm.Pdf.SetCompression(false)
+	for _, c := range []color.Color{pdfRed(), pdfRed(), pdfBlue(), pdfOrange(), pdfBlack()} {
+		pdf_write_row_base(m, "row", 5, 12, consts.Normal, c)
+	}
+	buf, err := m.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.Bytes()
+	// squares for errors, a triangle for the warning and a circle for info
+	fill := func(c color.Color) []byte {
+		return []byte(fmt.Sprintf("%.3f %.3f %.3f rg", float64(c.Red)/255, float64(c.Green)/255, float64(c.Blue)/255))
+	}
+	if got := bytes.Count(out, []byte(" re B")); got != 2 {
+		t.Errorf("%d squares, want 2", got)
+	}
+	if got := len(regexp.MustCompile(`l\s+B\s`).FindAll(out, -1)); got != 1 {
+		t.Errorf("%d triangles, want 1", got)
+	}
+	if got := len(regexp.MustCompile(`c\s+B\s`).FindAll(out, -1)); got != 1 {
+		t.Errorf("%d circles, want 1", got)
+	}
+	for _, c := range []color.Color{pdfRed(), pdfBlue(), pdfOrange()} {
+		if !bytes.Contains(out, fill(c)) {
+			t.Errorf("no marker filled with %s", fill(c))
+		}
+	}
+}
+
+func TestFindingSection(t *testing.T) {
+	saved := PDF_OUTLINE
+	defer func() { PDF_OUTLINE = saved }()
+	PDF_OUTLINE = []OutlineEntry{{Key: "label.creators", Page: 2}, {Key: "section.retention", Page: 3}}
+	tests := []struct {
+		field string
+		page  int
+		ok    bool
+	}{
+		{"Creator", 2, true},
+		{"Creator[2].Name.Family_Name", 2, true},
+		{"Embargo_End_Date", 3, true},
+		// in the report but not bookmarked, and not in the report at all
+		{"Title", 0, false},
+		{"Unknown_Field", 0, false},
+	}
+	for _, tt := range tests {
+		section, ok := finding_section(tt.field)
+		if ok != tt.ok || section.Page != tt.page {
+			t.Errorf("finding_section(%s) = page %d, %v, want %d, %v", tt.field, section.Page, ok, tt.page, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+normalise.go text hygiene for Yoda metadata, every string value is trimmed, has its internal
+whitespace collapsed, is converted to Unicode NFC, has zero-width and control characters removed
+and has typographic quotes replaced by plain ones. Each change is reported as a finding.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// free text fields where line breaks are meaningful (paragraphs, bullet lists)
+var multiline_fields = map[string]bool{
+	"Description":           true,
+	"Remarks":               true,
+	"Retention_Information": true,
+}
+
+// characters that are invisible but break matching and identifiers
+var zero_width_chars = map[rune]bool{
+	'\u200b': true, // zero width space
+	'\u200c': true, // zero width non-joiner
+	'\u200d': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\ufeff': true, // zero width no-break space, byte order mark
+	'\u00ad': true, // soft hyphen
+}
+
+// typographic quotes and their plain replacements
+var quote_replacer = strings.NewReplacer(
+	"\u2018", "'", "\u2019", "'", "\u201a", "'", "\u201b", "'", "\u2032", "'",
+	"\u201c", "\"", "\u201d", "\"", "\u201e", "\"", "\u201f", "\"", "\u2033", "\"",
+)
+
+// a single change made by the normaliser
+type TextChange struct {
+	Field   string
+	Old     string
+	New     string
+	Reasons []string
+}
+
+// is a string empty or whitespace only
+func is_blank(s string) bool {
+	return strings.TrimSpace(s) == ""
+}
+
+// normalise all string values of the metadata in place, returns the changes made
+func normalise_metadata(data *Yoda18Metadata) []TextChange {
+	var changes []TextChange
+	walk_metadata_strings(reflect.ValueOf(data).Elem(), "", func(field string, s string) string {
+		name := field[strings.LastIndex(field, ".")+1:]
+		clean, reasons := normalise_text(s, multiline_fields[name])
+		if clean != s {
+			changes = append(changes, TextChange{Field: field, Old: s, New: clean, Reasons: reasons})
+		}
+		return clean
+	})
+	return changes
+}
+
+// call fn for every string in v and replace it with the result, field is the Yoda field path
+// using the same [n] list notation as find_empty_fields
+func walk_metadata_strings(v reflect.Value, field string, fn func(field string, s string) string) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(fn(field, v.String()))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if name == "" {
+				name = v.Type().Field(i).Name
+			}
+			if field != "" {
+				name = field + "." + name
+			}
+			walk_metadata_strings(v.Field(i), name, fn)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			walk_metadata_strings(v.Index(i), fmt.Sprintf("%s[%d]", field, i+1), fn)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			walk_metadata_strings(v.Elem(), field, fn)
+		}
+	}
+}
+
+// normalise a single text value, multiline text keeps its line breaks
+func normalise_text(s string, multiline bool) (string, []string) {
+	var reasons []string
+	note := func(changed bool, reason string) {
+		if changed {
+			reasons = append(reasons, reason)
+		}
+	}
+
+	out := norm.NFC.String(s)
+	note(out != s, tr("hygiene.nfc"))
+
+	prev := out
+	out = strings.Map(func(r rune) rune {
+		if zero_width_chars[r] {
+			return -1
+		}
+		return r
+	}, out)
+	note(out != prev, tr("hygiene.zero_width"))
+
+	prev = out
+	out = strings.ReplaceAll(out, "\r\n", "\n")
+	out = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, out)
+	note(out != prev, tr("hygiene.control"))
+
+	prev = out
+	out = quote_replacer.Replace(out)
+	note(out != prev, tr("hygiene.quotes"))
+
+	prev = out
+	out = strings.TrimSpace(out)
+	note(out != prev, tr("hygiene.trim"))
+
+	prev = out
+	if multiline {
+		lines := strings.Split(out, "\n")
+		for i := range lines {
+			lines[i] = collapse_whitespace(lines[i])
+		}
+		out = strings.Join(lines, "\n")
+	} else {
+		out = collapse_whitespace(out)
+	}
+	note(out != prev, tr("hygiene.collapse"))
+
+	return out, reasons
+}
+
+// replace runs of whitespace by a single space and trim the result
+func collapse_whitespace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// report every change the normaliser would make as a finding
+func check_text_hygiene(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	clean := clone_metadata(data)
+	for _, c := range normalise_metadata(&clean) {
+		findings = append(findings, Finding{Field: c.Field,
+			Message: tr("msg.text_hygiene", c.Field, strings.Join(c.Reasons, ", "), shorten_text(c.Old, 40), shorten_text(c.New, 40))})
+	}
+	return findings
+}
+
+// shorten long text for use in messages
+func shorten_text(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormaliseText(t *testing.T) {
+	for _, c := range []struct {
+		in        string
+		multiline bool
+		out       string
+		reasons   []string
+	}{
+		{"clean text", false, "clean text", nil},
+		{"  padded\t", false, "padded", []string{"hygiene.trim"}},
+		{"two  spaces\nand a line", false, "two spaces and a line", []string{"hygiene.collapse"}},
+		{"first  line\r\n\nsecond\t line", true, "first line\n\nsecond line", []string{"hygiene.control", "hygiene.collapse"}},
+		{"Cafe\u0301", false, "Caf\u00e9", []string{"hygiene.nfc"}},
+		{"zero\u200bwidth\u00ad", false, "zerowidth", []string{"hygiene.zero_width"}},
+		{"bell\x07", false, "bell", []string{"hygiene.control"}},
+		{"“quoted” it’s", false, "\"quoted\" it's", []string{"hygiene.quotes"}},
+		{"\ufeff ‘a’  b ", false, "'a' b", []string{"hygiene.zero_width", "hygiene.quotes", "hygiene.trim", "hygiene.collapse"}},
+	} {
+		out, reasons := normalise_text(c.in, c.multiline)
+		var want []string
+		for _, key := range c.reasons {
+			want = append(want, tr(key))
+		}
+		if out != c.out || !reflect.DeepEqual(reasons, want) {
+			t.Errorf("normalise_text(%q) = %q, %v, want %q, %v", c.in, out, reasons, c.out, want)
+		}
+	}
+}
+
+func TestNormaliseMetadata(t *testing.T) {
+	data := test_metadata(t, `{"Title": " Title ", "Description": "One\n\n  Two  words", "Tag": ["ok", "a\u200bb"],
+		"Creator": [{"Name": {"Given_Name": "Jan", "Family_Name": "Jansen "}}]}`)
+	changes := normalise_metadata(&data)
+	var fields []string
+	for _, c := range changes {
+		fields = append(fields, c.Field)
+	}
+	if want := []string{"Tag[2]", "Creator[1].Name.Family_Name", "Title", "Description"}; !reflect.DeepEqual(fields, want) {
+		t.Errorf("changed fields %v, want %v", fields, want)
+	}
+	if data.Title != "Title" || data.Description != "One\n\nTwo words" || data.Tag[1] != "ab" || data.Creator[0].Name.FamilyName != "Jansen" {
+		t.Errorf("normalised metadata %+v", data)
+	}
+	if again := normalise_metadata(&data); len(again) != 0 {
+		t.Errorf("normalising twice changes %v", again)
+	}
+}
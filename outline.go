@@ -0,0 +1,108 @@
+/*
+outline.go PDF navigation: a bookmark (outline entry) for every section of the report and an
+optional table of contents page. Bookmarks are requested before a section heading is written
+and placed on the next row, once its page and position are known. The table of contents needs
+the page numbers of the sections, so the report is rendered again until they no longer change.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+// a bookmark, Key is the message key of its title, Page is 1-based and Y is the distance from the top of the page in mm
+type OutlineEntry struct {
+	Key   string
+	Title string
+	Level int
+	Page  int
+	Y     float64
+}
+
+// bookmarks of the document being written, reset for every report
+var PDF_OUTLINE []OutlineEntry
+
+// bookmarks waiting for the next row
+var pending_bookmarks []OutlineEntry
+
+// maximum number of times the report is rendered to get the table of contents right
+const toc_max_passes int = 4
+
+// request a bookmark for the next row written, titled with the message for key
+func pdf_bookmark(key string, level int) {
+	pending_bookmarks = append(pending_bookmarks, OutlineEntry{Key: key, Title: tr(key), Level: level})
+}
+
+// place the pending bookmarks on the row that was just written
+func pdf_place_bookmarks(m pdf.Maroto, rowheight float64) {
+	if len(pending_bookmarks) == 0 {
+		return
+	}
+	pm, ok := base_maroto(m)
+	if !ok {
+		pending_bookmarks = nil
+		return
+	}
+	for _, b := range pending_bookmarks {
+		b.Page = pm.Pdf.PageNo()
+		b.Y = pm.Pdf.GetY() - rowheight
+		pm.Pdf.Bookmark(b.Title, b.Level, b.Y)
+		PDF_OUTLINE = append(PDF_OUTLINE, b)
+	}
+	pending_bookmarks = nil
+}
+
+// table of contents with a linked line per bookmark, followed by a page break
+func pdf_write_toc(m pdf.Maroto, toc []OutlineEntry, rowheight float64, colwidth uint) {
+	pdf_write_row_base(m, tr("toc.contents"), rowheight+2, colwidth, consts.Bold, pdfBlack())
+	pdf_write_empty_row(m, rowheight, colwidth)
+	pm, ok := base_maroto(m)
+	for _, e := range toc {
+		m.Row(rowheight+1, func() {
+			m.Col(colwidth-1, func() {
+				m.Text(strings.Repeat("    ", e.Level)+e.Title, props.Text{Size: fontsize, Color: pdfBlack()})
+			})
+			m.Col(1, func() {
+				m.Text(fmt.Sprint(e.Page), props.Text{Size: fontsize, Align: consts.Right, Color: pdfBlack()})
+			})
+		})
+		if ok {
+			left, _, right, _ := m.GetPageMargins()
+			width, _ := m.GetPageSize()
+			link := pm.Pdf.AddLink()
+			pm.Pdf.SetLink(link, e.Y, e.Page)
+			pm.Pdf.Link(left, pm.Pdf.GetY()-rowheight-1, width-left-right, rowheight+1, link)
+		}
+	}
+	m.AddPage()
+}
+
+// bookmarks are on the same pages in both outlines
+func outline_pages_equal(a []OutlineEntry, b []OutlineEntry) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Title != b[i].Title || a[i].Page != b[i].Page {
+			return false
+		}
+	}
+	return true
+}
+
+// shift the bookmarks by a number of pages
+func outline_shift(outline []OutlineEntry, pages int) []OutlineEntry {
+	shifted := make([]OutlineEntry, len(outline))
+	for i, e := range outline {
+		e.Page += pages
+		shifted[i] = e
+	}
+	return shifted
+}
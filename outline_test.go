@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+// render the report of a test data file, with a table of contents when toc is set
+func test_outline(t *testing.T, fname string, toc bool) ([]OutlineEntry, []byte) {
+	t.Helper()
+	saved := *toc_flag
+	*toc_flag = toc
+	defer func() { *toc_flag = saved }()
+	data, err := read_metadata_file(filepath.Join("test-data", fname))
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := load_profile(default_profile_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := create_pdf_report(data, fname, create_metadata_report(data, fname, profile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	outline := append([]OutlineEntry{}, PDF_OUTLINE...)
+	out, err := pdf_file_bytes(doc, data, fname, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return outline, out
+}
+
+func TestReportOutline(t *testing.T) {
+	outline, out := test_outline(t, "yoda-metadata.json", false)
+	keys := map[string]bool{}
+	for i, e := range outline {
+		keys[e.Key] = true
+		if e.Title != tr(e.Key) || e.Page < 1 || e.Y < 0 {
+			t.Errorf("bookmark %+v", e)
+		}
+		if i > 0 && (e.Page < outline[i-1].Page || (e.Page == outline[i-1].Page && e.Y < outline[i-1].Y)) {
+			t.Errorf("bookmark %s before %s", e.Key, outline[i-1].Key)
+		}
+	}
+	if len(outline) == 0 || outline[0].Key != "section.summary" {
+		t.Fatalf("outline %+v does not start with the summary", outline)
+	}
+	for _, key := range []string{"label.title", "label.description", "label.creators", "section.licence_access", "section.findings"} {
+		if !keys[key] {
+			t.Errorf("no bookmark for %s", key)
+		}
+	}
+	if !bytes.Contains(out, []byte("/Outlines")) {
+		t.Error("the PDF has no outline")
+	}
+
+	// the contents page moves every section one page down
+	toc, _ := test_outline(t, "yoda-metadata.json", true)
+	if !outline_pages_equal(toc, outline_shift(outline, 1)) {
+		t.Errorf("outline with contents %+v, want %+v", toc, outline_shift(outline, 1))
+	}
+}
+
+func TestOutlineShift(t *testing.T) {
+	outline := []OutlineEntry{{Title: "A", Page: 1}, {Title: "B", Page: 3}}
+	shifted := outline_shift(outline, 2)
+	if shifted[0].Page != 3 || shifted[1].Page != 5 || outline[0].Page != 1 {
+		t.Errorf("shifted %+v, outline %+v", shifted, outline)
+	}
+	tests := []struct {
+		a, b []OutlineEntry
+		want bool
+	}{
+		{outline, outline, true},
+		{outline, shifted, false},
+		{outline, outline[:1], false},
+		{outline, []OutlineEntry{{Title: "A", Page: 1, Y: 20}, {Title: "B", Page: 3}}, true},
+		{outline, []OutlineEntry{{Title: "A", Page: 1}, {Title: "C", Page: 3}}, false},
+	}
+	for i, tt := range tests {
+		if got := outline_pages_equal(tt.a, tt.b); got != tt.want {
+			t.Errorf("%d: outline_pages_equal = %v, want %v", i, got, tt.want)
+		}
+	}
+}
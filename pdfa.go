@@ -0,0 +1,522 @@
+/*
+pdfa.go PDF/A-2b archival output. The PDF written by the PDF library is parsed into its objects
+and rewritten with what PDF/A needs and the library does not write: an sRGB output intent, a file
+identifier and a binary header comment (the XMP metadata is added by pdfmeta.go). Font resources
+that are never used (the library always registers the non-embedded core font) are dropped.
+pdfa_check is a self-check of the result for the PDF/A requirements readYmeta can break.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the objects of a PDF file
+type pdf_file struct {
+	objects map[int][]byte
+	root    int
+	info    int
+}
+
+var (
+	pdf_startxref_pattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	pdf_object_pattern    = regexp.MustCompile(`(?s)^\s*\d+\s+\d+\s+obj\s*(.*?)\s*endobj\s*$`)
+	pdf_root_pattern      = regexp.MustCompile(`/Root\s+(\d+)\s+0\s+R`)
+	pdf_info_pattern      = regexp.MustCompile(`/Info\s+(\d+)\s+0\s+R`)
+	pdf_font_dict_pattern = regexp.MustCompile(`/Font\s*<<([^>]*)>>`)
+	pdf_font_ref_pattern  = regexp.MustCompile(`/(\S+)\s+(\d+)\s+0\s+R`)
+	pdf_font_use_pattern  = regexp.MustCompile(`/(\S+)\s+[\d.]+\s+Tf`)
+	pdf_annot_pattern     = regexp.MustCompile(`/Type\s*/Annot\b`)
+	// the value of a /F key, not of e.g. /Filter
+	pdf_flags_value_pattern = regexp.MustCompile(`^\s+(\d+)`)
+	pdf_ref_pattern         = regexp.MustCompile(`(\d+)\s+0\s+R`)
+	pdf_contents_pattern    = regexp.MustCompile(`/Contents\s+(\d+)\s+0\s+R`)
+	pdf_length_pattern      = regexp.MustCompile(`/Length\s+\d+`)
+	// font selection or a text showing operator
+	pdf_text_op_pattern = regexp.MustCompile(`/(\S+)\s+[\d.]+\s+Tf|[)>\]]\s*(?:Tj|TJ|'|")`)
+)
+
+// annotation flags: print, and invisible, hidden, no view and toggle no view which PDF/A forbids
+const pdf_annot_print_flag int = 4
+const pdf_annot_hidden_flags int = 1 | 2 | 32 | 256
+
+// add what PDF/A-2b needs to a PDF: unused (not embedded) fonts are dropped, annotations are
+// made printable and an sRGB output intent is added to the catalog
+func (p *pdf_file) convert_pdfa() {
+	p.remove_unused_fonts()
+	p.set_annotation_print_flags()
+
+	icc := pdf_flate(srgb_icc_profile())
+	icc_obj := p.add_object([]byte(fmt.Sprintf("<< /N 3 /Filter /FlateDecode /Length %d >>\nstream\n%s\nendstream", len(icc), icc)))
+	intent_obj := p.add_object([]byte(fmt.Sprintf("<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier %s /Info %s /DestOutputProfile %d 0 R >>",
+		pdf_text_string(srgb_profile_name), pdf_text_string(srgb_profile_name), icc_obj)))
+	p.add_to_catalog(fmt.Sprintf("/OutputIntents [%d 0 R]", intent_obj))
+}
+
+// add an object, returns its number
+func (p *pdf_file) add_object(body []byte) int {
+	next := 0
+	for n := range p.objects {
+		if n > next {
+			next = n
+		}
+	}
+	p.objects[next+1] = body
+	return next + 1
+}
+
+// add an entry to the document catalog
+func (p *pdf_file) add_to_catalog(entry string) {
+	catalog := bytes.TrimSuffix(bytes.TrimSpace(p.objects[p.root]), []byte(">>"))
+	p.objects[p.root] = append(catalog, []byte(entry+"\n>>")...)
+}
+
+// compress data with the Flate filter
+func pdf_flate(data []byte) []byte {
+	var b bytes.Buffer
+	w := zlib.NewWriter(&b)
+	w.Write(data)
+	w.Close()
+	return b.Bytes()
+}
+
+// split a PDF into its objects using the cross-reference table
+func parse_pdf(data []byte) (*pdf_file, error) {
+	m := pdf_startxref_pattern.FindSubmatch(data)
+	if m == nil {
+		return nil, fmt.Errorf("PDF has no startxref")
+	}
+	xref, _ := strconv.Atoi(string(m[1]))
+	if xref >= len(data) || !bytes.HasPrefix(data[xref:], []byte("xref")) {
+		return nil, fmt.Errorf("PDF has no cross-reference table at %d", xref)
+	}
+	trailer_at := bytes.Index(data[xref:], []byte("trailer"))
+	if trailer_at < 0 {
+		return nil, fmt.Errorf("PDF has no trailer")
+	}
+	trailer := data[xref+trailer_at:]
+
+	offsets := map[int]int{}
+	lines := strings.Split(string(data[xref:xref+trailer_at]), "\n")
+	number := 0
+	for _, line := range lines[1:] {
+		f := strings.Fields(line)
+		switch {
+		case len(f) == 2:
+			number, _ = strconv.Atoi(f[0])
+		case len(f) == 3:
+			if f[2] == "n" {
+				offsets[number], _ = strconv.Atoi(f[0])
+			}
+			number++
+		}
+	}
+
+	// an object ends where the next object (or the cross-reference table) starts
+	var starts []int
+	for _, o := range offsets {
+		starts = append(starts, o)
+	}
+	starts = append(starts, xref)
+	sort.Ints(starts)
+	p := &pdf_file{objects: map[int][]byte{}}
+	for n, o := range offsets {
+		end := starts[sort.SearchInts(starts, o)+1]
+		body := pdf_object_pattern.FindSubmatch(data[o:end])
+		if body == nil {
+			return nil, fmt.Errorf("PDF object %d cannot be read", n)
+		}
+		p.objects[n] = body[1]
+	}
+
+	root := pdf_root_pattern.FindSubmatch(trailer)
+	info := pdf_info_pattern.FindSubmatch(trailer)
+	if root == nil || info == nil {
+		return nil, fmt.Errorf("PDF trailer has no /Root or /Info")
+	}
+	p.root, _ = strconv.Atoi(string(root[1]))
+	p.info, _ = strconv.Atoi(string(info[1]))
+	return p, nil
+}
+
+// objects holding the page content streams
+func (p *pdf_file) content_objects() []int {
+	var contents []int
+	for _, body := range p.objects {
+		for _, m := range pdf_contents_pattern.FindAllSubmatch(body, -1) {
+			n, _ := strconv.Atoi(string(m[1]))
+			contents = append(contents, n)
+		}
+	}
+	sort.Ints(contents)
+	return contents
+}
+
+// font names selected by the content streams
+func (p *pdf_file) used_fonts() map[string]bool {
+	used := map[string]bool{}
+	for _, n := range p.content_objects() {
+		for _, m := range pdf_font_use_pattern.FindAllSubmatch(pdf_stream(p.objects[n]), -1) {
+			used[string(m[1])] = true
+		}
+	}
+	return used
+}
+
+// drop the fonts that are selected but never used to show text. The PDF library selects the
+// (not embedded) core font at the start of the first page, such selections are replaced by a
+// font that is used, after which the unused font resources are removed.
+func (p *pdf_file) remove_unused_fonts() {
+	rendered := map[string]bool{}
+	replacement := ""
+	for _, n := range p.content_objects() {
+		current := ""
+		for _, m := range pdf_text_op_pattern.FindAllSubmatch(pdf_stream(p.objects[n]), -1) {
+			if len(m[1]) > 0 {
+				current = string(m[1])
+			} else if current != "" {
+				rendered[current] = true
+				if replacement == "" {
+					replacement = current
+				}
+			}
+		}
+	}
+
+	if replacement != "" {
+		for _, n := range p.content_objects() {
+			stream := pdf_stream(p.objects[n])
+			fixed := pdf_font_use_pattern.ReplaceAllFunc(stream, func(tf []byte) []byte {
+				if name := string(pdf_font_use_pattern.FindSubmatch(tf)[1]); !rendered[name] {
+					return bytes.Replace(tf, []byte("/"+name), []byte("/"+replacement), 1)
+				}
+				return tf
+			})
+			if !bytes.Equal(stream, fixed) {
+				p.objects[n] = pdf_set_stream(p.objects[n], fixed)
+			}
+		}
+	}
+
+	used := p.used_fonts()
+	for n, body := range p.objects {
+		p.objects[n] = pdf_font_dict_pattern.ReplaceAllFunc(body, func(dict []byte) []byte {
+			return pdf_font_ref_pattern.ReplaceAllFunc(dict, func(ref []byte) []byte {
+				if name := pdf_font_ref_pattern.FindSubmatch(ref)[1]; !used[string(name)] {
+					return nil
+				}
+				return ref
+			})
+		})
+	}
+}
+
+// annotations have to be printable and must not be hidden in PDF/A, an existing /F entry is
+// replaced
+func (p *pdf_file) set_annotation_print_flags() {
+	for n, body := range p.objects {
+		dicts := pdf_annotation_dicts(body)
+		// from the last one, the offsets of the ones before stay valid
+		for i := len(dicts) - 1; i >= 0; i-- {
+			dict := body[dicts[i][0]:dicts[i][1]]
+			var fixed []byte
+			if flags, start, end, ok := pdf_dict_flags(dict); ok {
+				fixed = append(fixed, dict[:start]...)
+				fixed = append(fixed, strconv.Itoa((flags|pdf_annot_print_flag)&^pdf_annot_hidden_flags)...)
+				fixed = append(fixed, dict[end:]...)
+			} else {
+				fixed = append([]byte("<</F 4 "), dict[2:]...)
+			}
+			var out []byte
+			out = append(out, body[:dicts[i][0]]...)
+			out = append(out, fixed...)
+			body = append(out, body[dicts[i][1]:]...)
+		}
+		p.objects[n] = body
+	}
+}
+
+// offsets of the dictionaries of the annotations in an object, annotations are written as
+// separate objects or inline in the /Annots array of a page
+func pdf_annotation_dicts(body []byte) [][2]int {
+	var dicts [][2]int
+	for _, m := range pdf_annot_pattern.FindAllIndex(body, -1) {
+		start := pdf_dict_start(body, m[0])
+		if start < 0 {
+			continue
+		}
+		if end := pdf_dict_end(body, start); end > 0 {
+			dicts = append(dicts, [2]int{start, end})
+		}
+	}
+	return dicts
+}
+
+// offset of the << that opens the dictionary around offset i, -1 if there is none
+func pdf_dict_start(body []byte, i int) int {
+	depth := 0
+	for j := i - 1; j > 0; j-- {
+		if body[j-1] == '<' && body[j] == '<' {
+			if depth == 0 {
+				return j - 1
+			}
+			depth--
+			j--
+		} else if body[j-1] == '>' && body[j] == '>' {
+			depth++
+			j--
+		}
+	}
+	return -1
+}
+
+// offset after the >> that closes the dictionary opened at start, -1 if it is not closed
+func pdf_dict_end(body []byte, start int) int {
+	depth := 0
+	for j := start; j < len(body)-1; j++ {
+		switch {
+		case body[j] == '(':
+			j = pdf_string_end(body, j)
+		case body[j] == '<' && body[j+1] == '<':
+			depth++
+			j++
+		case body[j] == '>' && body[j+1] == '>':
+			depth--
+			j++
+			if depth == 0 {
+				return j + 1
+			}
+		}
+	}
+	return -1
+}
+
+// offset of the ) that closes the literal string opened at start
+func pdf_string_end(body []byte, start int) int {
+	depth := 0
+	for j := start; j < len(body); j++ {
+		switch body[j] {
+		case '\\':
+			j++
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+	}
+	return len(body)
+}
+
+// the /F entry of a dictionary, not of the dictionaries nested in it: its value and the offsets
+// of the value, ok is false if the dictionary has none
+func pdf_dict_flags(dict []byte) (flags int, start int, end int, ok bool) {
+	depth := 0
+	for j := 0; j < len(dict)-1; j++ {
+		switch {
+		case dict[j] == '(':
+			j = pdf_string_end(dict, j)
+		case dict[j] == '<' && dict[j+1] == '<':
+			depth++
+			j++
+		case dict[j] == '>' && dict[j+1] == '>':
+			depth--
+			j++
+		case depth == 1 && dict[j] == '/' && dict[j+1] == 'F':
+			if m := pdf_flags_value_pattern.FindSubmatchIndex(dict[j+2:]); m != nil {
+				flags, _ = strconv.Atoi(string(dict[j+2+m[2] : j+2+m[3]]))
+				return flags, j + 2 + m[2], j + 2 + m[3], true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// the decompressed stream of an object, nil if it has none
+func pdf_stream(body []byte) []byte {
+	start := bytes.Index(body, []byte("stream"))
+	end := bytes.LastIndex(body, []byte("endstream"))
+	if start < 0 || end < start {
+		return nil
+	}
+	data := body[start+len("stream") : end]
+	if bytes.HasPrefix(data, []byte("\r\n")) {
+		data = data[2:]
+	} else if bytes.HasPrefix(data, []byte("\n")) {
+		data = data[1:]
+	}
+	if !bytes.Contains(body[:start], []byte("/FlateDecode")) {
+		return data
+	}
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil
+	}
+	out, _ := io.ReadAll(r)
+	return out
+}
+
+// replace the stream of an object, compressed again if the object uses the Flate filter
+func pdf_set_stream(body []byte, data []byte) []byte {
+	start := bytes.Index(body, []byte("stream"))
+	dict := body[:start]
+	if bytes.Contains(dict, []byte("/FlateDecode")) {
+		data = pdf_flate(data)
+	}
+	dict = pdf_length_pattern.ReplaceAll(dict, []byte(fmt.Sprintf("/Length %d", len(data))))
+	out := append([]byte{}, dict...)
+	out = append(out, "stream\n"...)
+	out = append(out, data...)
+	return append(out, "\nendstream"...)
+}
+
+// write the objects as a PDF file with a fresh cross-reference table and file identifier
+func (p *pdf_file) bytes(version string) []byte {
+	var numbers []int
+	for n := range p.objects {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	size := numbers[len(numbers)-1] + 1
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-" + version + "\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, size)
+	for _, n := range numbers {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n, p.objects[n])
+	}
+	id := md5.Sum(b.Bytes())
+
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for n := 1; n < size; n++ {
+		if _, ok := p.objects[n]; ok {
+			fmt.Fprintf(&b, "%010d 00000 n \n", offsets[n])
+		} else {
+			b.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&b, "trailer\n<<\n/Size %d\n/Root %d 0 R\n/Info %d 0 R\n/ID [<%X> <%X>]\n>>\nstartxref\n%d\n%%%%EOF\n",
+		size, p.root, p.info, id, id, xref)
+	return b.Bytes()
+}
+
+// check a PDF for the PDF/A-2b requirements, returns the problems found
+func pdfa_check(data []byte) []string {
+	var problems []string
+	if !pdf_header_ok(data) {
+		problems = append(problems, "header is not followed by a binary comment")
+	}
+	p, err := parse_pdf(data)
+	if err != nil {
+		return append(problems, err.Error())
+	}
+	trailer := data[bytes.LastIndex(data, []byte("trailer")):]
+	if !bytes.Contains(trailer, []byte("/ID")) {
+		problems = append(problems, "trailer has no file identifier (/ID)")
+	}
+	if bytes.Contains(trailer, []byte("/Encrypt")) {
+		problems = append(problems, "document is encrypted")
+	}
+
+	catalog := p.objects[p.root]
+	if !bytes.Contains(catalog, []byte("/OutputIntents")) {
+		problems = append(problems, "catalog has no output intent")
+	}
+	var metadata [][]byte
+	if i := bytes.Index(catalog, []byte("/Metadata")); i >= 0 {
+		metadata = pdf_ref_pattern.FindSubmatch(catalog[i:])
+	}
+	if metadata == nil {
+		problems = append(problems, "catalog has no XMP metadata")
+	} else {
+		n, _ := strconv.Atoi(string(metadata[1]))
+		xmp := p.objects[n]
+		if bytes.Contains(xmp[:bytes.Index(xmp, []byte("stream"))+1], []byte("/Filter")) {
+			problems = append(problems, "XMP metadata stream is compressed")
+		}
+		if !bytes.Contains(xmp, []byte("<pdfaid:part>2</pdfaid:part>")) || !bytes.Contains(xmp, []byte("<pdfaid:conformance>B</pdfaid:conformance>")) {
+			problems = append(problems, "XMP metadata has no PDF/A-2b identification")
+		}
+	}
+
+	used := p.used_fonts()
+	var numbers []int
+	for n := range p.objects {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	for _, n := range numbers {
+		body := p.objects[n]
+		for _, bad := range []string{"/JavaScript", "/LZWDecode", "/Launch", "/Sound", "/Movie"} {
+			if bytes.Contains(body, []byte(bad)) {
+				problems = append(problems, fmt.Sprintf("object %d uses %s", n, bad[1:]))
+			}
+		}
+		for _, d := range pdf_annotation_dicts(body) {
+			flags, _, _, ok := pdf_dict_flags(body[d[0]:d[1]])
+			if !ok || flags&pdf_annot_print_flag == 0 || flags&pdf_annot_hidden_flags != 0 {
+				problems = append(problems, fmt.Sprintf("object %d has an annotation that is not printable", n))
+				break
+			}
+		}
+		for _, dict := range pdf_font_dict_pattern.FindAllSubmatch(body, -1) {
+			for _, ref := range pdf_font_ref_pattern.FindAllSubmatch(dict[1], -1) {
+				font, _ := strconv.Atoi(string(ref[2]))
+				if used[string(ref[1])] && !p.font_embedded(font) {
+					problems = append(problems, fmt.Sprintf("font %s (object %d) is not embedded", ref[1], font))
+				}
+			}
+		}
+	}
+	return problems
+}
+
+// PDF/A needs a PDF 1.x header followed by a comment of at least four binary characters
+func pdf_header_ok(data []byte) bool {
+	lines := bytes.SplitN(data, []byte("\n"), 3)
+	if len(lines) < 3 || !regexp.MustCompile(`^%PDF-1\.[0-7]\r?$`).Match(lines[0]) || !bytes.HasPrefix(lines[1], []byte("%")) {
+		return false
+	}
+	binary := 0
+	for _, c := range lines[1] {
+		if c > 127 {
+			binary++
+		}
+	}
+	return binary >= 4
+}
+
+// a font is embedded if its descriptor, or that of its descendant font, has a font file
+func (p *pdf_file) font_embedded(n int) bool {
+	body := p.objects[n]
+	if i := bytes.Index(body, []byte("/DescendantFonts")); i >= 0 {
+		if m := pdf_ref_pattern.FindSubmatch(body[i:]); m != nil {
+			d, _ := strconv.Atoi(string(m[1]))
+			return p.font_embedded(d)
+		}
+	}
+	i := bytes.Index(body, []byte("/FontDescriptor"))
+	if i < 0 {
+		return false
+	}
+	m := pdf_ref_pattern.FindSubmatch(body[i:])
+	if m == nil {
+		return false
+	}
+	d, _ := strconv.Atoi(string(m[1]))
+	return bytes.Contains(p.objects[d], []byte("/FontFile"))
+}
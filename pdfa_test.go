@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+func TestSetAnnotationPrintFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no flags", `<</Type /Annot /Subtype /Link>>`, `<</F 4 /Type /Annot /Subtype /Link>>`},
+		{"not printable", `<</Type /Annot /F 0 /Subtype /Link>>`, `<</Type /Annot /F 4 /Subtype /Link>>`},
+		{"hidden", `<</Type /Annot /F 6>>`, `<</Type /Annot /F 4>>`},
+		{"locked is kept", `<</Type /Annot /F 132>>`, `<</Type /Annot /F 132>>`},
+		{"printable", `<</Type /Annot /F 4>>`, `<</Type /Annot /F 4>>`},
+		{"nested /F is not the flags", `<</Type /Annot /A <</S /Launch /F (a.pdf)>> /Filter 2>>`,
+			`<</F 4 /Type /Annot /A <</S /Launch /F (a.pdf)>> /Filter 2>>`},
+		{"string with >>", `<</Type /Annot /A <</S /URI /URI (https://example.org/>>)>> /F 2>>`,
+			`<</Type /Annot /A <</S /URI /URI (https://example.org/>>)>> /F 4>>`},
+		{"inline annotations of a page", `<</Type /Page /Annots [<</Type /Annot /F 0>> <</Type /Annot /Border [0 0 0]>>]>>`,
+			`<</Type /Page /Annots [<</Type /Annot /F 4>> <</F 4 /Type /Annot /Border [0 0 0]>>]>>`},
+		{"no annotation", `<</Type /Page /F 2>>`, `<</Type /Page /F 2>>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pdf_file{objects: map[int][]byte{1: []byte(tt.body)}}
+			p.set_annotation_print_flags()
+			if got := string(p.objects[1]); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// a one page report with a link annotation
+func test_pdf_document(t *testing.T) pdf.Maroto {
+	t.Helper()
+	m, err := setup_fonts(pdf.NewMaroto(consts.Portrait, consts.A4), font_name_builtin, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("Zażółć gęślą jaźń")
+		})
+	})
+	pdf_link_last_row(m, "https://orcid.org/0000-0003-3674-598X", 10, 12, 0)
+	return m
+}
+
+func TestPdfFileBytes(t *testing.T) {
+	data := test_metadata(t, `{"Title": "Zażółć", "Creator": [{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}}], "Tag": ["poetry"]}`)
+
+	out, err := pdf_file_bytes(test_pdf_document(t), data, "test.pdf", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(out, []byte("/Author (Ada Lovelace)")) || !bytes.Contains(out, []byte("/Keywords (poetry)")) {
+		t.Error("document information is missing")
+	}
+	if !bytes.Contains(out, []byte("/Producer (readYmeta")) {
+		t.Error("producer is not readYmeta")
+	}
+	if bytes.Contains(out, []byte("/Metadata")) {
+		t.Error("XMP metadata written without -pdfa")
+	}
+
+	out, err = pdf_file_bytes(test_pdf_document(t), data, "test.pdf", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := pdfa_check(out); len(problems) > 0 {
+		t.Errorf("PDF/A self-check: %v", problems)
+	}
+	if !bytes.Contains(out, []byte("/Author (Ada Lovelace)")) {
+		t.Error("document information is missing")
+	}
+}
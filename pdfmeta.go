@@ -0,0 +1,211 @@
+/*
+pdfmeta.go PDF document properties. The document information dictionary and the XMP metadata are
+written from the Yoda metadata: title, creators as author, a description excerpt as subject and
+the tags as keywords. The PDF library writes the information dictionary, but it does not link its
+XMP metadata from the catalog, so for PDF/A both are written into the parsed PDF (see pdfa.go).
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// maximum length of the description excerpt used as document subject
+const pdf_subject_length int = 250
+
+// document information written to both the information dictionary and the XMP metadata
+type PdfDocumentInfo struct {
+	Title    string
+	Authors  []string
+	Subject  string
+	Keywords []string
+	Creator  string
+	Producer string
+	Created  time.Time
+}
+
+// document information from the Yoda metadata
+func pdf_document_info(data Yoda18Metadata) PdfDocumentInfo {
+	info := PdfDocumentInfo{
+		Title:    collapse_whitespace(data.Title),
+		Subject:  shorten_text(collapse_whitespace(data.Description), pdf_subject_length),
+		Creator:  "readYmeta " + _MYVERSION_,
+		Producer: "readYmeta " + _MYVERSION_ + " (gofpdf)",
+		Created:  time.Now().UTC().Truncate(time.Second),
+	}
+	for _, c := range data.Creator {
+		name := strings.TrimSpace(c.Name.GivenName + " " + c.Name.FamilyName)
+		if name != "" {
+			info.Authors = append(info.Authors, collapse_whitespace(name))
+		}
+	}
+	for _, t := range data.Tag {
+		if !is_blank(t) {
+			info.Keywords = append(info.Keywords, collapse_whitespace(t))
+		}
+	}
+	return info
+}
+
+// write the document with its properties taken from the metadata, as PDF/A-2b if pdfa is set in
+// which case any compliance problems found by the self-check are reported
+func write_pdf_file(m pdf.Maroto, data Yoda18Metadata, fname string, pdfa bool) error {
+	out, err := pdf_file_bytes(m, data, fname, pdfa)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fname, out, 0644)
+}
+
+// the PDF file of a document with the document information of the data package, PDF/A files also
+// get XMP metadata, fname is only used in messages
+func pdf_file_bytes(m pdf.Maroto, data Yoda18Metadata, fname string, pdfa bool) ([]byte, error) {
+	info := pdf_document_info(data)
+	// the PDF library writes the information dictionary, only PDF/A needs the file rewritten
+	if pm, ok := base_maroto(m); ok && !pdfa {
+		// strings are written as UTF-16 when flagged as UTF-8, plain ASCII is kept readable
+		pm.Pdf.SetTitle(info.Title, !pdf_ascii(info.Title))
+		authors := strings.Join(info.Authors, "; ")
+		pm.Pdf.SetAuthor(authors, !pdf_ascii(authors))
+		pm.Pdf.SetSubject(info.Subject, !pdf_ascii(info.Subject))
+		keywords := strings.Join(info.Keywords, ", ")
+		pm.Pdf.SetKeywords(keywords, !pdf_ascii(keywords))
+		pm.Pdf.SetCreator(info.Creator, false)
+		pm.Pdf.SetCreationDate(info.Created)
+		// not part of Maroto's interface to the PDF library
+		if f, ok := pm.Pdf.(*gofpdf.Fpdf); ok {
+			f.SetProducer(info.Producer, false)
+			f.SetModificationDate(info.Created)
+		}
+	}
+	buf, err := m.Output()
+	if err != nil {
+		return nil, err
+	}
+	if !pdfa {
+		return buf.Bytes(), nil
+	}
+
+	p, err := parse_pdf(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	p.convert_pdfa()
+	xmp := pdf_xmp(info, pdfa)
+	xmp_obj := p.add_object([]byte(fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>\nstream\n%s\nendstream", len(xmp), xmp)))
+	p.add_to_catalog(fmt.Sprintf("/Metadata %d 0 R", xmp_obj))
+	p.objects[p.info] = pdf_info_dictionary(info)
+	out := p.bytes("1.7")
+
+	if problems := pdfa_check(out); len(problems) > 0 {
+		fmt.Printf("PDF/A-2b self-check found %d problems in %s:\n", len(problems), fname)
+		for _, problem := range problems {
+			fmt.Println(" -", problem)
+		}
+	} else {
+		fmt.Println("PDF/A-2b self-check passed")
+	}
+	return out, nil
+}
+
+// XMP metadata packet, with the PDF/A identification for archival output
+func pdf_xmp(info PdfDocumentInfo, pdfa bool) []byte {
+	esc := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	date := info.Created.Format("2006-01-02T15:04:05Z")
+
+	var x strings.Builder
+	x.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	x.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n<rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	x.WriteString("<rdf:Description rdf:about=\"\" xmlns:pdfaid=\"http://www.aiim.org/pdfa/ns/id/\" xmlns:dc=\"http://purl.org/dc/elements/1.1/\"" +
+		" xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\" xmlns:pdf=\"http://ns.adobe.com/pdf/1.3/\">\n")
+	if pdfa {
+		x.WriteString("<pdfaid:part>2</pdfaid:part>\n<pdfaid:conformance>B</pdfaid:conformance>\n")
+	}
+	x.WriteString("<dc:format>application/pdf</dc:format>\n")
+	if info.Title != "" {
+		x.WriteString("<dc:title><rdf:Alt><rdf:li xml:lang=\"x-default\">" + esc(info.Title) + "</rdf:li></rdf:Alt></dc:title>\n")
+	}
+	if len(info.Authors) > 0 {
+		// the information dictionary has a single author entry, dc:creator has to match it
+		x.WriteString("<dc:creator><rdf:Seq><rdf:li>" + esc(strings.Join(info.Authors, "; ")) + "</rdf:li></rdf:Seq></dc:creator>\n")
+	}
+	if info.Subject != "" {
+		x.WriteString("<dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">" + esc(info.Subject) + "</rdf:li></rdf:Alt></dc:description>\n")
+	}
+	if len(info.Keywords) > 0 {
+		x.WriteString("<dc:subject><rdf:Bag>")
+		for _, k := range info.Keywords {
+			x.WriteString("<rdf:li>" + esc(k) + "</rdf:li>")
+		}
+		x.WriteString("</rdf:Bag></dc:subject>\n")
+		x.WriteString("<pdf:Keywords>" + esc(strings.Join(info.Keywords, ", ")) + "</pdf:Keywords>\n")
+	}
+	x.WriteString("<pdf:Producer>" + esc(info.Producer) + "</pdf:Producer>\n")
+	x.WriteString("<xmp:CreatorTool>" + esc(info.Creator) + "</xmp:CreatorTool>\n")
+	x.WriteString("<xmp:CreateDate>" + date + "</xmp:CreateDate>\n<xmp:ModifyDate>" + date + "</xmp:ModifyDate>\n")
+	x.WriteString("<xmp:MetadataDate>" + date + "</xmp:MetadataDate>\n")
+	x.WriteString("</rdf:Description>\n</rdf:RDF>\n</x:xmpmeta>\n<?xpacket end=\"w\"?>")
+	return []byte(x.String())
+}
+
+// document information dictionary matching the XMP metadata
+func pdf_info_dictionary(info PdfDocumentInfo) []byte {
+	date := pdf_text_string("D:" + info.Created.Format("20060102150405") + "Z")
+	var d strings.Builder
+	d.WriteString("<<\n")
+	if info.Title != "" {
+		d.WriteString("/Title " + pdf_text_string(info.Title) + "\n")
+	}
+	if len(info.Authors) > 0 {
+		d.WriteString("/Author " + pdf_text_string(strings.Join(info.Authors, "; ")) + "\n")
+	}
+	if info.Subject != "" {
+		d.WriteString("/Subject " + pdf_text_string(info.Subject) + "\n")
+	}
+	if len(info.Keywords) > 0 {
+		d.WriteString("/Keywords " + pdf_text_string(strings.Join(info.Keywords, ", ")) + "\n")
+	}
+	d.WriteString("/Creator " + pdf_text_string(info.Creator) + "\n")
+	d.WriteString("/Producer " + pdf_text_string(info.Producer) + "\n")
+	d.WriteString("/CreationDate " + date + "\n/ModDate " + date + "\n>>")
+	return []byte(d.String())
+}
+
+// whether s can be written as a literal PDF string
+func pdf_ascii(s string) bool {
+	for _, r := range s {
+		if r > 126 || r < 32 {
+			return false
+		}
+	}
+	return true
+}
+
+// PDF text string, ASCII as a literal string and anything else as UTF-16BE with byte order mark
+func pdf_text_string(s string) string {
+	if pdf_ascii(s) {
+		return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+	}
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteString(">")
+	return b.String()
+}
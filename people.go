@@ -0,0 +1,352 @@
+/*
+people.go person reconciliation, the creators and contributors of a data package are clustered
+by person identifier and by fuzzy name matching (diacritics, initials, swapped given and family
+names, small spelling differences) to find likely duplicates and conflicting affiliations.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// a creator or contributor in a form independent of its role
+type Person struct {
+	Field           string
+	Role            string
+	ContributorType string
+	GivenName       string
+	FamilyName      string
+	Affiliations    []string
+	Identifiers     []string
+}
+
+// a group of entries that are likely the same person, Reason explains the first match
+type PersonCluster struct {
+	People []Person
+	Reason string
+}
+
+// display name of a person
+func (p Person) name() string {
+	return strings.TrimSpace(strings.TrimSpace(p.GivenName) + " " + strings.TrimSpace(p.FamilyName))
+}
+
+// all creators and contributors of the metadata
+func collect_people(data Yoda18Metadata) []Person {
+	var people []Person
+	for i, c := range data.Creator {
+		p := Person{Field: fmt.Sprintf("Creator[%d]", i+1), Role: "Creator", GivenName: c.Name.GivenName, FamilyName: c.Name.FamilyName}
+		p.Affiliations = c.Affiliation
+		for _, pid := range c.PersonIdentifier {
+			if !is_blank(pid.NameIdentifier) {
+				p.Identifiers = append(p.Identifiers, fmt.Sprintf("(%s) %s", strings.TrimSpace(pid.NameIdentifierScheme), strings.TrimSpace(pid.NameIdentifier)))
+			}
+		}
+		people = append(people, p)
+	}
+	for i, c := range data.Contributor {
+		p := Person{Field: fmt.Sprintf("Contributor[%d]", i+1), Role: "Contributor", ContributorType: c.ContributorType,
+			GivenName: c.Name.GivenName, FamilyName: c.Name.FamilyName}
+		p.Affiliations = c.Affiliation
+		for _, pid := range c.PersonIdentifier {
+			if !is_blank(pid.NameIdentifier) {
+				p.Identifiers = append(p.Identifiers, fmt.Sprintf("(%s) %s", strings.TrimSpace(pid.NameIdentifierScheme), strings.TrimSpace(pid.NameIdentifier)))
+			}
+		}
+		people = append(people, p)
+	}
+	return people
+}
+
+// cluster the people, every person ends up in exactly one cluster, in order of first appearance
+func reconcile_people(people []Person) []PersonCluster {
+	parent := make([]int, len(people))
+	reason := make([]string, len(people))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	// members of every cluster by its root
+	members := make([][]int, len(people))
+	for i := range members {
+		members[i] = []int{i}
+	}
+	// a name match only joins two clusters if all their names match, so "J. Smith" or a bare
+	// "Smith" does not bridge John Smith and Jane Smith; a shared identifier always does
+	names_compatible := func(a int, b int) bool {
+		for _, x := range members[a] {
+			for _, y := range members[b] {
+				if same_person(people[x], people[y]) == "" {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	for i := range people {
+		for j := i + 1; j < len(people); j++ {
+			if why := same_person(people[i], people[j]); why != "" {
+				a, b := find(i), find(j)
+				if a == b || (!same_person_identifier(people[i], people[j]) && !names_compatible(a, b)) {
+					continue
+				}
+				if a > b {
+					a, b = b, a
+				}
+				parent[b] = a
+				members[a] = append(members[a], members[b]...)
+				members[b] = nil
+				if reason[a] == "" {
+					reason[a] = why
+				}
+			}
+		}
+	}
+
+	var clusters []PersonCluster
+	index := map[int]int{}
+	for i, p := range people {
+		root := find(i)
+		if k, ok := index[root]; ok {
+			clusters[k].People = append(clusters[k].People, p)
+		} else {
+			index[root] = len(clusters)
+			clusters = append(clusters, PersonCluster{People: []Person{p}, Reason: reason[root]})
+		}
+	}
+	return clusters
+}
+
+// return why two entries are likely the same person, or "" if they are not
+func same_person(a Person, b Person) string {
+	name_match := person_names_match(a, b)
+	for _, ida := range a.Identifiers {
+		for _, idb := range b.Identifiers {
+			if person_identifier_key(ida) == person_identifier_key(idb) {
+				if name_match == "" {
+					return tr("people.same_identifier_names", ida)
+				}
+				return tr("people.same_identifier", ida)
+			}
+		}
+	}
+	return name_match
+}
+
+// return how the names of two entries match, or "" if they do not
+func person_names_match(a Person, b Person) string {
+	ga, fa := name_tokens(a.GivenName), name_tokens(a.FamilyName)
+	gb, fb := name_tokens(b.GivenName), name_tokens(b.FamilyName)
+	if len(fa) == 0 || len(fb) == 0 {
+		return ""
+	}
+	switch {
+	case names_match(fa, fb) && given_names_match(ga, gb):
+		return tr("people.matching_names")
+	case names_match(fa, gb) && given_names_match(ga, fb) && len(ga) > 0 && len(gb) > 0:
+		return tr("people.swapped_names")
+	}
+	return ""
+}
+
+// compare identifiers on their value, ORCIDs in any notation are equal
+func person_identifier_key(identifier string) string {
+	value := identifier[strings.Index(identifier, ")")+1:]
+	if id, ok := parse_orcid(value); ok {
+		return "orcid:" + id
+	}
+	return strings.ToLower(strings.TrimSpace(identifier))
+}
+
+// lower case name parts without diacritics and punctuation
+func name_tokens(name string) []string {
+	var out strings.Builder
+	for _, r := range norm.NFD.String(name) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+		case unicode.IsLetter(r):
+			out.WriteRune(unicode.ToLower(r))
+		default:
+			out.WriteRune(' ')
+		}
+	}
+	return strings.Fields(out.String())
+}
+
+// family names match if they are equal or differ by a small spelling difference, short names
+// (Li, Lu, Ma) must be equal
+func names_match(a []string, b []string) bool {
+	x, y := strings.Join(a, " "), strings.Join(b, " ")
+	if x == y {
+		return true
+	}
+	lx, ly := utf8.RuneCountInString(x), utf8.RuneCountInString(y)
+	switch {
+	case lx < 5 || ly < 5:
+		return false
+	case lx >= 8 && ly >= 8:
+		return levenshtein(x, y) <= 2
+	}
+	return levenshtein(x, y) <= 1
+}
+
+// given names match if every part is equal or an initial of the other, "J. P." matches "Jan Pieter"
+func given_names_match(a []string, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		x, y := a[i], b[i]
+		switch {
+		case x == y:
+		case len(x) == 1 && strings.HasPrefix(y, x):
+		case len(y) == 1 && strings.HasPrefix(x, y):
+		case len(x) > 3 && len(y) > 3 && levenshtein(x, y) <= 1:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// edit distance between two strings
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min_int(min_int(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
+
+func min_int(a int, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// merged view of a cluster: name, roles, affiliations and identifiers
+func merge_person_cluster(c PersonCluster) (string, []string, []string, []string) {
+	var names []Person
+	var roles, affiliations, identifiers []string
+	seen_roles, seen_affiliations, seen_identifiers := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	add := func(seen map[string]bool, list []string, value string) []string {
+		key := strings.ToLower(collapse_whitespace(value))
+		if key == "" || seen[key] {
+			return list
+		}
+		seen[key] = true
+		return append(list, collapse_whitespace(value))
+	}
+	for _, p := range c.People {
+		// keep one spelling per distinct name, the longest is usually the most complete one
+		known := false
+		for k := range names {
+			if person_names_match(names[k], p) != "" || names[k].name() == p.name() {
+				known = true
+				if len(p.name()) > len(names[k].name()) {
+					names[k] = p
+				}
+			}
+		}
+		if !known {
+			names = append(names, p)
+		}
+		role := tr("role." + p.Role)
+		if p.ContributorType != "" {
+			role += " (" + p.ContributorType + ")"
+		}
+		roles = add(seen_roles, roles, role)
+		for _, a := range p.Affiliations {
+			affiliations = add(seen_affiliations, affiliations, a)
+		}
+		for _, id := range p.Identifiers {
+			identifiers = add(seen_identifiers, identifiers, id)
+		}
+	}
+	var name []string
+	for _, p := range names {
+		if p.name() != "" {
+			name = append(name, p.name())
+		}
+	}
+	return strings.Join(name, " / "), roles, affiliations, identifiers
+}
+
+// likely duplicate people across and within the creators and contributors
+func check_duplicate_people(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for _, c := range reconcile_people(collect_people(data)) {
+		if len(c.People) < 2 {
+			continue
+		}
+		var entries []string
+		for _, p := range c.People {
+			entries = append(entries, fmt.Sprintf("%s \"%s\"", p.Field, p.name()))
+		}
+		findings = append(findings, Finding{Field: c.People[0].Field,
+			Message: tr("msg.duplicate_person", strings.Join(entries, ", "), c.Reason)})
+	}
+	return findings
+}
+
+// likely duplicate people that list different affiliations
+func check_person_affiliations(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for _, c := range reconcile_people(collect_people(data)) {
+		if len(c.People) < 2 {
+			continue
+		}
+		sets := map[string][]string{}
+		for _, p := range c.People {
+			var affs []string
+			for _, a := range p.Affiliations {
+				if !is_blank(a) {
+					affs = append(affs, strings.ToLower(collapse_whitespace(a)))
+				}
+			}
+			sort.Strings(affs)
+			key := strings.Join(affs, "|")
+			sets[key] = append(sets[key], p.Field)
+		}
+		if len(sets) > 1 {
+			name, _, affiliations, _ := merge_person_cluster(c)
+			findings = append(findings, Finding{Field: c.People[0].Field,
+				Message: tr("msg.person_affiliation_conflict", name, strings.Join(affiliations, "; "))})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+	for _, c := range []struct {
+		a, b string
+		d    int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"jansen", "jansen", 0},
+		{"jansen", "janssen", 1},
+		{"kitten", "sitting", 3},
+		{"müller", "muller", 1},
+	} {
+		if d := levenshtein(c.a, c.b); d != c.d {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", c.a, c.b, d, c.d)
+		}
+		if d := levenshtein(c.b, c.a); d != c.d {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", c.b, c.a, d, c.d)
+		}
+	}
+}
+
+func TestPersonNamesMatch(t *testing.T) {
+	for _, c := range []struct {
+		given_a, family_a string
+		given_b, family_b string
+		reason            string
+	}{
+		{"Jan", "Jansen", "Jan", "Jansen", "people.matching_names"},
+		{"Jan Pieter", "de Vries", "J. P.", "De Vries", "people.matching_names"},
+		{"José", "Núñez", "Jose", "Nunez", "people.matching_names"},
+		{"Jan", "Jansen", "Jan", "Janssen", "people.matching_names"},
+		{"Johannes", "van der Berg", "Johanes", "van den Berg", "people.matching_names"},
+		{"", "Jansen", "Piet", "Jansen", "people.matching_names"},
+		{"Jansen", "Jan", "Jan", "Jansen", "people.swapped_names"},
+		{"Jan", "Jansen", "Piet", "Jansen", ""},
+		{"Jan", "Jansen", "Jan", "Pietersen", ""},
+		{"Jan", "Li", "Jan", "Lu", ""},
+		{"Jan", "Lima", "Jan", "Lina", ""},
+		{"Jan", "Li", "Jan", "Ma", ""},
+		{"Jan", "", "Jan", "", ""},
+	} {
+		a := Person{GivenName: c.given_a, FamilyName: c.family_a}
+		b := Person{GivenName: c.given_b, FamilyName: c.family_b}
+		want := ""
+		if c.reason != "" {
+			want = tr(c.reason)
+		}
+		if got := person_names_match(a, b); got != want {
+			t.Errorf("%s %s / %s %s: %q, want %q", c.given_a, c.family_a, c.given_b, c.family_b, got, want)
+		}
+	}
+}
+
+func TestReconcilePeople(t *testing.T) {
+	data := test_metadata(t, `{
+		"Creator": [
+			{"Name": {"Given_Name": "Jan", "Family_Name": "Jansen"}, "Affiliation": ["VU"],
+				"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]},
+			{"Name": {"Given_Name": "Ann", "Family_Name": "Smith"}},
+			{"Name": {"Given_Name": "Bob", "Family_Name": "Jones"}, "Affiliation": [" vu"],
+				"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "https://orcid.org/0000-0002-1825-0097"}]}
+		],
+		"Contributor": [
+			{"Name": {"Given_Name": "A.", "Family_Name": "Smith"}, "Affiliation": ["UvA"], "Contributor_Type": "Editor"},
+			{"Name": {"Given_Name": "Carla", "Family_Name": "Diaz"}}
+		]}`)
+	clusters := reconcile_people(collect_people(data))
+	var got [][]string
+	for _, c := range clusters {
+		var fields []string
+		for _, p := range c.People {
+			fields = append(fields, p.Field)
+		}
+		got = append(got, fields)
+	}
+	want := [][]string{{"Creator[1]", "Creator[3]"}, {"Creator[2]", "Contributor[1]"}, {"Contributor[2]"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("clusters %v, want %v", got, want)
+	}
+	if clusters[0].Reason != tr("people.same_identifier_names", "(ORCID) 0000-0002-1825-0097") {
+		t.Errorf("reason %q", clusters[0].Reason)
+	}
+	if clusters[1].Reason != tr("people.matching_names") || clusters[2].Reason != "" {
+		t.Errorf("reasons %q, %q", clusters[1].Reason, clusters[2].Reason)
+	}
+
+	findings := check_person_affiliations(data, nil, Profile{})
+	if len(findings) != 1 || findings[0].Field != "Creator[2]" {
+		t.Errorf("affiliation conflicts %+v", findings)
+	}
+}
+
+func TestReconcilePeopleNoBridge(t *testing.T) {
+	for _, given := range []string{"", "J."} {
+		people := []Person{
+			{Field: "Creator[1]", GivenName: "John", FamilyName: "Smith"},
+			{Field: "Creator[2]", GivenName: given, FamilyName: "Smith"},
+			{Field: "Creator[3]", GivenName: "Jane", FamilyName: "Smith"},
+		}
+		clusters := reconcile_people(people)
+		if len(clusters) != 2 || len(clusters[0].People) != 2 || clusters[1].People[0].GivenName != "Jane" {
+			t.Errorf("%q Smith bridges John and Jane Smith: %+v", given, clusters)
+		}
+	}
+}
+
+func TestMergePersonCluster(t *testing.T) {
+	c := PersonCluster{People: []Person{
+		{Role: "Creator", GivenName: "Jan", FamilyName: "Jansen", Affiliations: []string{tr("role.Creator")}},
+		{Role: "Contributor", ContributorType: "Editor", GivenName: "J.", FamilyName: "Jansen",
+			Affiliations: []string{"VU", "vu "}, Identifiers: []string{"VU"}},
+	}}
+	name, roles, affiliations, identifiers := merge_person_cluster(c)
+	if name != "Jan Jansen" {
+		t.Errorf("name %q", name)
+	}
+	if want := []string{tr("role.Creator"), tr("role.Contributor") + " (Editor)"}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles %v, want %v", roles, want)
+	}
+	// every list is deduplicated on its own
+	if want := []string{tr("role.Creator"), "VU"}; !reflect.DeepEqual(affiliations, want) {
+		t.Errorf("affiliations %v, want %v", affiliations, want)
+	}
+	if want := []string{"VU"}; !reflect.DeepEqual(identifiers, want) {
+		t.Errorf("identifiers %v, want %v", identifiers, want)
+	}
+}
@@ -0,0 +1,174 @@
+/*
+profile.go rule profiles for readYmeta, a profile enables/disables validation rules, sets their
+severity and declares the required and recommended Yoda metadata fields.
+Profiles are YAML (.yaml/.yml) or TOML (.toml) files and are selected on the command line
+with -profile <name|path>.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// profiles shipped with readYmeta, selectable by name
+//
+//go:embed profiles/*.yaml
+var builtin_profiles embed.FS
+
+const default_profile_name string = "default"
+
+// Severity of a validation finding, maps onto pdfInfoColour/pdfWarningColour/pdfErrorColour
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Per rule settings, a nil Enabled means the rule is enabled
+type RuleConfig struct {
+	Enabled  *bool    `yaml:"enabled" toml:"enabled"`
+	Severity Severity `yaml:"severity" toml:"severity"`
+}
+
+// Numeric limits used by the threshold rules, zero disables the check
+type ProfileThresholds struct {
+	MinTags              int `yaml:"min_tags" toml:"min_tags"`
+	MinDescriptionLength int `yaml:"min_description_length" toml:"min_description_length"`
+}
+
+// A rule profile, Required and Recommended contain Yoda field paths such as "Creator.Person_Identifier"
+type Profile struct {
+	Name        string                `yaml:"name" toml:"name"`
+	Description string                `yaml:"description" toml:"description"`
+	Rules       map[string]RuleConfig `yaml:"rules" toml:"rules"`
+	Required    []string              `yaml:"required" toml:"required"`
+	Recommended []string              `yaml:"recommended" toml:"recommended"`
+	Thresholds  ProfileThresholds     `yaml:"thresholds" toml:"thresholds"`
+}
+
+// load a profile by path, by name from ./profiles or by name from the builtin profiles
+func load_profile(name_or_path string) (Profile, error) {
+	var profile Profile
+
+	if name_or_path == "" {
+		name_or_path = default_profile_name
+	}
+
+	// an existing file always wins
+	if info, err := os.Stat(name_or_path); err == nil && !info.IsDir() {
+		return read_profile_file(name_or_path)
+	}
+
+	// a name, try the local profiles directory first
+	for _, ext := range []string{".yaml", ".yml", ".toml"} {
+		fname := filepath.Join("profiles", name_or_path+ext)
+		if _, err := os.Stat(fname); err == nil {
+			return read_profile_file(fname)
+		}
+	}
+
+	// fall back on the profiles compiled into readYmeta
+	data, err := builtin_profiles.ReadFile("profiles/" + name_or_path + ".yaml")
+	if err != nil {
+		return profile, fmt.Errorf("unknown profile \"%s\", available profiles: %s", name_or_path,
+			strings.Join(list_builtin_profiles(), ", "))
+	}
+	profile, err = parse_profile(data, ".yaml")
+	if err != nil {
+		return profile, fmt.Errorf("builtin profile \"%s\": %w", name_or_path, err)
+	}
+	return profile, nil
+}
+
+// read and parse a profile file, the format is taken from the file extension
+func read_profile_file(fname string) (Profile, error) {
+	var profile Profile
+
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return profile, err
+	}
+	profile, err = parse_profile(data, strings.ToLower(filepath.Ext(fname)))
+	if err != nil {
+		return profile, fmt.Errorf("profile file \"%s\": %w", fname, err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname))
+	}
+	return profile, nil
+}
+
+// decode YAML or TOML profile data and check the result
+func parse_profile(data []byte, ext string) (Profile, error) {
+	var profile Profile
+	var err error
+
+	switch ext {
+	case ".toml":
+		_, err = toml.Decode(string(data), &profile)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &profile)
+	default:
+		err = fmt.Errorf("unsupported profile format \"%s\", use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return profile, err
+	}
+	return profile, check_profile(profile)
+}
+
+// check that a profile only refers to known rules and severities
+func check_profile(profile Profile) error {
+	for id, cfg := range profile.Rules {
+		if get_rule(id) == nil {
+			return fmt.Errorf("unknown rule \"%s\"", id)
+		}
+		switch cfg.Severity {
+		case "", SeverityInfo, SeverityWarning, SeverityError:
+		default:
+			return fmt.Errorf("rule \"%s\" has invalid severity \"%s\", use info, warning or error", id, cfg.Severity)
+		}
+	}
+	return nil
+}
+
+// names of the builtin profiles
+func list_builtin_profiles() []string {
+	var names []string
+	entries, _ := builtin_profiles.ReadDir("profiles")
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return names
+}
+
+// is rule id enabled in this profile
+func (p Profile) rule_enabled(id string) bool {
+	cfg, ok := p.Rules[id]
+	if !ok || cfg.Enabled == nil {
+		return true
+	}
+	return *cfg.Enabled
+}
+
+// severity of rule id in this profile, falls back on the rule default
+func (p Profile) rule_severity(id string) Severity {
+	if cfg, ok := p.Rules[id]; ok && cfg.Severity != "" {
+		return cfg.Severity
+	}
+	if r := get_rule(id); r != nil {
+		return r.severity
+	}
+	return SeverityWarning
+}
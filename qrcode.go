@@ -0,0 +1,89 @@
+/*
+qrcode.go QR codes in the PDF report (-qr), so that a printed report can be scanned back to the
+online record. The first page gets a QR code for the landing page of the data package: the
+-landing-page URL, or the package DOI taken from the links or an IsIdenticalTo related
+datapackage. Related datapackages with a resolvable identifier get a QR code next to them.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+// grid columns used for a QR code
+const qr_colwidth uint = 2
+
+// height of the row holding the landing page QR code in mm
+const qr_landing_height float64 = 24
+
+// landing page of the data package and a description of where it came from, "" if there is none
+func package_landing_page(data Yoda18Metadata, landing string) (string, string) {
+	if strings.TrimSpace(landing) != "" {
+		return strings.TrimSpace(landing), tr("qr.landing_page")
+	}
+	for _, l := range data.Links {
+		if u, ok := identifier_url("DOI", l.Href); ok {
+			return u, "DOI"
+		}
+	}
+	for _, rel := range data.RelatedDatapackage {
+		if strings.HasPrefix(strings.TrimSpace(rel.RelationType), "IsIdenticalTo") {
+			if u, ok := identifier_url(rel.PersistentIdentifier.IdentifierScheme, rel.PersistentIdentifier.Identifier); ok {
+				return u, rel.PersistentIdentifier.IdentifierScheme
+			}
+		}
+	}
+	return "", ""
+}
+
+// QR code for the landing page of the data package with its URL as a link
+func pdf_write_landing_qr(m pdf.Maroto, link string, source string, rowheight float64, colwidth uint) {
+	if link == "" {
+		pdf_write_row(m, tr("qr.none"), rowheight, colwidth, consts.Normal, pdfInfoColour())
+		return
+	}
+	m.Row(qr_landing_height, func() {
+		m.Col(colwidth-qr_colwidth, func() {
+			m.Text(tr("qr.online_record", source), props.Text{Top: qr_landing_height/2 - rowheight, Size: fontsize, Style: consts.Bold, Color: pdfBlack()})
+			m.Text(link, props.Text{Top: qr_landing_height / 2, Size: fontsize, Color: pdfLinkColour()})
+		})
+		m.Col(qr_colwidth, func() {
+			m.QrCode(link, props.Rect{Center: true, Percent: 95})
+		})
+	})
+	if pm, ok := base_maroto(m); ok {
+		left, _, _, _ := m.GetPageMargins()
+		pm.Pdf.LinkString(left, pm.Pdf.GetY()-qr_landing_height/2, grid_width(m, colwidth-qr_colwidth), rowheight, link)
+	}
+}
+
+// a related datapackage with a QR code for its identifier next to it
+func pdf_write_related_qr(m pdf.Maroto, reltype string, scheme string, identifier string, title string, link string,
+	rowheight float64, colwidth uint, reltypecolour color.Color, titlecolour color.Color) {
+	height := 3 * rowheight
+	m.Row(height, func() {
+		m.Col(1, func() {
+			m.Text(reltype, props.Text{Size: fontsize, Extrapolate: true, Color: reltypecolour})
+			m.Text(indentsymb, props.Text{Top: rowheight, Size: fontsize - 1, Color: pdfLinkColour()})
+			m.Text(indentsymb, props.Text{Top: 2 * rowheight, Size: fontsize - 1, Color: titlecolour})
+		})
+		m.Col(colwidth-1-qr_colwidth, func() {
+			m.Text("("+scheme+") "+identifier, props.Text{Top: rowheight, Size: fontsize - 1, Color: pdfLinkColour()})
+			m.Text(title, props.Text{Top: 2 * rowheight, Size: fontsize - 1, Color: titlecolour})
+		})
+		m.Col(qr_colwidth, func() {
+			m.QrCode(link, props.Rect{Center: true, Percent: 100})
+		})
+	})
+	if pm, ok := base_maroto(m); ok {
+		left, _, _, _ := m.GetPageMargins()
+		pm.Pdf.LinkString(left+grid_width(m, 1), pm.Pdf.GetY()-2*rowheight, grid_width(m, colwidth-1-qr_colwidth), rowheight, link)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPackageLandingPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		landing string
+		url     string
+		source  string
+	}{
+		{"none", `{}`, "", "", ""},
+		{"landing page option", `{"links": [{"href": "10.5281/zenodo.1"}]}`, " https://example.org/package ", "https://example.org/package", tr("qr.landing_page")},
+		{"DOI link", `{"links": [{"rel": "describedby", "href": "https://example.org/schema.json"}, {"href": "10.5281/zenodo.1"}]}`, "",
+			"https://doi.org/10.5281/zenodo.1", "DOI"},
+		{"identical datapackage", `{"Related_Datapackage": [
+			{"Relation_Type": "IsPartOf: Current datapackage is part of", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.2"}},
+			{"Relation_Type": "IsIdenticalTo: Current datapackage is identical to", "Persistent_Identifier": {"Identifier_Scheme": "Handle", "Identifier": "21.12109/abc"}}]}`, "",
+			"https://hdl.handle.net/21.12109/abc", "Handle"},
+		{"identical datapackage without a resolvable identifier", `{"Related_Datapackage": [
+			{"Relation_Type": "IsIdenticalTo: Current datapackage is identical to", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "zenodo"}}]}`, "", "", ""},
+	}
+	for _, tt := range tests {
+		url, source := package_landing_page(test_metadata(t, tt.doc), tt.landing)
+		if url != tt.url || source != tt.source {
+			t.Errorf("%s: %q (%s), want %q (%s)", tt.name, url, source, tt.url, tt.source)
+		}
+	}
+}
+
+func TestReportQrCodes(t *testing.T) {
+	data := test_metadata(t, `{"Title": "QR", "links": [{"href": "10.5281/zenodo.1"}], "Related_Datapackage": [
+		{"Relation_Type": "IsPartOf: Current datapackage is part of", "Title": "Whole",
+			"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.2"}},
+		{"Relation_Type": "References: Current datapackage references", "Title": "No identifier"}]}`)
+	render := func(qr bool) []byte {
+		saved := *qr_flag
+		*qr_flag = qr
+		defer func() { *qr_flag = saved }()
+		doc, err := create_pdf_report(data, "qr.json", MetadataReport{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := pdf_file_bytes(doc, data, "qr.json", false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return out
+	}
+	// a code for the landing page and one for the related datapackage with an identifier
+	out := render(true)
+	if got := bytes.Count(out, []byte("/Subtype /Image")); got != 2 {
+		t.Errorf("%d QR codes, want 2", got)
+	}
+	for _, link := range []string{"https://doi.org/10.5281/zenodo.1", "https://doi.org/10.5281/zenodo.2"} {
+		if !bytes.Contains(out, []byte("/URI ("+link+")")) {
+			t.Errorf("no link to %s", link)
+		}
+	}
+	if got := bytes.Count(render(false), []byte("/Subtype /Image")); got != 0 {
+		t.Errorf("%d QR codes without -qr", got)
+	}
+}
@@ -15,7 +15,9 @@ Date: 2022-08-22
 package main
 
 import (
+	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -37,6 +39,14 @@ import (
 const _MYVERSION_ = "0.8.2"
 
 // Vanilla Yoda metadata struct
+// a GeoLocation bounding box, nil when the metadata has none so that an all-zero box is kept
+type YodaGeoLocationBox struct {
+	WestBoundLongitude float64 `json:"westBoundLongitude"`
+	EastBoundLongitude float64 `json:"eastBoundLongitude"`
+	SouthBoundLatitude float64 `json:"southBoundLatitude"`
+	NorthBoundLatitude float64 `json:"northBoundLatitude"`
+}
+
 type Yoda18Metadata struct {
 	Links []struct {
 		Rel  string `json:"rel"`
@@ -49,7 +59,11 @@ type Yoda18Metadata struct {
 		EndDate   string `json:"End_Date"`
 	} `json:"Collected"`
 	CoveredGeolocationPlace []string `json:"Covered_Geolocation_Place"`
-	CoveredPeriod           struct {
+	GeoLocation             []struct {
+		GeoLocationBox     *YodaGeoLocationBox `json:"geoLocationBox"`
+		DescriptionSpatial string              `json:"Description_Spatial"`
+	} `json:"GeoLocation"`
+	CoveredPeriod struct {
 		StartDate string `json:"Start_Date"`
 		EndDate   string `json:"End_Date"`
 	} `json:"Covered_Period"`
@@ -116,7 +130,12 @@ type Yoda18MetadataV2 struct {
 		EndDate   string `json:"End_Date,omitempty"`
 	} `json:"Collected,omitempty"`
 	CoveredGeolocationPlace []string `json:"Covered_Geolocation_Place,omitempty"`
-	CoveredPeriod           struct {
+	GeoLocation             []struct {
+		// a given box is written with all its coordinates, 0 is a valid coordinate
+		GeoLocationBox     *YodaGeoLocationBox `json:"geoLocationBox,omitempty"`
+		DescriptionSpatial string              `json:"Description_Spatial,omitempty"`
+	} `json:"GeoLocation,omitempty"`
+	CoveredPeriod struct {
 		StartDate string `json:"Start_Date,omitempty"`
 		EndDate   string `json:"End_Date,omitempty"`
 	} `json:"Covered_Period,omitempty"`
@@ -177,7 +196,26 @@ const nullstring string = "<empty>"
 const minRGB8Bytes = 0
 const maxRGB8Bytes = 255
 
-var ERROR_COUNT uint = 0
+// command line options
+var profile_flag = flag.String("profile", default_profile_name, "rule profile, a builtin profile name ("+
+	strings.Join(list_builtin_profiles(), ", ")+") or the path to a YAML/TOML profile file")
+var theme_flag = flag.String("theme", default_theme_name, "report theme, a builtin theme name ("+
+	strings.Join(list_builtin_themes(), ", ")+") or the path to a YAML/TOML theme file")
+var lang_flag = flag.String("lang", language_auto, "report language: "+language_auto+" (the Language field of the metadata) or one of "+
+	strings.Join(list_languages(), ", "))
+var layout_flag = flag.String("layout", layout_auto, "layout of creators, contributors and funding: "+layout_auto+" (tables above -table-above entries), "+
+	layout_rows+" or "+layout_table)
+var table_above_flag = flag.Int("table-above", 10, "number of creators, contributors or funding references above which -layout "+layout_auto+" writes a table")
+var font_flag = flag.String("font", font_name_builtin, "PDF font: "+font_name_builtin+" (bundled Unicode font), "+
+	font_name_core+" (PDF core fonts, Latin-1 only) or the path to a TTF file")
+var pdfa_flag = flag.Bool("pdfa", false, "write archival PDF/A-2b output (needs an embedded font, not -font "+font_name_core+")")
+var toc_flag = flag.Bool("toc", false, "insert a table of contents page at the start of the PDF report")
+var qr_flag = flag.Bool("qr", false, "add QR codes for the landing page of the data package and for related datapackages to the PDF report")
+var landing_page_flag = flag.String("landing-page", "", "landing page URL encoded in the first page QR code (default: the package DOI)")
+var font_fallback_flag = flag.String("font-fallback", "", "comma separated TTF files used for characters the main font cannot render (e.g. CJK, or Arabic with a main font that lacks it)")
+var irods_env_flag = flag.String("irods-env", "", "iRODS environment file for "+irods_prefix+" inputs (default: $IRODS_ENVIRONMENT_FILE or ~/.irods/irods_environment.json)")
+var template_flag = flag.String("template", "", "comma separated report templates (text/template, html/template for *.html.tmpl), builtin names ("+
+	strings.Join(list_builtin_templates(), ", ")+") or paths, each written to <name>.<template name>")
 
 func main() {
 
@@ -186,47 +224,113 @@ func main() {
 	// fmt.Println()
 	fmt.Println(" ")
 
-	// define input and output files
-	input_file_name, input_file_path, output_file_path, err1 := get_input_file_path_from_clargs()
+	// subcommands, the default is to write the reports
+	if len(os.Args) > 1 && os.Args[1] == "fix" {
+		fix_command(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		diff_command(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "history" {
+		history_command(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		import_command(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		export_command(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve_command(os.Args[2:])
+		return
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "Usage: readYmeta [options] <yoda metadata file|-|package directory|archive|irods:<collection>> ...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta fix [options] <yoda metadata file>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta diff [options] <old yoda metadata file> <new yoda metadata file>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta history [options] <package directory>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta import [options] <datacite xml or json file|spreadsheet>")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta export [options] <yoda metadata input> ...")
+		fmt.Fprintln(flag.CommandLine.Output(), "       readYmeta serve [options]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	profile, err0 := load_profile(*profile_flag)
+	errcntrl(err0)
+	fmt.Println("Using rule profile:", profile.Name)
+	REPORT_THEME, err0 = load_theme(*theme_flag)
+	errcntrl(err0)
+	apply_theme_fonts(REPORT_THEME, flag.CommandLine)
+	fmt.Println("Using report theme:", REPORT_THEME.Name)
+	errcntrl(check_language(*lang_flag))
+	errcntrl(check_layout(*layout_flag))
+	MARKDOWN_TEMPLATE, err0 = load_report_template(default_template_name)
+	errcntrl(err0)
+	REPORT_TEMPLATES, err0 = load_report_templates(split_list_flag(*template_flag))
+	errcntrl(err0)
+
+	input_files := flag.Args()
+	if len(input_files) == 0 {
+		fmt.Println("Filename argument not provided, using default: yoda-metadata.json")
+		input_files = append(input_files, "yoda-metadata.json")
+	}
+
+	var batch []MetadataReport
+	for _, fname := range input_files {
+		batch = append(batch, process_metadata_file(fname, profile))
+	}
+	if len(batch) > 1 {
+		errcntrl(write_batch_summary(batch, filepath.Join("output", batch_summary_file_name)))
+	}
+}
+
+// read, validate and score the metadata of an input (file, stdin, directory, archive or iRODS
+// collection, see inputs.go) and write the PDF, Markdown and JSON reports
+func process_metadata_file(fname string, profile Profile) MetadataReport {
+	source, err1 := open_input_source(fname)
 	errcntrl(err1)
 
-	input_file_name_noext := strings.Join(strings.Split(input_file_name, "")[:len(input_file_name)-5], "")
-	output_file_name := filepath.Join(output_file_path, input_file_name_noext+".pdf")
-	output_file_name_md := filepath.Join(output_file_path, input_file_name_noext+".md")
+	// define input and output files
+	var output_file_path string = "output"
+	if file, ok := source.(file_source); ok {
+		_, _, output_file_path, err1 = get_input_file_path(file.File)
+		errcntrl(err1)
+	}
+	output_base := filepath.Join(output_file_path, source.OutputBase())
 
 	// winblowz
-
-	// fmt.Println("->", output_file_name)
-	output_file_path_full, _ := path.Split(strings.Replace(output_file_name, "\\", "/", -1))
-	// fmt.Println("->", fsplitd, fsplitf)
+	output_file_path_full, _ := path.Split(strings.Replace(output_base, "\\", "/", -1))
 	_ = os.MkdirAll(output_file_path_full, os.ModePerm)
 
-	// fmt.Println("-->", input_file_name)
-	// fmt.Println("-->", output_file_path)
-	// fmt.Println("-->", output_file_name)
-	// fmt.Println("-->", output_file_name_md)
+	// read metadata
+	json_dat, input_file_name, err2 := source.Read()
+	errcntrl(err2)
+	fmt.Println("Input metadata:", input_file_name)
 
 	if DEBUG {
 		fmt.Println(input_file_name)
-		fmt.Println(input_file_path)
-		fmt.Println(output_file_name)
+		fmt.Println(output_base)
 	}
 
-	// read metadata file
-	json_file, err1 := os.ReadFile(input_file_name)
-	errcntrl(err1)
+	return write_metadata_reports(json_dat, input_file_name, output_base, profile)
+}
 
-	// print the file cast as string
-	if DEBUG {
-		fmt.Print(string(json_file))
-	}
+// validate and score the metadata and write the PDF, Markdown, JSON and GeoJSON reports to <output_base>.*
+func write_metadata_reports(json_dat Yoda18Metadata, input_file_name string, output_base string, profile Profile) MetadataReport {
+	output_file_name := output_base + ".pdf"
+	output_file_name_md := output_base + ".md"
+	output_file_name_json := output_base + ".report.json"
+	output_file_name_geojson := output_base + ".geojson"
 
-	// create metadata struct and fill it with file data
-	var json_dat Yoda18Metadata
-	err2 := json.Unmarshal(json_file, &json_dat)
-	errcntrl(err2)
-
-	ERROR_COUNT = 0
+	REPORT_LANGUAGE = select_language(*lang_flag, json_dat)
+	report := create_metadata_report(json_dat, input_file_name, profile)
 	// lets do something more useful
 	if DEBUG {
 		fmt.Printf("\n\n----------------\n\n")
@@ -236,18 +340,68 @@ func main() {
 		fmt.Printf("\n\n-------***-------\n\n")
 	}
 	//// New way of doing things where we write the document directly
-	doc := pdf.NewMaroto(consts.Portrait, consts.A4)
-	//m.SetBorder(true)
-	doc.SetPageMargins(10, 10, 10)
-	doc = generate_pdf_report_basic(json_dat, doc, input_file_name)
-	err := doc.OutputFileAndClose(output_file_name)
-	errcntrl(err)
+	doc, err3 := create_pdf_report(json_dat, input_file_name, report)
+	errcntrl(err3)
+	errcntrl(write_pdf_file(doc, json_dat, output_file_name, *pdfa_flag))
 
 	// write the contents of the metadata to a md file
-	mdoc := "string contents of file\nthe end\n"
-	mdoc = create_md_readme(json_dat)
+	mdoc, err4 := MARKDOWN_TEMPLATE.render(json_dat, report)
+	errcntrl(err4)
 	_ = write_string_to_file(mdoc, output_file_name_md)
 
+	// write the custom reports
+	for _, t := range REPORT_TEMPLATES {
+		out, err5 := t.render(json_dat, report)
+		errcntrl(err5)
+		errcntrl(os.WriteFile(output_base+"."+t.Name, []byte(out), 0644))
+		fmt.Println("Template report written to:", output_base+"."+t.Name)
+	}
+
+	// write the findings and scores to a json file
+	errcntrl(write_json_report(report, output_file_name_json))
+
+	// write the bounding boxes as GeoJSON
+	errcntrl(write_geojson(json_dat, output_file_name_geojson))
+
+	return report
+}
+
+// render the PDF report, with a table of contents when -toc is given
+func create_pdf_report(json_dat Yoda18Metadata, input_file_name string, report MetadataReport) (pdf.Maroto, error) {
+	if *pdfa_flag && *font_flag == font_name_core {
+		return nil, fmt.Errorf("PDF/A needs embedded fonts, the core fonts cannot be used with -pdfa")
+	}
+	doc, err := render_pdf_report(json_dat, input_file_name, report, nil)
+	if err != nil {
+		return doc, err
+	}
+	check_font_coverage(doc, json_dat)
+	if *toc_flag {
+		// the contents page moves the report down, render again until the page numbers settle
+		toc := outline_shift(PDF_OUTLINE, 1)
+		for pass := 0; pass < toc_max_passes; pass++ {
+			doc, err = render_pdf_report(json_dat, input_file_name, report, toc)
+			if err != nil {
+				return doc, err
+			}
+			if outline_pages_equal(toc, PDF_OUTLINE) {
+				break
+			}
+			toc = PDF_OUTLINE
+		}
+	}
+	return doc, nil
+}
+
+// split a comma separated command line option into its non-empty values
+func split_list_flag(value string) []string {
+	var out []string
+	for _, v := range strings.Split(value, ",") {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, strings.TrimSpace(v))
+		}
+	}
+	return out
 }
 
 // handle and error
@@ -257,7 +411,45 @@ func errcntrl(e error) {
 	}
 }
 
-//string to file function
+// read a Yoda metadata file into the metadata struct
+func read_metadata_file(fname string) (Yoda18Metadata, error) {
+	var json_dat Yoda18Metadata
+
+	json_file, err := os.ReadFile(fname)
+	if err != nil {
+		return json_dat, err
+	}
+
+	// print the file cast as string
+	if DEBUG {
+		fmt.Print(string(json_file))
+	}
+
+	err = json.Unmarshal(json_file, &json_dat)
+	return json_dat, err
+}
+
+// write the metadata as a Yoda metadata file, empty fields are left out
+func write_metadata_file(data Yoda18Metadata, fname string) error {
+	out, err := encode_metadata(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fname, out, 0644)
+}
+
+// encode the metadata as indented Yoda metadata JSON
+func encode_metadata(data Yoda18Metadata) ([]byte, error) {
+	var buf bytes.Buffer
+
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "    ")
+	err := enc.Encode(Yoda18MetadataV2(data))
+	return buf.Bytes(), err
+}
+
+// string to file function
 func write_string_to_file(mdoc string, fname string) error {
 	f, err := os.Create(fname)
 
@@ -277,36 +469,6 @@ func write_string_to_file(mdoc string, fname string) error {
 	return err2
 }
 
-// create a md string that represents the Yoda metadata
-func create_md_readme(data Yoda18Metadata) string {
-	var out string = "# Hokey Kokey Reportey\n"
-
-	var basic string = fmt.Sprintln("\n## Identification")
-	basic += fmt.Sprintf("- Title: %s\n", data.Title)
-	basic += fmt.Sprintf("- CollectionDate: %s to %s\n", data.Collected.StartDate, data.Collected.EndDate)
-	basic += fmt.Sprintf("- ResourceType: %s\n", data.DataType)
-	basic += fmt.Sprintf("- Rights: %s\n", data.License)
-	basic += fmt.Sprintf("- Version: %s\n", data.Version)
-
-	var basic2 string = fmt.Sprintln("\n## Creator")
-	for cre := range data.Creator {
-		basic2 += fmt.Sprintf("- Creator: %s %s ", data.Creator[cre].Name.GivenName, data.Creator[cre].Name.FamilyName)
-		for pid := range data.Creator[cre].PersonIdentifier {
-			basic2 += fmt.Sprintf("(%s: %s) ", data.Creator[cre].PersonIdentifier[pid].NameIdentifierScheme,
-				data.Creator[cre].PersonIdentifier[pid].NameIdentifier)
-		}
-		basic2 += "\n"
-		for aff := range data.Creator[cre].Affiliation {
-			basic2 += fmt.Sprintf("- CreatorAffiliation: %s\n", data.Creator[cre].Affiliation[aff])
-		}
-	}
-	basic2 += fmt.Sprintln("\n## Description")
-	basic2 += fmt.Sprintf("%s\n", data.Description)
-
-	out = out + basic + basic2
-	return out
-}
-
 // Maroto PDF color defintions
 func pdfRed() color.Color {
 	return color.Color{
@@ -343,6 +505,24 @@ func pdfBlack() color.Color {
 	}
 }
 
+// Maroto PDF color defintions
+func pdfWhite() color.Color {
+	return color.Color{
+		Red:   maxRGB8Bytes,
+		Green: maxRGB8Bytes,
+		Blue:  maxRGB8Bytes,
+	}
+}
+
+// Maroto PDF color defintions
+func pdfLightGrey() color.Color {
+	return color.Color{
+		Red:   235,
+		Green: 235,
+		Blue:  235,
+	}
+}
+
 func pdfOrange() color.Color {
 	return color.Color{
 		Red:   255,
@@ -353,39 +533,27 @@ func pdfOrange() color.Color {
 
 // Maroto PDF color defintions
 func pdfWarningColour() color.Color {
-	// return pdfRed()
-	ERROR_COUNT = ERROR_COUNT + 1
-	return pdfBlue()
+	return severity_rgb(SeverityWarning)
 }
 
 func pdfErrorColour() color.Color {
-	// return pdfRed()
-	ERROR_COUNT = ERROR_COUNT + 1
-	return pdfRed()
+	return severity_rgb(SeverityError)
 }
 
 func pdfInfoColour() color.Color {
-	//ERROR_COUNT = ERROR_COUNT + 1
-	return pdfOrange()
+	return severity_rgb(SeverityInfo)
 }
 
-func get_input_file_path_from_clargs() (string, string, string, error) {
+func get_input_file_path(fname string) (string, string, string, error) {
 	var cDir string = ""
 	var err error = nil
-	var fname string
 	var outdir string = "output"
 
 	cDir, err = os.Getwd()
 	errcntrl(err)
 
-	if len(os.Args) > 1 {
-		fname = os.Args[1]
-	} else {
-		fmt.Println("Filename argument not provided, using default: yoda-metadata.json")
-		fname = "yoda-metadata.json"
-	}
-
-	input_file_path, err := filepath.Abs(filepath.Join(cDir, fname))
+	// Abs resolves a relative name against the current directory and keeps an absolute one
+	input_file_path, err := filepath.Abs(fname)
 	errcntrl(err)
 
 	//
@@ -420,92 +588,175 @@ func get_input_file_path_from_clargs() (string, string, string, error) {
 
 }
 
+// render the PDF report, with a table of contents when toc is given
+func render_pdf_report(data Yoda18Metadata, fname string, report MetadataReport, toc []OutlineEntry) (pdf.Maroto, error) {
+	PDF_OUTLINE = nil
+	pending_bookmarks = nil
+	table_header_repeat = nil
+	pagesize, _ := theme_page_size(REPORT_THEME.PageSize)
+	doc := pdf.NewMaroto(consts.Portrait, pagesize)
+	//m.SetBorder(true)
+	doc.SetPageMargins(REPORT_THEME.margins())
+	doc, err := setup_fonts(doc, *font_flag, split_list_flag(*font_fallback_flag))
+	if err != nil {
+		return doc, err
+	}
+	return generate_pdf_report_basic(data, doc, fname, report, toc), nil
+}
+
 // New style PDFreportwriter, writes basic metadata
-func generate_pdf_report_basic(data Yoda18Metadata, doc pdf.Maroto, fname string) pdf.Maroto {
+func generate_pdf_report_basic(data Yoda18Metadata, doc pdf.Maroto, fname string, report MetadataReport, toc []OutlineEntry) pdf.Maroto {
 	var ctime = time.Now().String()
 	var colwidth uint = 12
 	var rowheight float64 = 4
 	var textblock_divider float64 = 20
 	var empty_line_height float64 = 2
 
-	pdf_write_header(doc, fmt.Sprintf("\"%s\" metadata", fname), rowheight, colwidth)
-	pdf_write_footer(doc, fmt.Sprintf("\"%s\" metadata generated on %s\nby readYmeta v%s", fname, ctime, _MYVERSION_), rowheight, colwidth)
+	pdf_write_header(doc, REPORT_THEME.fill_template(REPORT_THEME.Header, tr("header", fname), fname, ctime),
+		REPORT_THEME.Logo, rowheight, colwidth)
+	pdf_write_footer(doc, REPORT_THEME.fill_template(REPORT_THEME.Footer, tr("footer", fname, ctime, _MYVERSION_), fname, ctime),
+		rowheight, colwidth)
+
+	if len(toc) > 0 {
+		pdf_write_toc(doc, toc, rowheight, colwidth)
+	}
+
+	pdf_bookmark("section.summary", 0)
+	pdf_write_score_box(doc, report.Score, rowheight, colwidth)
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	pdf_write_severity_legend(doc, len(report.Findings), rowheight, colwidth)
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	if *qr_flag {
+		landing, source := package_landing_page(data, *landing_page_flag)
+		pdf_write_landing_qr(doc, landing, source, rowheight, colwidth)
+		pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	}
 
-	pdf_write_labelled_row(doc, "Title", data.Title, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_bookmark("label.title", 0)
+	pdf_write_labelled_row(doc, tr("label.title"), data.Title, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
-	pdf_write_row(doc, "Description", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.description", 0)
+	pdf_write_row(doc, tr("label.description"), rowheight, colwidth, consts.Bold, pdfBlack())
 	if float64(len(data.Description))/textblock_divider > rowheight {
-		pdf_write_row(doc, data.Description, float64(len(data.Description))/textblock_divider, colwidth, consts.Normal, pdfBlack())
+		pdf_write_text_block(doc, data.Description, rowheight, colwidth, consts.Normal, pdfBlack())
 	} else {
-		pdf_write_row(doc, data.Description, rowheight, colwidth, consts.Normal, pdfInfoColour())
+		pdf_write_text_block(doc, data.Description, rowheight, colwidth, consts.Normal, pdfInfoColour())
 	}
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Tags", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.tags", 0)
+	pdf_write_row(doc, tr("label.tags"), rowheight, colwidth, consts.Bold, pdfBlack())
 	pdf_write_list(doc, data.Tag, rowheight, colwidth, consts.Normal, pdfBlack())
 	// pdf_write_list_sub1(doc, data.Tag, rowheight, colwidth, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_creators(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	if use_table_layout(len(data.Creator)) {
+		pdf_write_creators_table(doc, data, report.Findings, rowheight, colwidth)
+	} else {
+		pdf_write_creators(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	}
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_contributors(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_rule_findings(doc, report.Findings, "contributors-vs-creators", rowheight, colwidth)
+	if use_table_layout(len(data.Contributor)) {
+		pdf_write_contributors_table(doc, data, report.Findings, rowheight, colwidth)
+	} else {
+		pdf_write_contributors(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	}
+	pdf_write_empty_row(doc, empty_line_height, colwidth)
+
+	pdf_write_people(doc, data, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Disciplines", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.disciplines", 0)
+	pdf_write_row(doc, tr("label.disciplines"), rowheight, colwidth, consts.Bold, pdfBlack())
 	pdf_write_list(doc, data.Discipline, rowheight, colwidth, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Collected", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_row_tuple_indent(doc, "StartDate", data.Collected.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
-	pdf_write_row_tuple_indent(doc, "EndDate", data.Collected.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_bookmark("label.collected", 0)
+	pdf_write_row(doc, tr("label.collected"), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_row_tuple_indent(doc, tr("label.start_date"), data.Collected.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_row_tuple_indent(doc, tr("label.end_date"), data.Collected.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_empty_row(doc, empty_line_height, colwidth)
+
+	pdf_bookmark("label.covered_period", 0)
+	pdf_write_row(doc, tr("label.covered_period"), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_row_tuple_indent(doc, tr("label.start_date"), data.CoveredPeriod.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_row_tuple_indent(doc, tr("label.end_date"), data.CoveredPeriod.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Covered Period", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_row_tuple_indent(doc, "StartDate", data.CoveredPeriod.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
-	pdf_write_row_tuple_indent(doc, "EndDate", data.CoveredPeriod.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_timeline(doc, data, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_funding(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_geolocation(doc, data, rowheight, colwidth, empty_line_height)
+	pdf_write_empty_row(doc, empty_line_height, colwidth)
+
+	if use_table_layout(len(data.FundingReference)) {
+		pdf_write_funding_table(doc, data, report.Findings, rowheight, colwidth)
+	} else {
+		pdf_write_funding(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	}
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
 	pdf_write_related(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_labelled_row_error(doc, "Dataset Version", data.Version, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Licence", data.License, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Data Type", data.DataType, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	//pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_bookmark("section.licence_access", 0)
+	pdf_write_labelled_row_error(doc, tr("label.version"), data.Version, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	license_link, _ := license_url(data.License)
+	pdf_write_labelled_link_row(doc, tr("label.licence"), data.License, license_link, rowheight, colwidth, empty_line_height, pdfBlack())
+	pdf_write_labelled_row(doc, tr("label.data_type"), data.DataType, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	//pdf_write_labelled_row(doc, tr("label.data_classification"), data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
 	if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification == "Public" {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfGreen())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfGreen())
+		pdf_write_labelled_row(doc, tr("label.data_classification"), data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, theme_colour(REPORT_THEME.Colours.Highlight, pdfGreen()))
+		pdf_write_labelled_row(doc, tr("label.data_access_restriction"), data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, theme_colour(REPORT_THEME.Colours.Highlight, pdfGreen()))
 	} else if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification != "Public" {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
+		pdf_write_labelled_row(doc, tr("label.data_classification"), data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
+		pdf_write_labelled_row(doc, tr("label.data_access_restriction"), data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
 	} else {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
+		pdf_write_labelled_row(doc, tr("label.data_classification"), data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
+		pdf_write_labelled_row(doc, tr("label.data_access_restriction"), data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
 	}
 
-	pdf_write_labelled_row(doc, "Language", data.Language, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Retention Period", fmt.Sprint(data.RetentionPeriod)+" years", rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Retention Information", data.RetentionInformation, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Embargo EndDate", data.EmbargoEndDate, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Remarks", data.Remarks, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	if ERROR_COUNT > 0 {
+	pdf_bookmark("section.retention", 0)
+	pdf_write_labelled_row(doc, tr("label.language"), data.Language, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_write_labelled_row(doc, tr("label.retention_period"), tr("value.retention_years", data.RetentionPeriod), rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_write_labelled_text_block(doc, tr("label.retention_information"), data.RetentionInformation, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_write_labelled_row(doc, tr("label.embargo_end_date"), data.EmbargoEndDate, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	pdf_write_labelled_text_block(doc, tr("label.remarks"), data.Remarks, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+
+	if warnings := report.Counts[SeverityError] + report.Counts[SeverityWarning]; warnings > 0 {
 		pdf_write_empty_row(doc, 20, colwidth)
 		doc.Line(10)
 
-		pdf_write_labelled_row(doc, "readYmeta diagnostics", fmt.Sprintf(" - %d warnings were generated, please check for missing (optional) information.",
-			ERROR_COUNT), rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+		pdf_write_labelled_row(doc, tr("label.diagnostics"), tr("value.diagnostics", warnings), rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
 	}
+	pdf_write_findings(doc, report.Findings, report.Profile, rowheight, colwidth, empty_line_height)
 
 	return doc
 }
 
 // New style PDFreportwriter header writer
-func pdf_write_header(m pdf.Maroto, line string, rowheight float64, colwidth uint) {
+func pdf_write_header(m pdf.Maroto, line string, logo string, rowheight float64, colwidth uint) {
 	m.RegisterHeader(func() {
+		if logo != "" {
+			m.Row(theme_logo_height, func() {
+				m.Col(colwidth-theme_logo_colwidth, func() {
+					m.Text(line, props.Text{
+						Top:  3,
+						Size: 12,
+					})
+				})
+				m.Col(theme_logo_colwidth, func() {
+					_ = m.FileImage(logo, props.Rect{Center: true, Percent: 100})
+				})
+			})
+			m.Line(10)
+			if table_header_repeat != nil {
+				table_header_repeat()
+			}
+			return
+		}
 		m.Row(rowheight, func() {
 			m.Col(colwidth, func() {
 				m.Text(line, props.Text{
@@ -517,6 +768,9 @@ func pdf_write_header(m pdf.Maroto, line string, rowheight float64, colwidth uin
 			m.ColSpace(4)
 		})
 		m.Line(10)
+		if table_header_repeat != nil {
+			table_header_repeat()
+		}
 	})
 }
 
@@ -537,7 +791,7 @@ func pdf_write_footer(m pdf.Maroto, line string, rowheight float64, colwidth uin
 
 // Write row that generates a warning
 func pdf_write_row(m pdf.Maroto, line string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	if line == "" || line == " " {
+	if is_blank(line) {
 		textcolour = pdfWarningColour()
 		line = nullstring
 	}
@@ -546,7 +800,7 @@ func pdf_write_row(m pdf.Maroto, line string, rowheight float64, colwidth uint,
 
 // Write row that generates an error rather than a warning
 func pdf_write_row_error(m pdf.Maroto, line string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	if line == "" || line == " " {
+	if is_blank(line) {
 		textcolour = pdfErrorColour()
 		line = nullstring
 	}
@@ -566,6 +820,8 @@ func pdf_write_row_base(m pdf.Maroto, line string, rowheight float64, colwidth u
 			})
 		})
 	})
+	pdf_place_bookmarks(m, rowheight)
+	pdf_mark_row(m, textcolour, rowheight)
 }
 
 // New style PDFreportwriter row writer
@@ -575,6 +831,13 @@ func pdf_write_labelled_row(m pdf.Maroto, label string, line string, rowheight f
 	pdf_write_empty_row(m, emptyrowheight, colwidth)
 }
 
+// New style PDFreportwriter labelled free text writer, the text flows across pages
+func pdf_write_labelled_text_block(m pdf.Maroto, label string, text string, rowheight float64, colwidth uint, emptyrowheight float64, fontstyle consts.Style, textcolour color.Color) {
+	pdf_write_row(m, label, rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_text_block(m, text, rowheight, colwidth, consts.Normal, textcolour)
+	pdf_write_empty_row(m, emptyrowheight, colwidth)
+}
+
 // New style PDFreportwriter row writer
 func pdf_write_labelled_row_error(m pdf.Maroto, label string, line string, rowheight float64, colwidth uint, emptyrowheight float64, fontstyle consts.Style, textcolour color.Color) {
 	pdf_write_row_error(m, label, rowheight, colwidth, consts.Bold, textcolour)
@@ -584,12 +847,12 @@ func pdf_write_labelled_row_error(m pdf.Maroto, label string, line string, rowhe
 
 // New style PDFreportwriter row writer
 func pdf_write_empty_row(m pdf.Maroto, rowheight float64, colwidth uint) {
-	pdf_write_row(m, "  ", rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_row_base(m, "  ", rowheight, colwidth, consts.Normal, pdfBlack())
 }
 
 // New style PDFreportwriter row writer
 func pdf_write_row_indent(m pdf.Maroto, line string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color, indent uint) {
-	if line == "" || line == " " {
+	if is_blank(line) {
 		textcolour = pdfWarningColour()
 		line = nullstring
 	}
@@ -614,11 +877,12 @@ func pdf_write_row_indent(m pdf.Maroto, line string, rowheight float64, colwidth
 			})
 		})
 	})
+	pdf_mark_row(m, textcolour, rowheight)
 }
 
 // New style PDFreportwriter row writer
 func pdf_write_row_tuple_indent(m pdf.Maroto, line1 string, line2 string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color, indent uint) {
-	if line2 == "" || line2 == " " {
+	if is_blank(line2) {
 		textcolour = pdfWarningColour()
 		line2 = nullstring
 	}
@@ -656,6 +920,7 @@ func pdf_write_row_tuple_indent(m pdf.Maroto, line1 string, line2 string, rowhei
 			})
 		})
 	})
+	pdf_mark_row(m, textcolour, rowheight)
 }
 
 // New style PDFreportwriter list writer
@@ -669,7 +934,7 @@ func pdf_write_list(m pdf.Maroto, lines []string, rowheight float64, colwidth ui
 	for line := range lines {
 		var text string = lines[line]
 
-		if text == "" || text == " " || text == nullstring {
+		if is_blank(text) || text == nullstring {
 			textcolour = pdfWarningColour()
 		} else {
 			textcolour = pdfBlack()
@@ -695,6 +960,7 @@ func pdf_write_list(m pdf.Maroto, lines []string, rowheight float64, colwidth ui
 				})
 			})
 		})
+		pdf_mark_row(m, textcolour, rowheight)
 	}
 }
 
@@ -736,17 +1002,18 @@ func pdf_write_list_sub1(m pdf.Maroto, lines []string, rowheight float64, colwid
 func pdf_write_creators(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
 	var ind1 uint = 1
 	// var ind2 uint = 2
-	pdf_write_row(m, "Creators", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.creators", 0)
+	pdf_write_row(m, tr("label.creators"), rowheight, colwidth, consts.Bold, pdfBlack())
 	for i := range data.Creator {
 		GivenName := data.Creator[i].Name.GivenName
 		FamilyName := data.Creator[i].Name.FamilyName
 		textcolour2 := textcolour
-		if GivenName == "" {
-			GivenName = "GivenName"
+		if is_blank(GivenName) {
+			GivenName = tr("missing.given_name")
 			textcolour2 = pdfWarningColour()
 		}
-		if FamilyName == "" {
-			FamilyName = "FamilyName"
+		if is_blank(FamilyName) {
+			FamilyName = tr("missing.family_name")
 			textcolour2 = pdfWarningColour()
 		}
 
@@ -754,8 +1021,8 @@ func pdf_write_creators(m pdf.Maroto, data Yoda18Metadata, rowheight float64, co
 		for j := range data.Creator[i].Affiliation {
 			textcolour2 = textcolour
 			text := data.Creator[i].Affiliation[j]
-			if text == "" {
-				text = "Affiliation"
+			if is_blank(text) {
+				text = tr("missing.affiliation")
 				textcolour2 = pdfWarningColour()
 			}
 			pdf_write_row_indent(m, text, rowheight, colwidth, consts.Normal, textcolour2, ind1)
@@ -764,14 +1031,18 @@ func pdf_write_creators(m pdf.Maroto, data Yoda18Metadata, rowheight float64, co
 			text := data.Creator[i].PersonIdentifier[k].NameIdentifierScheme
 			text2 := data.Creator[i].PersonIdentifier[k].NameIdentifier
 			textcolour2 = textcolour
-			if text == "" {
-				text = "IdentifierScheme"
+			if is_blank(text) {
+				text = tr("missing.identifier_scheme")
 				textcolour2 = pdfErrorColour()
 			}
-			if text2 == "" {
-				text2 = "Identifier"
+			if is_blank(text2) {
+				text2 = tr("missing.identifier")
 				textcolour2 = pdfErrorColour()
 			}
+			if !is_blank(data.Creator[i].PersonIdentifier[k].NameIdentifierScheme) && !is_blank(data.Creator[i].PersonIdentifier[k].NameIdentifier) {
+				pdf_write_identifier_row(m, text, text2, rowheight, colwidth, ind1)
+				continue
+			}
 
 			pdf_write_row_indent(m, fmt.Sprintf("(%s) %s", text, text2),
 				rowheight, colwidth, consts.Normal, textcolour2, ind1)
@@ -779,31 +1050,28 @@ func pdf_write_creators(m pdf.Maroto, data Yoda18Metadata, rowheight float64, co
 	}
 }
 
-// write the list of contributors to the PDF if len(contributors) > len(creators) trigger a creator vs. contribtor warning
+// write the list of contributors to the PDF
 func pdf_write_contributors(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
 	var ind1 uint = 1
-	if len(data.Contributor) >= len(data.Creator) {
-		pdf_write_row(m, "\"INFO: there are more contributors than creators listed, please note that dataset authors should always be listed as creators to get credit for the dataset.\"", rowheight, colwidth, consts.Normal, pdfInfoColour())
-		pdf_write_empty_row(m, rowheight*2, colwidth)
-	}
 	// var ind2 uint = 2
-	pdf_write_row(m, "Contributors", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.contributors", 0)
+	pdf_write_row(m, tr("label.contributors"), rowheight, colwidth, consts.Bold, pdfBlack())
 	for i := range data.Contributor {
 		GivenName := data.Contributor[i].Name.GivenName
 		FamilyName := data.Contributor[i].Name.FamilyName
 		ContributorType := data.Contributor[i].ContributorType
 		textcolour2 := textcolour
 		textcolour3 := textcolour
-		if GivenName == "" {
-			GivenName = "GivenName"
+		if is_blank(GivenName) {
+			GivenName = tr("missing.given_name")
 			textcolour2 = pdfWarningColour()
 		}
-		if FamilyName == "" {
-			FamilyName = "FamilyName"
+		if is_blank(FamilyName) {
+			FamilyName = tr("missing.family_name")
 			textcolour2 = pdfWarningColour()
 		}
-		if ContributorType == "" {
-			ContributorType = "ContributorType"
+		if is_blank(ContributorType) {
+			ContributorType = tr("missing.contributor_type")
 			textcolour3 = pdfWarningColour()
 		}
 		pdf_write_row(m, fmt.Sprintf("%s %s", GivenName, FamilyName), rowheight, colwidth, consts.Normal, textcolour2)
@@ -811,8 +1079,8 @@ func pdf_write_contributors(m pdf.Maroto, data Yoda18Metadata, rowheight float64
 		for j := range data.Contributor[i].Affiliation {
 			textcolour2 := textcolour
 			affil := data.Contributor[i].Affiliation[j]
-			if affil == "" {
-				affil = "Affiliation"
+			if is_blank(affil) {
+				affil = tr("missing.affiliation")
 				textcolour2 = pdfWarningColour()
 			}
 
@@ -822,62 +1090,133 @@ func pdf_write_contributors(m pdf.Maroto, data Yoda18Metadata, rowheight float64
 			text := data.Contributor[i].PersonIdentifier[k].NameIdentifierScheme
 			text2 := data.Contributor[i].PersonIdentifier[k].NameIdentifier
 			textcolour2 := textcolour
-			if text == "" {
-				text = "IdentifierScheme"
+			if is_blank(text) {
+				text = tr("missing.identifier_scheme")
 				textcolour2 = pdfWarningColour()
 			}
-			if text2 == "" {
-				text2 = "Identifier"
+			if is_blank(text2) {
+				text2 = tr("missing.identifier")
 				textcolour2 = pdfWarningColour()
 			}
+			if !is_blank(data.Contributor[i].PersonIdentifier[k].NameIdentifierScheme) && !is_blank(data.Contributor[i].PersonIdentifier[k].NameIdentifier) {
+				pdf_write_identifier_row(m, text, text2, rowheight, colwidth, ind1)
+				continue
+			}
 			pdf_write_row_indent(m, fmt.Sprintf("(%s) %s", text, text2),
 				rowheight, colwidth, consts.Normal, textcolour2, ind1)
 		}
 	}
 }
 
+// merged table of all people, entries that are likely the same person are combined
+func pdf_write_people(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint) {
+	var rows [][]table_cell
+	for _, c := range reconcile_people(collect_people(data)) {
+		name, roles, affiliations, identifiers := merge_person_cluster(c)
+		if name == "" {
+			name = nullstring
+		}
+		rows = append(rows, []table_cell{text_cell(name), text_cell(roles...), text_cell(affiliations...), text_cell(identifiers...)})
+	}
+	if len(rows) == 0 {
+		return
+	}
+	pdf_bookmark("label.people", 0)
+	pdf_write_row(m, tr("label.people"), rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_table(m, []string{tr("table.name"), tr("table.roles"), tr("table.affiliations"), tr("table.identifiers")},
+		[]uint{3, 3, 3, 3}, rows, rowheight)
+}
+
 // new function for writing funders
 func pdf_write_funding(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	pdf_write_row(m, "Funding references", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.funding", 0)
+	pdf_write_row(m, tr("label.funding_references"), rowheight, colwidth, consts.Bold, pdfBlack())
 	for i := range data.FundingReference {
 		pdf_write_row_tuple_indent(m, data.FundingReference[i].FunderName, data.FundingReference[i].AwardNumber, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
 	}
 }
 
-//new functions for writing related data packages
+// new functions for writing related data packages
 func pdf_write_related(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	pdf_write_row(m, "Related datapackages", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_bookmark("label.related", 0)
+	pdf_write_row(m, tr("label.related"), rowheight, colwidth, consts.Bold, pdfBlack())
 	for i := range data.RelatedDatapackage {
 		textcolour2 := textcolour
 		reltype := data.RelatedDatapackage[i].RelationType
-		if reltype == "" {
-			reltype = "RelationType"
+		if is_blank(reltype) {
+			reltype = tr("missing.relation_type")
 			textcolour2 = pdfWarningColour()
 		}
-		pdf_write_row(m, reltype, rowheight, colwidth, consts.Normal, textcolour2)
 		textcolour3 := textcolour
 		text := data.RelatedDatapackage[i].PersistentIdentifier.IdentifierScheme
 		text2 := data.RelatedDatapackage[i].PersistentIdentifier.Identifier
-		if text == "" {
-			text = "IdentifierSchema"
+		if is_blank(text) {
+			text = tr("missing.identifier_scheme")
 			textcolour3 = pdfWarningColour()
 		}
-		if text2 == "" {
-			text2 = "Identifier"
+		if is_blank(text2) {
+			text2 = tr("missing.identifier")
 			textcolour3 = pdfWarningColour()
 		}
-		pdf_write_row_indent(m, "("+text+") "+text2, rowheight, colwidth, consts.Normal, textcolour3, 1)
-
 		textcolour4 := textcolour
 		title := data.RelatedDatapackage[i].Title
-		if title == "" {
-			title = "Title"
+		if is_blank(title) {
+			title = tr("missing.title")
 			textcolour4 = pdfWarningColour()
 		}
+
+		if textcolour3 == textcolour && *qr_flag {
+			if link, ok := identifier_url(text, text2); ok {
+				pdf_write_related_qr(m, reltype, text, text2, title, link, rowheight, colwidth, textcolour2, textcolour4)
+				continue
+			}
+		}
+		pdf_write_row(m, reltype, rowheight, colwidth, consts.Normal, textcolour2)
+		if textcolour3 == textcolour {
+			pdf_write_identifier_row(m, text, text2, rowheight, colwidth, 1)
+		} else {
+			pdf_write_row_indent(m, "("+text+") "+text2, rowheight, colwidth, consts.Normal, textcolour3, 1)
+		}
 		pdf_write_row_indent(m, title, rowheight, colwidth, consts.Normal, textcolour4, 1)
 	}
 }
 
+// summary box with the completeness and FAIR scores, written on the first page
+func pdf_write_score_box(m pdf.Maroto, score ScoreReport, rowheight float64, colwidth uint) {
+	m.SetBackgroundColor(theme_colour(REPORT_THEME.Colours.Box, pdfLightGrey()))
+	pdf_write_row(m, tr("score.completeness", score.Completeness), rowheight+1, colwidth, consts.Bold, pdfBlack())
+	pdf_write_row_tuple_indent(m, tr("score.mandatory"), tr("score.filled", score.Mandatory.Filled, score.Mandatory.Total),
+		rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_row_tuple_indent(m, tr("score.recommended"), tr("score.filled", score.Recommended.Filled, score.Recommended.Total),
+		rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_row_tuple_indent(m, tr("score.optional"), tr("score.filled", score.Optional.Filled, score.Optional.Total),
+		rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	for _, p := range fair_principles {
+		var failed []string
+		for _, ind := range score.Indicators {
+			if ind.Principle == p && !ind.Passed && !ind.NotApplicable {
+				failed = append(failed, tr("indicator."+ind.Name))
+			}
+		}
+		text := fmt.Sprintf("%.0f%%", score.Fair[p])
+		if len(failed) > 0 {
+			text += tr("score.missing") + strings.Join(failed, ", ")
+		}
+		pdf_write_row_tuple_indent(m, tr("fair."+p), text, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	}
+	m.SetBackgroundColor(pdfWhite())
+}
+
+// write the findings of a single rule inline, e.g. the creator vs. contributor info above the contributors
+func pdf_write_rule_findings(m pdf.Maroto, findings []Finding, rule_id string, rowheight float64, colwidth uint) {
+	for _, f := range findings {
+		if f.Rule == rule_id {
+			pdf_write_row(m, fmt.Sprintf("\"%s: %s\"", strings.ToUpper(tr("severity."+string(f.Severity))), f.Message), rowheight, colwidth, consts.Normal, pdfInfoColour())
+			pdf_write_empty_row(m, rowheight*2, colwidth)
+		}
+	}
+}
+
 // //test main function
 // func TestMain(m *testing.M) {
 // 	// call flag.Parse() here if TestMain uses flags
@@ -1016,6 +1355,12 @@ func get_basic_document_data(doc Yoda18Metadata) []string {
 	for i := range doc.CoveredGeolocationPlace {
 		output = append(output, fmt.Sprintf("- %s", doc.CoveredGeolocationPlace[i]))
 	}
+	output = append(output, fmt.Sprintf("GeoLocation"))
+	for i := range doc.GeoLocation {
+		if box, ok := geo_box(doc, i); ok {
+			output = append(output, fmt.Sprintf("- %s: %s", doc.GeoLocation[i].DescriptionSpatial, box))
+		}
+	}
 	output = append(output, fmt.Sprintf("Version: %s", doc.Version))
 	output = append(output, fmt.Sprintf("Licence: %s", doc.License))
 	output = append(output, fmt.Sprintf("Language: %s", doc.Language))
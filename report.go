@@ -0,0 +1,103 @@
+/*
+report.go machine readable readYmeta reports, a JSON report per metadata file with the validation
+findings and scores and a batch summary (CSV) that is appended to on every batch run so that the
+scores of a set of data packages can be tracked over time.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const batch_summary_file_name string = "batch-summary.csv"
+
+// validation findings and scores of a single metadata file
+type MetadataReport struct {
+	File      string           `json:"file"`
+	Title     string           `json:"title"`
+	Version   string           `json:"version"`
+	Generated string           `json:"generated"`
+	Generator string           `json:"generator"`
+	Profile   string           `json:"profile"`
+	Language  string           `json:"language"`
+	Counts    map[Severity]int `json:"counts"`
+	Findings  []Finding        `json:"findings"`
+	Score     ScoreReport      `json:"score"`
+}
+
+// validate and score the metadata
+func create_metadata_report(data Yoda18Metadata, fname string, profile Profile) MetadataReport {
+	findings := validate_metadata(data, profile)
+	return MetadataReport{
+		File:      fname,
+		Title:     data.Title,
+		Version:   data.Version,
+		Generated: time.Now().Format(time.RFC3339),
+		Generator: "readYmeta v" + _MYVERSION_,
+		Profile:   profile.Name,
+		Language:  REPORT_LANGUAGE,
+		Counts:    count_findings(findings),
+		Findings:  findings,
+		Score:     score_metadata(data, profile),
+	}
+}
+
+// write the report as indented JSON
+func write_json_report(report MetadataReport, fname string) error {
+	out, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fname, out, 0644)
+}
+
+// append one row per report to the batch summary CSV, the header is written when the file is new
+func write_batch_summary(batch []MetadataReport, fname string) error {
+	_, err := os.Stat(fname)
+	new_file := os.IsNotExist(err)
+
+	f, err := os.OpenFile(fname, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	if new_file {
+		header := []string{"generated", "file", "title", "version", "profile", "completeness"}
+		header = append(header, fair_principles...)
+		header = append(header, "errors", "warnings", "info")
+		if err = w.Write(header); err != nil {
+			return err
+		}
+	}
+	for _, r := range batch {
+		row := []string{r.Generated, r.File, r.Title, r.Version, r.Profile, fmt.Sprintf("%.1f", r.Score.Completeness)}
+		for _, p := range fair_principles {
+			row = append(row, fmt.Sprintf("%.1f", r.Score.Fair[p]))
+		}
+		row = append(row, fmt.Sprint(r.Counts[SeverityError]), fmt.Sprint(r.Counts[SeverityWarning]), fmt.Sprint(r.Counts[SeverityInfo]))
+		if err = w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	if err = w.Error(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Batch summary of %d files written to: %s\n", len(batch), fname)
+	for _, r := range batch {
+		fmt.Printf(" %5.1f%%  %s  %s\n", r.Score.Completeness, fair_summary(r.Score), r.File)
+	}
+	return nil
+}
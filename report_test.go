@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteBatchSummary(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), batch_summary_file_name)
+	batch := []MetadataReport{
+		{File: "a.json", Title: "A", Counts: map[Severity]int{SeverityError: 2}},
+		{File: "b.json", Title: "B", Counts: map[Severity]int{SeverityInfo: 1}},
+	}
+	// the header is only written once, later runs append
+	for i := 0; i < 2; i++ {
+		if err := write_batch_summary(batch, fname); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("got %d rows, want a header and 4 rows", len(rows))
+	}
+	if rows[0][0] != "generated" || rows[1][1] != "a.json" || rows[4][1] != "b.json" {
+		t.Errorf("unexpected rows: %v", rows)
+	}
+	errors := rows[1][len(rows[1])-3]
+	if errors != "2" {
+		t.Errorf("error count %q, want 2", errors)
+	}
+}
+
+func TestWriteBatchSummaryError(t *testing.T) {
+	// a directory cannot be written to, the error is returned instead of a panic
+	if err := write_batch_summary(nil, t.TempDir()); err == nil {
+		t.Error("expected an error")
+	}
+}
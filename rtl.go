@@ -0,0 +1,324 @@
+/*
+rtl.go right-to-left text for the PDF report. The PDF library writes the characters of a text
+from left to right as they are, so Arabic letters are replaced by their contextual presentation
+forms (isolated, initial, medial, final and the lam-alef ligatures) and every line is put in visual
+order with a simplified version of the Unicode bidirectional algorithm: right-to-left runs are
+reversed, numbers and left-to-right words inside them keep their order and brackets are mirrored.
+Explicit bidi controls and scripts that need other shaping (e.g. Indic) are not supported.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import "unicode"
+
+// presentation form of an Arabic letter, the forms follow each other in the order isolated,
+// final, initial, medial; right joining letters only have the first two
+type arabic_letter struct {
+	isolated rune
+	dual     bool
+}
+
+// Arabic letters and their isolated form in the Arabic Presentation Forms-B block
+var arabic_letters = map[rune]arabic_letter{
+	'ء': {0xFE80, false}, // hamza, does not join at all
+	'آ': {0xFE81, false},
+	'أ': {0xFE83, false},
+	'ؤ': {0xFE85, false},
+	'إ': {0xFE87, false},
+	'ئ': {0xFE89, true},
+	'ا': {0xFE8D, false},
+	'ب': {0xFE8F, true},
+	'ة': {0xFE93, false},
+	'ت': {0xFE95, true},
+	'ث': {0xFE99, true},
+	'ج': {0xFE9D, true},
+	'ح': {0xFEA1, true},
+	'خ': {0xFEA5, true},
+	'د': {0xFEA9, false},
+	'ذ': {0xFEAB, false},
+	'ر': {0xFEAD, false},
+	'ز': {0xFEAF, false},
+	'س': {0xFEB1, true},
+	'ش': {0xFEB5, true},
+	'ص': {0xFEB9, true},
+	'ض': {0xFEBD, true},
+	'ط': {0xFEC1, true},
+	'ظ': {0xFEC5, true},
+	'ع': {0xFEC9, true},
+	'غ': {0xFECD, true},
+	'ف': {0xFED1, true},
+	'ق': {0xFED5, true},
+	'ك': {0xFED9, true},
+	'ل': {0xFEDD, true},
+	'م': {0xFEE1, true},
+	'ن': {0xFEE5, true},
+	'ه': {0xFEE9, true},
+	'و': {0xFEED, false},
+	'ى': {0xFEEF, false}, // alef maksura, Forms-B has no initial and medial form
+	'ي': {0xFEF1, true},
+}
+
+// isolated form of the lam-alef ligature per alef, the final form follows it
+var lam_alef_ligatures = map[rune]rune{
+	'آ': 0xFEF5,
+	'أ': 0xFEF7,
+	'إ': 0xFEF9,
+	'ا': 0xFEFB,
+}
+
+const arabic_lam rune = 'ل'
+const arabic_tatweel rune = 'ـ'
+
+// marks that do not break the joining of the letters around them (harakat)
+func arabic_transparent(r rune) bool {
+	return unicode.Is(unicode.Mn, r)
+}
+
+// can the letter join the letter after it (in logical order)
+func arabic_joins_next(r rune) bool {
+	l, ok := arabic_letters[r]
+	return (ok && l.dual) || r == arabic_tatweel
+}
+
+// can the letter join the letter before it
+func arabic_joins_previous(r rune) bool {
+	return (arabic_letters[r].isolated != 0 && r != 'ء') || r == arabic_tatweel
+}
+
+// the next letter from i in direction step, skipping the marks on a letter
+func arabic_neighbour(text []rune, i int, step int) rune {
+	for j := i + step; j >= 0 && j < len(text); j += step {
+		if !arabic_transparent(text[j]) {
+			return text[j]
+		}
+	}
+	return 0
+}
+
+// replace the Arabic letters of a text by the presentation forms for their position in a word,
+// the text stays in logical order
+func shape_arabic(text string) string {
+	in := []rune(text)
+	out := make([]rune, 0, len(in))
+	for i := 0; i < len(in); i++ {
+		r := in[i]
+		letter, ok := arabic_letters[r]
+		if !ok {
+			out = append(out, r)
+			continue
+		}
+		previous := arabic_joins_next(arabic_neighbour(in, i, -1))
+		next := arabic_neighbour(in, i, 1)
+		if r == arabic_lam && i+1 < len(in) && lam_alef_ligatures[in[i+1]] != 0 {
+			form := lam_alef_ligatures[in[i+1]]
+			if previous {
+				form++
+			}
+			out = append(out, form)
+			i++
+			continue
+		}
+		form := letter.isolated
+		following := letter.dual && arabic_joins_previous(next)
+		switch {
+		case previous && following:
+			form += 3
+		case following:
+			form += 2
+		case previous && r != 'ء':
+			form++
+		}
+		out = append(out, form)
+	}
+	return string(out)
+}
+
+// bidi class of a character, simplified to what the report needs
+type bidi_class int
+
+const (
+	bidi_neutral bidi_class = iota
+	bidi_ltr
+	bidi_rtl
+	bidi_number
+)
+
+func rune_bidi_class(r rune) bidi_class {
+	switch {
+	case unicode.In(r, unicode.Arabic, unicode.Hebrew, unicode.Syriac, unicode.Thaana, unicode.Nko) && !unicode.IsDigit(r) && !arabic_transparent(r):
+		return bidi_rtl
+	case unicode.IsDigit(r):
+		return bidi_number
+	case unicode.IsLetter(r):
+		return bidi_ltr
+	}
+	return bidi_neutral
+}
+
+// does text contain right-to-left characters
+func has_rtl(text string) bool {
+	for _, r := range text {
+		if rune_bidi_class(r) == bidi_rtl {
+			return true
+		}
+	}
+	return false
+}
+
+// brackets and their mirror image, used in right-to-left runs
+var bidi_mirror = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<', '«': '»', '»': '«',
+}
+
+// a line of text in the order its characters are written from left to right, the direction of
+// the line is that of its first letter
+func visual_order(line string) string {
+	if !has_rtl(line) {
+		return line
+	}
+	text := []rune(line)
+	base := rune_bidi_class(text[first_strong(text)])
+	if base != bidi_rtl {
+		base = bidi_ltr
+	}
+	// marks take the class of their letter
+	classes := make([]bidi_class, len(text))
+	for i, r := range text {
+		classes[i] = rune_bidi_class(r)
+		if arabic_transparent(r) && i > 0 {
+			classes[i] = classes[i-1]
+		}
+	}
+
+	// levels: the base is 0 (left to right) or 1, right-to-left text is odd, numbers and
+	// left-to-right text in a right-to-left context are written left to right one level up
+	levels := make([]int, len(text))
+	last_strong := base
+	for i, c := range classes {
+		switch c {
+		case bidi_ltr, bidi_rtl:
+			last_strong = c
+		}
+		levels[i] = bidi_level(c, last_strong, base)
+	}
+	// neutrals between two characters of the same direction get that direction, others the base
+	for i := 0; i < len(text); {
+		if classes[i] != bidi_neutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(text) && classes[j] == bidi_neutral {
+			j++
+		}
+		before, after := base, base
+		if i > 0 {
+			before = strong_direction(classes[i-1], levels[i-1])
+		}
+		if j < len(text) {
+			after = strong_direction(classes[j], levels[j])
+		}
+		direction := base
+		if before == after {
+			direction = before
+		}
+		for k := i; k < j; k++ {
+			levels[k] = bidi_level(direction, direction, base)
+		}
+		i = j
+	}
+
+	// reverse every run at a level and above, from the highest level down to the lowest odd level
+	highest, lowest_odd := 0, 99
+	for _, l := range levels {
+		if l > highest {
+			highest = l
+		}
+		if l%2 == 1 && l < lowest_odd {
+			lowest_odd = l
+		}
+	}
+	for level := highest; level >= lowest_odd; level-- {
+		for i := 0; i < len(text); {
+			if levels[i] < level {
+				i++
+				continue
+			}
+			j := i
+			for j < len(text) && levels[j] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				text[a], text[b] = text[b], text[a]
+				levels[a], levels[b] = levels[b], levels[a]
+			}
+			i = j
+		}
+	}
+	for i := 0; i < len(text); i++ {
+		if levels[i]%2 == 0 {
+			continue
+		}
+		if m, ok := bidi_mirror[text[i]]; ok {
+			text[i] = m
+		}
+		// reversed marks are in front of their letter, the font places a mark over the glyph before it
+		if arabic_transparent(text[i]) {
+			j := i
+			for j < len(text) && arabic_transparent(text[j]) {
+				j++
+			}
+			if j < len(text) {
+				letter := text[j]
+				copy(text[i+1:j+1], text[i:j])
+				text[i] = letter
+			}
+			i = j
+		}
+	}
+	return string(text)
+}
+
+// index of the first letter of text, 0 if it has none
+func first_strong(text []rune) int {
+	for i, r := range text {
+		if c := rune_bidi_class(r); c == bidi_ltr || c == bidi_rtl {
+			return i
+		}
+	}
+	return 0
+}
+
+// the embedding level of a character of class c after a letter of direction last_strong
+func bidi_level(c bidi_class, last_strong bidi_class, base bidi_class) int {
+	if base == bidi_rtl {
+		if c == bidi_rtl {
+			return 1
+		}
+		return 2
+	}
+	switch {
+	case c == bidi_rtl:
+		return 1
+	case c == bidi_number && last_strong == bidi_rtl:
+		return 2
+	}
+	return 0
+}
+
+// direction a resolved character counts as for the neutrals next to it, numbers count as right to left
+func strong_direction(c bidi_class, level int) bidi_class {
+	if c == bidi_number || level%2 == 1 {
+		return bidi_rtl
+	}
+	return bidi_ltr
+}
+
+// a line as it is written to the PDF: shaped and in visual order
+func rtl_line(line string) string {
+	if !has_rtl(line) {
+		return line
+	}
+	return visual_order(shape_arabic(line))
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+func TestShapeArabic(t *testing.T) {
+	for _, c := range []struct {
+		text string
+		want string
+	}{
+		{"data", "data"},
+		{"ب", "ﺏ"},
+		// initial, medial and final forms; alef only joins the letter before it
+		{"بيت", "ﺑﻴﺖ"},
+		{"العربية", "ﺍﻟﻌﺮﺑﻴﺔ"},
+		// lam-alef ligature, final after a joining letter and isolated at the start of a word
+		{"سلام", "ﺳﻼﻡ"},
+		{"لا", "ﻻ"},
+		// marks do not break the joining
+		{"بَت", "ﺑَﺖ"},
+		// hamza does not join, words are shaped separately
+		{"ماء بيت", "ﻣﺎﺀ ﺑﻴﺖ"},
+	} {
+		if got := shape_arabic(c.text); got != c.want {
+			t.Errorf("shape_arabic(%q) = %+q, want %+q", c.text, got, c.want)
+		}
+	}
+}
+
+func TestVisualOrder(t *testing.T) {
+	for _, c := range []struct {
+		line string
+		want string
+	}{
+		{"left to right (1)", "left to right (1)"},
+		// a left-to-right line with a right-to-left word
+		{"abc אבג def", "abc גבא def"},
+		// a right-to-left line keeps numbers and left-to-right words in order
+		{"אבג 123 דה", "הד 123 גבא"},
+		{"אבג data set דה", "הד data set גבא"},
+		// brackets are mirrored in right-to-left text
+		{"א(ב)", "(ב)א"},
+		// a number after a right-to-left word in a left-to-right line
+		{"Title: ب 12", "Title: 12 ب"},
+		// marks stay after their letter
+		{"ﺏَﻪ", "ﻪﺏَ"},
+	} {
+		if got := visual_order(c.line); got != c.want {
+			t.Errorf("visual_order(%q) = %q, want %q", c.line, got, c.want)
+		}
+	}
+	if got := rtl_line("سلام"); got != "ﻡﻼﺳ" {
+		t.Errorf("rtl_line = %+q", got)
+	}
+}
+
+func TestArabicText(t *testing.T) {
+	dir, err := extract_builtin_fonts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := setup_fonts(pdf.NewMaroto(consts.Portrait, consts.A4), font_name_builtin, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm := m.(*fallback_maroto)
+	text := "اختبار البيانات الوصفية متعددة اللغات"
+	if !fm.faces[0].covers(rtl_line(text)) {
+		t.Errorf("the bundled font in %s has no glyphs for the shaped text", dir)
+	}
+	pm, _ := base_maroto(m)
+	if _, ok := maroto_column(pm); !ok {
+		t.Fatal("the column position of this Maroto version cannot be read")
+	}
+	m.Row(10, func() {
+		m.Col(12, func() {
+			m.Text("العربية: "+text, props.Text{Style: consts.Italic})
+		})
+	})
+	if _, err = m.Output(); err != nil {
+		t.Fatal(err)
+	}
+}
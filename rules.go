@@ -0,0 +1,233 @@
+/*
+rules.go validation rules for Yoda metadata, each rule produces findings whose severity and
+enabled state are controlled by the active rule profile (see profile.go).
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// A single validation result, Field is the Yoda field path the finding refers to
+type Finding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field"`
+	Message  string   `json:"message"`
+}
+
+// A validation rule, check returns findings without a severity, this is filled in from the profile
+type rule struct {
+	id          string
+	severity    Severity
+	description string
+	check       func(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding
+}
+
+var rule_registry = []rule{
+	{"required-field", SeverityError, "fields declared as required by the profile must be filled in", check_required_fields},
+	{"recommended-field", SeverityWarning, "fields declared as recommended by the profile should be filled in", check_recommended_fields},
+	{"creator-orcid", SeverityError, "every creator has at least one ORCID", check_creator_orcid},
+	{"contributors-vs-creators", SeverityInfo, "dataset authors should be listed as creators rather than contributors", check_contributors_vs_creators},
+	{"access-classification", SeverityError, "open data must be classified as public", check_access_classification},
+	{"min-tags", SeverityWarning, "the number of tags is at least thresholds.min_tags", check_min_tags},
+	{"description-length", SeverityInfo, "the description is at least thresholds.min_description_length characters", check_description_length},
+	{"duplicate-person", SeverityWarning, "a person is not listed more than once as creator or contributor", check_duplicate_people},
+	{"person-affiliation-conflict", SeverityInfo, "entries of the same person list the same affiliations", check_person_affiliations},
+	{"geolocation-box", SeverityError, "bounding boxes have latitudes within -90..90, longitudes within -180..180 and south below north", check_geolocation_boxes},
+	{"ambiguous-date", SeverityWarning, "numeric dates cannot be read both day first and month first", check_ambiguous_dates},
+	{"text-hygiene", SeverityWarning, "text has no stray whitespace, invisible or control characters, typographic quotes or non-NFC Unicode", check_text_hygiene},
+}
+
+// look up a rule by id
+func get_rule(id string) *rule {
+	for i := range rule_registry {
+		if rule_registry[i].id == id {
+			return &rule_registry[i]
+		}
+	}
+	return nil
+}
+
+// run all rules enabled in the profile over the metadata
+func validate_metadata(data Yoda18Metadata, profile Profile) []Finding {
+	var findings []Finding
+	fields := metadata_to_map(data)
+
+	for _, r := range rule_registry {
+		if !profile.rule_enabled(r.id) {
+			continue
+		}
+		for _, f := range r.check(data, fields, profile) {
+			f.Rule = r.id
+			f.Severity = profile.rule_severity(r.id)
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+// count the findings per severity
+func count_findings(findings []Finding) map[Severity]int {
+	counts := map[Severity]int{SeverityInfo: 0, SeverityWarning: 0, SeverityError: 0}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	return counts
+}
+
+// generic representation of the metadata keyed on Yoda (JSON) field names
+func metadata_to_map(data Yoda18Metadata) map[string]interface{} {
+	var fields map[string]interface{}
+	raw, err := json.Marshal(data)
+	errcntrl(err)
+	errcntrl(json.Unmarshal(raw, &fields))
+	return fields
+}
+
+// deep copy of the metadata, the nested lists are not shared with the original
+func clone_metadata(data Yoda18Metadata) Yoda18Metadata {
+	var clone Yoda18Metadata
+	raw, err := json.Marshal(data)
+	errcntrl(err)
+	errcntrl(json.Unmarshal(raw, &clone))
+	return clone
+}
+
+// a value is empty if it is blank, zero or only contains empty values
+func field_is_empty(v interface{}) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(vv) == ""
+	case float64:
+		return vv == 0
+	case []interface{}:
+		for _, e := range vv {
+			if !field_is_empty(e) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		for _, e := range vv {
+			if !field_is_empty(e) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// return the locations where field path (e.g. "Creator.Person_Identifier") is empty,
+// list fields are checked element by element
+func find_empty_fields(fields map[string]interface{}, path string) []string {
+	return find_empty_fields_base(fields, strings.Split(path, "."), "")
+}
+
+func find_empty_fields_base(fields map[string]interface{}, parts []string, prefix string) []string {
+	var missing []string
+	name := prefix + parts[0]
+	value := fields[parts[0]]
+
+	if field_is_empty(value) {
+		// report the requested field, not its empty parent, so every child is reported once
+		return append(missing, strings.Join(append([]string{name}, parts[1:]...), "."))
+	}
+	if len(parts) == 1 {
+		return missing
+	}
+
+	switch vv := value.(type) {
+	case []interface{}:
+		for i, e := range vv {
+			if sub, ok := e.(map[string]interface{}); ok {
+				missing = append(missing, find_empty_fields_base(sub, parts[1:], fmt.Sprintf("%s[%d].", name, i+1))...)
+			}
+		}
+	case map[string]interface{}:
+		missing = append(missing, find_empty_fields_base(vv, parts[1:], name+".")...)
+	}
+	return missing
+}
+
+func check_required_fields(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for _, path := range profile.Required {
+		for _, loc := range find_empty_fields(fields, path) {
+			findings = append(findings, Finding{Field: loc, Message: tr("msg.required_field", loc)})
+		}
+	}
+	return findings
+}
+
+func check_recommended_fields(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for _, path := range profile.Recommended {
+		for _, loc := range find_empty_fields(fields, path) {
+			findings = append(findings, Finding{Field: loc, Message: tr("msg.recommended_field", loc)})
+		}
+	}
+	return findings
+}
+
+func check_creator_orcid(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var findings []Finding
+	for i := range data.Creator {
+		has_orcid := false
+		for _, pid := range data.Creator[i].PersonIdentifier {
+			if strings.EqualFold(strings.TrimSpace(pid.NameIdentifierScheme), "ORCID") && strings.TrimSpace(pid.NameIdentifier) != "" {
+				has_orcid = true
+			}
+		}
+		if !has_orcid {
+			findings = append(findings, Finding{Field: fmt.Sprintf("Creator[%d].Person_Identifier", i+1),
+				Message: tr("msg.creator_orcid", data.Creator[i].Name.GivenName, data.Creator[i].Name.FamilyName)})
+		}
+	}
+	return findings
+}
+
+func check_contributors_vs_creators(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	if len(data.Contributor) > len(data.Creator) {
+		return []Finding{{Field: "Contributor",
+			Message: tr("msg.contributors_vs_creators")}}
+	}
+	return nil
+}
+
+func check_access_classification(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification != "Public" {
+		return []Finding{{Field: "Data_Classification",
+			Message: tr("msg.access_classification", data.DataClassification)}}
+	}
+	return nil
+}
+
+func check_min_tags(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	var tags int
+	for _, t := range data.Tag {
+		if strings.TrimSpace(t) != "" {
+			tags++
+		}
+	}
+	if tags < profile.Thresholds.MinTags {
+		return []Finding{{Field: "Tag", Message: tr("msg.min_tags", tags, profile.Thresholds.MinTags)}}
+	}
+	return nil
+}
+
+func check_description_length(data Yoda18Metadata, fields map[string]interface{}, profile Profile) []Finding {
+	length := len([]rune(strings.TrimSpace(data.Description)))
+	if length < profile.Thresholds.MinDescriptionLength {
+		return []Finding{{Field: "Description", Message: tr("msg.description_length",
+			length, profile.Thresholds.MinDescriptionLength)}}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// metadata from a Yoda metadata JSON document
+func test_metadata(t *testing.T, doc string) Yoda18Metadata {
+	t.Helper()
+	var data Yoda18Metadata
+	if err := json.Unmarshal([]byte(doc), &data); err != nil {
+		t.Fatalf("bad test metadata: %v", err)
+	}
+	return data
+}
+
+func TestContributorsVsCreators(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want int
+	}{
+		{"fewer contributors", `{"Creator": [{}, {}], "Contributor": [{}]}`, 0},
+		{"as many contributors", `{"Creator": [{}], "Contributor": [{}]}`, 0},
+		{"more contributors", `{"Creator": [{}], "Contributor": [{}, {}]}`, 1},
+		{"no creators", `{"Contributor": [{}]}`, 1},
+		{"no people", `{}`, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := check_contributors_vs_creators(test_metadata(t, tt.doc), nil, Profile{})
+			if len(got) != tt.want {
+				t.Errorf("got %d findings, want %d: %v", len(got), tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateMetadataSeverities(t *testing.T) {
+	profile, err := load_profile(default_profile_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := test_metadata(t, `{"Creator": [{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}}],
+		"Data_Access_Restriction": "Open - freely retrievable", "Data_Classification": "Sensitive"}`)
+	findings := validate_metadata(data, profile)
+	counts := count_findings(findings)
+	if counts[SeverityError] == 0 {
+		t.Errorf("expected errors for missing required fields and classification, got %v", counts)
+	}
+	for _, f := range findings {
+		if f.Rule == "" || f.Severity == "" {
+			t.Errorf("finding without rule or severity: %+v", f)
+		}
+		if f.Rule == "access-classification" && f.Severity != SeverityError {
+			t.Errorf("access-classification severity %s, want %s", f.Severity, SeverityError)
+		}
+	}
+}
+
+func TestFindEmptyFields(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		path string
+		want []string
+	}{
+		{"present", `{"Collected": {"Start_Date": "2020-01-01"}}`, "Collected.Start_Date", nil},
+		{"empty child", `{"Collected": {"Start_Date": ""}}`, "Collected.Start_Date", []string{"Collected.Start_Date"}},
+		{"missing parent", `{}`, "Collected.Start_Date", []string{"Collected.Start_Date"}},
+		{"empty parent", `{"Collected": {}}`, "Collected.End_Date", []string{"Collected.End_Date"}},
+		{"list", `{"Creator": [{"Name": {"Given_Name": "Ada"}}, {}]}`, "Creator.Name.Family_Name",
+			[]string{"Creator[1].Name.Family_Name", "Creator[2].Name.Family_Name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fields map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.doc), &fields); err != nil {
+				t.Fatal(err)
+			}
+			if got := find_empty_fields(fields, tt.path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("find_empty_fields(%s) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRecommendedFieldsMissingParent(t *testing.T) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"Collected": {}}`), &fields); err != nil {
+		t.Fatal(err)
+	}
+	profile := Profile{Recommended: []string{"Collected.Start_Date", "Collected.End_Date"}}
+	findings := check_recommended_fields(Yoda18Metadata{}, fields, profile)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Field)
+	}
+	if want := []string{"Collected.Start_Date", "Collected.End_Date"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findings for %v, want %v", got, want)
+	}
+}
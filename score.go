@@ -0,0 +1,182 @@
+/*
+score.go metadata completeness and FAIR indicator scores for a Yoda data package.
+Completeness is the weighted coverage of the mandatory, recommended and optional fields of the
+active rule profile, the FAIR breakdown is a set of simple pass/fail indicators per principle.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// weights of the field classes in the completeness score
+const weight_mandatory float64 = 0.6
+const weight_recommended float64 = 0.3
+const weight_optional float64 = 0.1
+
+// FAIR principles in report order
+var fair_principles = []string{"Findable", "Accessible", "Interoperable", "Reusable"}
+
+// coverage of one class of fields
+type FieldCoverage struct {
+	Filled  int      `json:"filled"`
+	Total   int      `json:"total"`
+	Missing []string `json:"missing,omitempty"`
+}
+
+// a single pass/fail FAIR indicator, an indicator that does not apply does not count
+type FairIndicator struct {
+	Principle     string `json:"principle"`
+	Name          string `json:"name"`
+	Passed        bool   `json:"passed"`
+	NotApplicable bool   `json:"not_applicable,omitempty"`
+}
+
+// completeness and FAIR scores of a data package, all scores are percentages
+type ScoreReport struct {
+	Completeness float64            `json:"completeness"`
+	Mandatory    FieldCoverage      `json:"mandatory"`
+	Recommended  FieldCoverage      `json:"recommended"`
+	Optional     FieldCoverage      `json:"optional"`
+	Fair         map[string]float64 `json:"fair"`
+	Indicators   []FairIndicator    `json:"indicators"`
+}
+
+// controlled vocabulary formats used by the Yoda 1.8 schema
+var discipline_term = regexp.MustCompile(`^.+ \([0-9]+(\.[0-9]+)*\)$`)
+var language_term = regexp.MustCompile(`^[a-z]{2,3} - .+$`)
+
+// compute the completeness and FAIR scores of the metadata
+func score_metadata(data Yoda18Metadata, profile Profile) ScoreReport {
+	var score ScoreReport
+	fields := metadata_to_map(data)
+
+	// every top level field not mentioned by the profile is optional
+	declared := map[string]bool{}
+	for _, path := range append(append([]string{}, profile.Required...), profile.Recommended...) {
+		declared[strings.Split(path, ".")[0]] = true
+	}
+	var optional []string
+	for name := range fields {
+		if !declared[name] && name != "links" {
+			optional = append(optional, name)
+		}
+	}
+	sort.Strings(optional)
+
+	score.Mandatory = field_coverage(fields, profile.Required)
+	score.Recommended = field_coverage(fields, profile.Recommended)
+	score.Optional = field_coverage(fields, optional)
+
+	// field classes without fields do not count towards the score
+	var total, weights float64
+	for _, c := range []struct {
+		coverage FieldCoverage
+		weight   float64
+	}{{score.Mandatory, weight_mandatory}, {score.Recommended, weight_recommended}, {score.Optional, weight_optional}} {
+		if c.coverage.Total > 0 {
+			total += c.weight * float64(c.coverage.Filled) / float64(c.coverage.Total)
+			weights += c.weight
+		}
+	}
+	if weights > 0 {
+		score.Completeness = round_score(100 * total / weights)
+	}
+
+	score.Indicators = fair_indicators(data)
+	score.Fair = map[string]float64{}
+	for _, p := range fair_principles {
+		var passed, count int
+		for _, ind := range score.Indicators {
+			if ind.Principle == p && !ind.NotApplicable {
+				count++
+				if ind.Passed {
+					passed++
+				}
+			}
+		}
+		if count > 0 {
+			score.Fair[p] = round_score(100 * float64(passed) / float64(count))
+		}
+	}
+	return score
+}
+
+// count how many of the field paths are completely filled in
+func field_coverage(fields map[string]interface{}, paths []string) FieldCoverage {
+	var cov FieldCoverage
+	for _, path := range paths {
+		cov.Total++
+		if len(find_empty_fields(fields, path)) == 0 {
+			cov.Filled++
+		} else {
+			cov.Missing = append(cov.Missing, path)
+		}
+	}
+	return cov
+}
+
+// the FAIR indicators: persistent identifiers, licence, provenance and vocabularies
+func fair_indicators(data Yoda18Metadata) []FairIndicator {
+	var creators_with_pid int
+	for _, c := range data.Creator {
+		for _, pid := range c.PersonIdentifier {
+			if strings.TrimSpace(pid.NameIdentifierScheme) != "" && strings.TrimSpace(pid.NameIdentifier) != "" {
+				creators_with_pid++
+				break
+			}
+		}
+	}
+	var related_with_pid int
+	for _, r := range data.RelatedDatapackage {
+		if strings.TrimSpace(r.PersistentIdentifier.IdentifierScheme) != "" && strings.TrimSpace(r.PersistentIdentifier.Identifier) != "" {
+			related_with_pid++
+		}
+	}
+	disciplines_ok := len(data.Discipline) > 0
+	for _, d := range data.Discipline {
+		disciplines_ok = disciplines_ok && discipline_term.MatchString(strings.TrimSpace(d))
+	}
+	funding_ok := len(data.FundingReference) > 0
+	for _, f := range data.FundingReference {
+		funding_ok = funding_ok && strings.TrimSpace(f.FunderName) != ""
+	}
+	filled := func(s string) bool { return strings.TrimSpace(s) != "" }
+
+	return []FairIndicator{
+		{"Findable", "rich title and description", filled(data.Title) && len(strings.TrimSpace(data.Description)) >= 100, false},
+		{"Findable", "keywords (tags)", len(data.Tag) > 0 && filled(data.Tag[0]), false},
+		{"Findable", "persistent identifiers for all creators", len(data.Creator) > 0 && creators_with_pid == len(data.Creator), false},
+		{"Findable", "persistent identifiers for related datapackages", len(data.RelatedDatapackage) > 0 && related_with_pid == len(data.RelatedDatapackage),
+			len(data.RelatedDatapackage) == 0},
+		{"Accessible", "licence", filled(data.License), false},
+		{"Accessible", "access restriction", filled(data.DataAccessRestriction), false},
+		{"Accessible", "retention period", data.RetentionPeriod > 0, false},
+		{"Interoperable", "discipline vocabulary", disciplines_ok, false},
+		{"Interoperable", "language vocabulary", language_term.MatchString(strings.TrimSpace(data.Language)), false},
+		{"Interoperable", "schema reference (links)", len(data.Links) > 0 && filled(data.Links[0].Href), false},
+		{"Reusable", "provenance: collection period", filled(data.Collected.StartDate) && filled(data.Collected.EndDate), false},
+		{"Reusable", "provenance: funding", funding_ok, false},
+		{"Reusable", "provenance: version", filled(data.Version), false},
+		{"Reusable", "standard licence", filled(data.License) && !strings.EqualFold(strings.TrimSpace(data.License), "Custom"), false},
+	}
+}
+
+// one line summary of the FAIR scores, e.g. "F 75% A 100% I 67% R 50%"
+func fair_summary(score ScoreReport) string {
+	var parts []string
+	for _, p := range fair_principles {
+		parts = append(parts, fmt.Sprintf("%s %.0f%%", p[:1], score.Fair[p]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func round_score(v float64) float64 {
+	return math.Round(v*10) / 10
+}
@@ -0,0 +1,78 @@
+package main
+
+import "testing"
+
+// the indicator of fair_indicators by name
+func find_indicator(t *testing.T, indicators []FairIndicator, name string) FairIndicator {
+	t.Helper()
+	for _, ind := range indicators {
+		if ind.Name == name {
+			return ind
+		}
+	}
+	t.Fatalf("no indicator %q", name)
+	return FairIndicator{}
+}
+
+func TestFairIndicators(t *testing.T) {
+	long := `"Description": "A description of the data package that is long enough to be a rich description of its contents and their origin."`
+	tests := []struct {
+		name           string
+		doc            string
+		indicator      string
+		passed         bool
+		not_applicable bool
+	}{
+		{"rich description", `{"Title": "T", ` + long + `}`, "rich title and description", true, false},
+		{"short description", `{"Title": "T", "Description": "Short"}`, "rich title and description", false, false},
+		{"tags", `{"Tag": ["a"]}`, "keywords (tags)", true, false},
+		{"empty tag", `{"Tag": [""]}`, "keywords (tags)", false, false},
+		{"creator ORCID", `{"Creator": [{"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]}]}`,
+			"persistent identifiers for all creators", true, false},
+		{"creator without ORCID", `{"Creator": [{"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]}, {}]}`,
+			"persistent identifiers for all creators", false, false},
+		{"no creators", `{}`, "persistent identifiers for all creators", false, false},
+		{"related with identifier", `{"Related_Datapackage": [{"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.1"}}]}`,
+			"persistent identifiers for related datapackages", true, false},
+		{"related without identifier", `{"Related_Datapackage": [{"Title": "Other"}]}`,
+			"persistent identifiers for related datapackages", false, false},
+		{"no related datapackages", `{}`, "persistent identifiers for related datapackages", false, true},
+		{"retention period", `{"Retention_Period": 10}`, "retention period", true, false},
+		{"discipline term", `{"Discipline": ["Natural Sciences - Biological sciences (1.6)"]}`, "discipline vocabulary", true, false},
+		{"free discipline", `{"Discipline": ["Natural Sciences (1)", "biology"]}`, "discipline vocabulary", false, false},
+		{"language term", `{"Language": "en - English"}`, "language vocabulary", true, false},
+		{"free language", `{"Language": "English"}`, "language vocabulary", false, false},
+		{"collection period", `{"Collected": {"Start_Date": "2020-01-01", "End_Date": "2020-12-31"}}`, "provenance: collection period", true, false},
+		{"open collection period", `{"Collected": {"Start_Date": "2020-01-01"}}`, "provenance: collection period", false, false},
+		{"funding", `{"Funding_Reference": [{"Funder_Name": "NWO"}]}`, "provenance: funding", true, false},
+		{"funding without funder", `{"Funding_Reference": [{"Funder_Name": "NWO"}, {"Award_Number": "1"}]}`, "provenance: funding", false, false},
+		{"standard licence", `{"License": "Creative Commons Attribution 4.0 International Public License"}`, "standard licence", true, false},
+		{"custom licence", `{"License": "custom"}`, "standard licence", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ind := find_indicator(t, fair_indicators(test_metadata(t, tt.doc)), tt.indicator)
+			if ind.Passed != tt.passed || ind.NotApplicable != tt.not_applicable {
+				t.Errorf("%s: passed %v, not applicable %v, want %v, %v", tt.indicator, ind.Passed, ind.NotApplicable, tt.passed, tt.not_applicable)
+			}
+		})
+	}
+}
+
+func TestScoreMetadata(t *testing.T) {
+	profile := Profile{Required: []string{"Title", "Creator.Name.Family_Name"}, Recommended: []string{"Tag"}}
+	data := test_metadata(t, `{"Title": "T", "Creator": [{"Name": {"Family_Name": "Jansen"}}, {}],
+		"Related_Datapackage": [], "License": "Custom"}`)
+	score := score_metadata(data, profile)
+	if score.Mandatory.Filled != 1 || score.Mandatory.Total != 2 || score.Recommended.Filled != 0 || score.Recommended.Total != 1 {
+		t.Errorf("mandatory %+v, recommended %+v", score.Mandatory, score.Recommended)
+	}
+	// the related datapackages indicator does not count without related datapackages: 0 of 3
+	if score.Fair["Findable"] != 0 || score.Fair["Accessible"] != 33.3 {
+		t.Errorf("FAIR scores %v", score.Fair)
+	}
+	with := test_metadata(t, `{"Related_Datapackage": [{"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.1"}}]}`)
+	if got := score_metadata(with, profile).Fair["Findable"]; got != 25 {
+		t.Errorf("Findable %v, want 25", got)
+	}
+}
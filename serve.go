@@ -0,0 +1,420 @@
+/*
+serve.go readYmeta as a small intranet service. readYmeta serve starts an HTTP server with an
+upload page (GET /), POST /render that returns the report of an uploaded yoda-metadata.json as
+PDF, HTML or Markdown, chosen by the Accept header or a format form value, and POST /validate
+that returns the validation findings and scores as JSON. The metadata is the request body or the
+file field of a multipart form. Uploads are limited in size, requests time out and reports are
+rendered one at a time, the renderer keeps its state in package variables. The PDF options of
+the main command (-pdfa, -font, -font-fallback, -toc, -qr, -layout, -table-above) apply to every
+report.
+Usage: readYmeta serve [-addr localhost:8080] [-max-size <bytes>] [-timeout 60s] [PDF options]
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const serve_default_addr string = "localhost:8080"
+const serve_default_max_size int64 = 1 << 20
+const serve_default_timeout time.Duration = 60 * time.Second
+
+// template of the HTML report
+const html_template_name string = "report"
+
+// report formats of /render by format name, in order of preference
+var serve_formats = []struct {
+	name       string
+	media_type string
+}{
+	{"pdf", "application/pdf"},
+	{"html", "text/html; charset=utf-8"},
+	{"md", "text/markdown; charset=utf-8"},
+}
+
+// the PDF options of the main command that serve takes as well
+var serve_render_options = []string{"pdfa", "font", "font-fallback", "toc", "qr", "layout", "table-above"}
+
+// the state of the service, render_lock holds a token while a report is rendered so that one
+// report is rendered at a time
+type metadata_server struct {
+	Profile     Profile
+	Language    string
+	MaxSize     int64
+	Timeout     time.Duration
+	Markdown    ReportTemplate
+	HTML        ReportTemplate
+	render_lock chan struct{}
+}
+
+// a service with the builtin report templates and the default limits
+func new_metadata_server(profile Profile, lang string) (*metadata_server, error) {
+	md, err := load_report_template(default_template_name)
+	if err != nil {
+		return nil, err
+	}
+	html, err := load_report_template(html_template_name)
+	if err != nil {
+		return nil, err
+	}
+	return &metadata_server{Profile: profile, Language: lang, MaxSize: serve_default_max_size,
+		Timeout: serve_default_timeout, Markdown: md, HTML: html, render_lock: make(chan struct{}, 1)}, nil
+}
+
+// the routes of the service, requests that take longer than Timeout get a 503
+func (s *metadata_server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", s.handle_upload_page)
+	mux.HandleFunc("/render", s.handle_render)
+	mux.HandleFunc("/validate", s.handle_validate)
+	return http.TimeoutHandler(mux, s.Timeout, "request timed out\n")
+}
+
+// write an error response
+func (s *metadata_server) fail(w http.ResponseWriter, r *http.Request, status int, err error) {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		status = http.StatusServiceUnavailable
+	}
+	fmt.Printf("%s %s: %d %v\n", r.Method, r.URL.Path, status, err)
+	http.Error(w, err.Error(), status)
+}
+
+// only POST is allowed on the API
+func (s *metadata_server) require_post(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		s.fail(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed, use POST", r.Method))
+		return false
+	}
+	return true
+}
+
+// read the metadata of a request, ok is false when an error response has been written
+func (s *metadata_server) read_upload(w http.ResponseWriter, r *http.Request) (Yoda18Metadata, string, bool) {
+	var data Yoda18Metadata
+	if r.ContentLength > s.MaxSize {
+		s.fail(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("upload is larger than %d bytes", s.MaxSize))
+		return data, "", false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, s.MaxSize)
+
+	name := current_metadata_file_name
+	var raw []byte
+	var err error
+	media, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if media == "multipart/form-data" {
+		if err = r.ParseMultipartForm(s.MaxSize); err == nil {
+			file, header, ferr := r.FormFile("file")
+			if ferr != nil {
+				s.fail(w, r, http.StatusBadRequest, fmt.Errorf("no metadata file uploaded: %w", ferr))
+				return data, "", false
+			}
+			defer file.Close()
+			name = filepath.Base(header.Filename)
+			raw, err = io.ReadAll(file)
+		}
+	} else {
+		raw, err = io.ReadAll(r.Body)
+	}
+	if err != nil {
+		// http.MaxBytesError is not available before Go 1.19
+		if strings.Contains(err.Error(), "request body too large") {
+			s.fail(w, r, http.StatusRequestEntityTooLarge, fmt.Errorf("upload is larger than %d bytes", s.MaxSize))
+		} else {
+			s.fail(w, r, http.StatusBadRequest, err)
+		}
+		return data, "", false
+	}
+	data, err = decode_metadata(raw, name)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err)
+		return data, "", false
+	}
+	return data, name, true
+}
+
+// the report language of a request, the lang form or query value or else the service default
+func (s *metadata_server) request_language(r *http.Request) (string, error) {
+	lang := r.FormValue("lang")
+	if lang == "" {
+		return s.Language, nil
+	}
+	return lang, check_language(lang)
+}
+
+// run f with the renderer to itself, a panic in the renderer is returned as an error. The
+// timeout handler does not stop a running f, so a request that timed out or whose client has gone
+// gives up waiting for the renderer and does not start rendering.
+func (s *metadata_server) locked(r *http.Request, f func() error) (err error) {
+	if err = r.Context().Err(); err != nil {
+		return err
+	}
+	select {
+	case s.render_lock <- struct{}{}:
+	case <-r.Context().Done():
+		return r.Context().Err()
+	}
+	defer func() { <-s.render_lock }()
+	// the request may have ended while the lock was taken
+	if err = r.Context().Err(); err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("rendering failed: %v", p)
+		}
+	}()
+	return f()
+}
+
+// the report format for an Accept header, PDF if any format will do; ok is false if none of
+// the formats is acceptable
+func negotiate_format(accept string) (string, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return serve_formats[0].name, true
+	}
+	best, best_q := "", 0.0
+	for _, f := range serve_formats {
+		media, _, _ := mime.ParseMediaType(f.media_type)
+		// the quality of the most specific matching range
+		q, specificity := 0.0, -1
+		for _, part := range strings.Split(accept, ",") {
+			accepted, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+			if err != nil {
+				continue
+			}
+			level := -1
+			switch {
+			case accepted == media:
+				level = 2
+			case accepted == strings.SplitN(media, "/", 2)[0]+"/*":
+				level = 1
+			case accepted == "*/*":
+				level = 0
+			}
+			if level <= specificity {
+				continue
+			}
+			specificity, q = level, 1.0
+			if v, ok := params["q"]; ok {
+				q, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+		if q > best_q {
+			best, best_q = f.name, q
+		}
+	}
+	return best, best != ""
+}
+
+// the media type of a report format
+func format_media_type(format string) (string, bool) {
+	for _, f := range serve_formats {
+		if f.name == format {
+			return f.media_type, true
+		}
+	}
+	return "", false
+}
+
+// GET /: the upload form
+func (s *metadata_server) handle_upload_page(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		s.fail(w, r, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+	var languages strings.Builder
+	for _, lang := range list_languages() {
+		fmt.Fprintf(&languages, `<option value="%s">%s</option>`, lang, lang)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprintf(w, serve_upload_page, _MYVERSION_, s.MaxSize/1024, language_auto, languages.String())
+}
+
+const serve_upload_page string = `<!DOCTYPE html>
+<html lang="en">
+<head><meta charset="utf-8"><title>readYmeta</title>
+<style>body { font-family: sans-serif; max-width: 40em; margin: 2em auto; } p { margin: 1em 0; }</style>
+</head>
+<body>
+<h1>readYmeta v%s</h1>
+<form method="post" action="render" enctype="multipart/form-data">
+<p><label>Yoda metadata file (at most %d kB): <input type="file" name="file" accept=".json,application/json" required></label></p>
+<p><label>Report: <select name="format"><option value="pdf">PDF</option><option value="html">HTML</option><option value="md">Markdown</option></select></label>
+<label>Language: <select name="lang"><option value="%s">from the metadata</option>%s</select></label></p>
+<p><button type="submit">Render report</button> <button type="submit" formaction="validate">Validate (JSON)</button></p>
+</form>
+</body>
+</html>
+`
+
+// POST /render: the PDF, HTML or Markdown report of the uploaded metadata
+func (s *metadata_server) handle_render(w http.ResponseWriter, r *http.Request) {
+	if !s.require_post(w, r) {
+		return
+	}
+	data, name, ok := s.read_upload(w, r)
+	if !ok {
+		return
+	}
+	lang, err := s.request_language(r)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+	format := r.FormValue("format")
+	if format == "" {
+		if format, ok = negotiate_format(r.Header.Get("Accept")); !ok {
+			s.fail(w, r, http.StatusNotAcceptable, fmt.Errorf("the report is available as application/pdf, text/html or text/markdown"))
+			return
+		}
+	}
+	media_type, ok := format_media_type(format)
+	if !ok {
+		s.fail(w, r, http.StatusBadRequest, fmt.Errorf("unknown report format %q, use pdf, html or md", format))
+		return
+	}
+
+	var out []byte
+	err = s.locked(r, func() error {
+		REPORT_LANGUAGE = select_language(lang, data)
+		report := create_metadata_report(data, name, s.Profile)
+		switch format {
+		case "pdf":
+			doc, err := create_pdf_report(data, name, report)
+			if err != nil {
+				return err
+			}
+			out, err = pdf_file_bytes(doc, data, name, *pdfa_flag)
+			return err
+		case "html":
+			text, err := s.HTML.render(data, report)
+			out = []byte(text)
+			return err
+		}
+		text, err := s.Markdown.render(data, report)
+		out = []byte(text)
+		return err
+	})
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	fname := strings.TrimSuffix(name, filepath.Ext(name)) + "." + format
+	w.Header().Set("Content-Type", media_type)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": fname}))
+	w.Header().Set("Vary", "Accept")
+	_, _ = w.Write(out)
+}
+
+// POST /validate: the findings and scores of the uploaded metadata as JSON
+func (s *metadata_server) handle_validate(w http.ResponseWriter, r *http.Request) {
+	if !s.require_post(w, r) {
+		return
+	}
+	data, name, ok := s.read_upload(w, r)
+	if !ok {
+		return
+	}
+	lang, err := s.request_language(r)
+	if err != nil {
+		s.fail(w, r, http.StatusBadRequest, err)
+		return
+	}
+	var report MetadataReport
+	err = s.locked(r, func() error {
+		REPORT_LANGUAGE = select_language(lang, data)
+		report = create_metadata_report(data, name, s.Profile)
+		return nil
+	})
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	out, err := json.MarshalIndent(report, "", "    ")
+	if err != nil {
+		s.fail(w, r, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(out)
+}
+
+// readYmeta serve: run the service until it is stopped
+func serve_command(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", serve_default_addr, "address to listen on, host:port")
+	max_size := fs.Int64("max-size", serve_default_max_size, "maximum size of an upload in bytes")
+	timeout := fs.Duration("timeout", serve_default_timeout, "maximum time to read, render and answer a request")
+	profile_name := fs.String("profile", default_profile_name, "rule profile, a builtin profile name or the path to a YAML/TOML profile file")
+	theme := fs.String("theme", default_theme_name, "report theme, a builtin theme name or the path to a YAML/TOML theme file")
+	lang := fs.String("lang", language_auto, "default report language: "+language_auto+" (the Language field of the metadata) or one of "+
+		strings.Join(list_languages(), ", "))
+	html_template := fs.String("html-template", html_template_name, "template of the HTML report, a builtin template name or a path")
+	// the renderer reads these options from the variables of the main command flags
+	for _, name := range serve_render_options {
+		f := flag.CommandLine.Lookup(name)
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta serve [options]")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	errcntrl(check_language(*lang))
+	errcntrl(check_layout(*layout_flag))
+
+	profile, err := load_profile(*profile_name)
+	errcntrl(err)
+	fmt.Println("Using rule profile:", profile.Name)
+	REPORT_THEME, err = load_theme(*theme)
+	errcntrl(err)
+	apply_theme_fonts(REPORT_THEME, fs)
+	fmt.Println("Using report theme:", REPORT_THEME.Name)
+	if *pdfa_flag && *font_flag == font_name_core {
+		errcntrl(fmt.Errorf("PDF/A needs embedded fonts, the core fonts cannot be used with -pdfa"))
+	}
+
+	s, err := new_metadata_server(profile, *lang)
+	errcntrl(err)
+	s.MaxSize = *max_size
+	s.Timeout = *timeout
+	templates, err := load_report_templates([]string{*html_template})
+	errcntrl(err)
+	s.HTML = templates[0]
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       *timeout,
+		// leave the timeout handler time to answer
+		WriteTimeout: *timeout + 5*time.Second,
+		IdleTimeout:  2 * time.Minute,
+	}
+	fmt.Printf("Serving on http://%s/ (max upload %d bytes, timeout %s)\n", *addr, *max_size, *timeout)
+	errcntrl(server.ListenAndServe())
+}
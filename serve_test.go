@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func test_server(t *testing.T) *metadata_server {
+	profile, err := load_profile(default_profile_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := new_metadata_server(profile, language_auto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func test_upload(t *testing.T) []byte {
+	raw, err := os.ReadFile("test-data/yoda-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	for _, c := range []struct {
+		accept string
+		format string
+		ok     bool
+	}{
+		{"", "pdf", true},
+		{"*/*", "pdf", true},
+		{"application/pdf", "pdf", true},
+		{"text/html", "html", true},
+		{"text/markdown", "md", true},
+		{"text/*", "html", true},
+		{"text/*;q=0.5, text/markdown", "md", true},
+		{"text/html;q=0.2, application/pdf;q=0.1", "html", true},
+		{"*/*;q=0.1, text/markdown;q=0.9", "md", true},
+		{"application/pdf;q=0, */*", "html", true},
+		{"image/png", "", false},
+		{"application/json, image/*", "", false},
+	} {
+		format, ok := negotiate_format(c.accept)
+		if format != c.format || ok != c.ok {
+			t.Errorf("negotiate_format(%q) = %q, %v, want %q, %v", c.accept, format, ok, c.format, c.ok)
+		}
+	}
+}
+
+func TestServeRender(t *testing.T) {
+	s := test_server(t)
+	h := s.handler()
+	raw := test_upload(t)
+
+	for _, c := range []struct {
+		accept string
+		format string
+		status int
+		media  string
+		prefix string
+	}{
+		{"text/markdown", "", http.StatusOK, "text/markdown; charset=utf-8", "# "},
+		{"text/html", "", http.StatusOK, "text/html; charset=utf-8", "<!DOCTYPE html>"},
+		{"", "", http.StatusOK, "application/pdf", "%PDF-"},
+		{"image/png", "", http.StatusNotAcceptable, "", ""},
+		{"application/pdf", "md", http.StatusOK, "text/markdown; charset=utf-8", "# "},
+		{"", "docx", http.StatusBadRequest, "", ""},
+	} {
+		url := "/render"
+		if c.format != "" {
+			url += "?format=" + c.format
+		}
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewReader(raw))
+		if c.accept != "" {
+			req.Header.Set("Accept", c.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		name := c.accept + " " + c.format
+		if rec.Code != c.status {
+			t.Errorf("%s: status %d, want %d: %s", name, rec.Code, c.status, rec.Body.String())
+			continue
+		}
+		if c.status != http.StatusOK {
+			continue
+		}
+		if got := rec.Header().Get("Content-Type"); got != c.media {
+			t.Errorf("%s: Content-Type %q, want %q", name, got, c.media)
+		}
+		if !strings.HasPrefix(strings.TrimSpace(rec.Body.String()), c.prefix) {
+			t.Errorf("%s: body does not start with %q", name, c.prefix)
+		}
+	}
+}
+
+func TestServeRenderForm(t *testing.T) {
+	s := test_server(t)
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("format", "md"); err != nil {
+		t.Fatal(err)
+	}
+	file, err := form.CreateFormFile("file", "package.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.Write(test_upload(t))
+	form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/render", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `inline; filename=package.md` {
+		t.Errorf("Content-Disposition %q", got)
+	}
+}
+
+func TestServeMethods(t *testing.T) {
+	h := test_server(t).handler()
+	for _, c := range []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{http.MethodGet, "/", http.StatusOK, ""},
+		{http.MethodPost, "/", http.StatusMethodNotAllowed, "GET, HEAD"},
+		{http.MethodGet, "/render", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodPut, "/render", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodGet, "/validate", http.StatusMethodNotAllowed, "POST"},
+		{http.MethodGet, "/missing", http.StatusNotFound, ""},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		if rec.Code != c.status {
+			t.Errorf("%s %s: status %d, want %d", c.method, c.path, rec.Code, c.status)
+		}
+		if got := rec.Header().Get("Allow"); got != c.allow {
+			t.Errorf("%s %s: Allow %q, want %q", c.method, c.path, got, c.allow)
+		}
+	}
+}
+
+func TestServeTooLarge(t *testing.T) {
+	s := test_server(t)
+	s.MaxSize = 100
+	h := s.handler()
+	raw := test_upload(t)
+
+	// with a Content-Length and chunked, where MaxBytesReader stops the read
+	for _, length := range []int64{int64(len(raw)), -1} {
+		for _, path := range []string{"/render", "/validate"} {
+			req := httptest.NewRequest(http.MethodPost, path, io.NopCloser(bytes.NewReader(raw)))
+			req.ContentLength = length
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != http.StatusRequestEntityTooLarge {
+				t.Errorf("%s length %d: status %d, want 413", path, length, rec.Code)
+			}
+		}
+	}
+}
+
+func TestServeValidate(t *testing.T) {
+	h := test_server(t).handler()
+	req := httptest.NewRequest(http.MethodPost, "/validate?lang=nl", bytes.NewReader(test_upload(t)))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type %q", got)
+	}
+	var report MetadataReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.File != current_metadata_file_name || report.Profile != default_profile_name || report.Language != "nl" {
+		t.Errorf("report of %q, profile %q, language %q", report.File, report.Profile, report.Language)
+	}
+	total := 0
+	for _, n := range report.Counts {
+		total += n
+	}
+	if total != len(report.Findings) {
+		t.Errorf("counts add up to %d, %d findings", total, len(report.Findings))
+	}
+	if report.Score.Completeness <= 0 {
+		t.Errorf("completeness %v", report.Score.Completeness)
+	}
+
+	for _, body := range []string{"{", `{"Title": 1}`} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate?lang=xx", bytes.NewReader(test_upload(t))))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown language: status %d, want 400", rec.Code)
+	}
+}
+
+func TestServeLocked(t *testing.T) {
+	s := test_server(t)
+	req := httptest.NewRequest(http.MethodPost, "/validate", nil)
+	ran := false
+	if err := s.locked(req, func() error { ran = true; return nil }); err != nil || !ran {
+		t.Fatalf("locked: %v, ran %v", err, ran)
+	}
+	if err := s.locked(req, func() error { panic("broken") }); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("panic returned as %v", err)
+	}
+
+	// a request that ends while another report is rendered gives up and is not rendered
+	s.render_lock <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	ran = false
+	go func() {
+		done <- s.locked(req.WithContext(ctx), func() error { ran = true; return nil })
+	}()
+	cancel()
+	if err := <-done; err != context.Canceled || ran {
+		t.Errorf("cancelled while waiting: %v, ran %v", err, ran)
+	}
+	<-s.render_lock
+	if err := s.locked(req.WithContext(ctx), func() error { ran = true; return nil }); err != context.Canceled || ran {
+		t.Errorf("cancelled before: %v, ran %v", err, ran)
+	}
+
+	rec := httptest.NewRecorder()
+	s.handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", bytes.NewReader(test_upload(t))).WithContext(ctx))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("cancelled request: status %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,431 @@
+/*
+spreadsheet.go bulk editing of metadata in a spreadsheet. readYmeta export writes a batch of
+metadata files as a CSV or XLSX table with one row per data package: lists and groups are
+flattened into numbered columns (Tag.1, Funding_Reference.2.Award_Number) and the creators and
+contributors are written to a second table with one row per person (a People sheet, or
+<name>.people.csv next to a CSV file). readYmeta import reads such a table back and writes a
+yoda-metadata.json per row, validated with the checks of the PDF report.
+Usage: readYmeta export [-o <file.csv|file.xlsx>] <inputs>
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// first column of both tables, the data package a row belongs to
+const sheet_package_column string = "Package"
+
+// second column of the people table, Creator or Contributor
+const sheet_role_column string = "Role"
+
+// sheet names of an XLSX workbook
+const sheet_packages_name string = "Packages"
+const sheet_people_name string = "People"
+
+// suffix of the people table written next to a CSV file
+const sheet_people_csv_suffix string = ".people.csv"
+
+// the people fields of the metadata, written to the people table
+var sheet_people_fields = []string{"Creator", "Contributor"}
+
+// a cell of a flattened metadata record, Key orders the columns as the fields of the metadata
+type sheet_value struct {
+	Column string
+	Key    []int
+	Value  string
+}
+
+// flatten a metadata value into columns, lists get numbered columns starting at 1
+func flatten_metadata_value(v reflect.Value, column string, key []int, skip []string, out *[]sheet_value) {
+	join := func(name string) string {
+		if column == "" {
+			return name
+		}
+		return column + "." + name
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+			if column == "" && string_in_list(name, skip) {
+				continue
+			}
+			flatten_metadata_value(v.Field(i), join(name), append(append([]int{}, key...), i), nil, out)
+		}
+	case reflect.Ptr:
+		if !v.IsNil() {
+			flatten_metadata_value(v.Elem(), column, key, skip, out)
+		}
+	case reflect.Slice:
+		for j := 0; j < v.Len(); j++ {
+			flatten_metadata_value(v.Index(j), join(fmt.Sprint(j+1)), append(append([]int{}, key...), j), nil, out)
+		}
+	case reflect.String:
+		*out = append(*out, sheet_value{column, key, v.String()})
+	case reflect.Int:
+		value := ""
+		if v.Int() != 0 {
+			value = fmt.Sprint(v.Int())
+		}
+		*out = append(*out, sheet_value{column, key, value})
+	case reflect.Float64:
+		*out = append(*out, sheet_value{column, key, strconv.FormatFloat(v.Float(), 'f', -1, 64)})
+	}
+}
+
+// true when s is one of list
+func string_in_list(s string, list []string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// build a table from flattened records, the columns are the union of all records in field order
+func sheet_table(fixed []string, records [][]sheet_value, fixed_values [][]string) [][]string {
+	keys := make(map[string][]int)
+	for _, rec := range records {
+		for _, v := range rec {
+			keys[v.Column] = v.Key
+		}
+	}
+	var columns []string
+	for c := range keys {
+		columns = append(columns, c)
+	}
+	sort.Slice(columns, func(i, j int) bool {
+		a, b := keys[columns[i]], keys[columns[j]]
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	index := make(map[string]int)
+	for i, c := range columns {
+		index[c] = len(fixed) + i
+	}
+	rows := [][]string{append(append([]string{}, fixed...), columns...)}
+	for r, rec := range records {
+		row := make([]string, len(fixed)+len(columns))
+		copy(row, fixed_values[r])
+		for _, v := range rec {
+			row[index[v.Column]] = v.Value
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// the packages and people tables of a batch of metadata records
+func metadata_sheets(names []string, batch []Yoda18Metadata) []Sheet {
+	var packages, people [][]sheet_value
+	var package_keys, people_keys [][]string
+	for i, data := range batch {
+		var rec []sheet_value
+		flatten_metadata_value(reflect.ValueOf(data), "", nil, sheet_people_fields, &rec)
+		packages = append(packages, rec)
+		package_keys = append(package_keys, []string{names[i]})
+		for _, role := range sheet_people_fields {
+			list := reflect.ValueOf(data).FieldByName(role)
+			for j := 0; j < list.Len(); j++ {
+				var person []sheet_value
+				flatten_metadata_value(list.Index(j), "", nil, nil, &person)
+				people = append(people, person)
+				people_keys = append(people_keys, []string{names[i], role})
+			}
+		}
+	}
+	return []Sheet{
+		{Name: sheet_packages_name, Rows: sheet_table([]string{sheet_package_column}, packages, package_keys)},
+		{Name: sheet_people_name, Rows: sheet_table([]string{sheet_package_column, sheet_role_column}, people, people_keys)},
+	}
+}
+
+// the struct field indices and list positions of a column such as Creator.2.Affiliation.1
+func metadata_column_path(column string) ([]int, []int, error) {
+	var index, positions []int
+	t := reflect.TypeOf(Yoda18Metadata{})
+	for _, part := range strings.Split(column, ".") {
+		if n, err := strconv.Atoi(part); err == nil {
+			if t.Kind() != reflect.Slice || n < 1 {
+				return nil, nil, fmt.Errorf("unknown column %s", column)
+			}
+			positions = append(positions, n-1)
+			t = t.Elem()
+			continue
+		}
+		for t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		found := false
+		for i := 0; t.Kind() == reflect.Struct && i < t.NumField(); i++ {
+			if strings.Split(t.Field(i).Tag.Get("json"), ",")[0] == part {
+				index = append(index, i)
+				t = t.Field(i).Type
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("unknown column %s", column)
+		}
+	}
+	if t.Kind() == reflect.Struct || ((t.Kind() == reflect.Slice || t.Kind() == reflect.Ptr) && t.Elem().Kind() == reflect.Struct) {
+		return nil, nil, fmt.Errorf("column %s is not a single value", column)
+	}
+	return index, positions, nil
+}
+
+// the metadata of the rows of a packages table and a people table (which may be empty)
+func sheet_metadata(packages [][]string, people [][]string) ([]string, []Yoda18Metadata, []string, error) {
+	var names []string
+	var batch []Yoda18Metadata
+	var ignored []string
+	if len(packages) == 0 {
+		return names, batch, ignored, fmt.Errorf("the table is empty")
+	}
+	byname := make(map[string]int)
+	header := packages[0]
+	for r, row := range packages[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+		var data Yoda18Metadata
+		name := fmt.Sprintf("row-%d", r+2)
+		for c, value := range row {
+			if c >= len(header) || strings.TrimSpace(value) == "" {
+				continue
+			}
+			column := strings.TrimSpace(header[c])
+			if column == sheet_package_column {
+				name = strings.TrimSpace(value)
+				continue
+			}
+			index, positions, err := metadata_column_path(column)
+			if err != nil {
+				if !string_in_list(column, ignored) {
+					ignored = append(ignored, column)
+				}
+				continue
+			}
+			if err = set_metadata_value(reflect.ValueOf(&data).Elem(), index, positions, strings.TrimSpace(value)); err != nil {
+				return names, batch, ignored, fmt.Errorf("row %d, %s: %w", r+2, column, err)
+			}
+		}
+		if _, ok := byname[name]; ok {
+			return names, batch, ignored, fmt.Errorf("row %d: package %s is listed more than once", r+2, name)
+		}
+		byname[name] = len(batch)
+		names = append(names, name)
+		batch = append(batch, data)
+	}
+
+	// people are appended to the package of their row in the order of the table
+	if len(people) > 0 {
+		header := people[0]
+		for r, row := range people[1:] {
+			if strings.TrimSpace(strings.Join(row, "")) == "" {
+				continue
+			}
+			var name, role string
+			for c, value := range row {
+				if c < len(header) && strings.TrimSpace(header[c]) == sheet_package_column {
+					name = strings.TrimSpace(value)
+				}
+				if c < len(header) && strings.TrimSpace(header[c]) == sheet_role_column {
+					role = strings.TrimSpace(value)
+				}
+			}
+			i, ok := byname[name]
+			if !ok {
+				return names, batch, ignored, fmt.Errorf("people row %d: unknown package %q", r+2, name)
+			}
+			if !string_in_list(role, sheet_people_fields) {
+				return names, batch, ignored, fmt.Errorf("people row %d: role %q is not one of %s", r+2, role, strings.Join(sheet_people_fields, ", "))
+			}
+			position := reflect.ValueOf(batch[i]).FieldByName(role).Len() + 1
+			for c, value := range row {
+				column := ""
+				if c < len(header) {
+					column = strings.TrimSpace(header[c])
+				}
+				if column == sheet_package_column || column == sheet_role_column || strings.TrimSpace(value) == "" {
+					continue
+				}
+				index, positions, err := metadata_column_path(fmt.Sprintf("%s.%d.%s", role, position, column))
+				if err != nil {
+					if !string_in_list(column, ignored) {
+						ignored = append(ignored, column)
+					}
+					continue
+				}
+				if err = set_metadata_value(reflect.ValueOf(&batch[i]).Elem(), index, positions, strings.TrimSpace(value)); err != nil {
+					return names, batch, ignored, fmt.Errorf("people row %d, %s: %w", r+2, column, err)
+				}
+			}
+		}
+	}
+
+	// cells left empty in the middle of numbered columns leave empty list entries
+	for i := range batch {
+		var removed []TextChange
+		remove_empty_entries(reflect.ValueOf(&batch[i]).Elem(), "", &removed)
+	}
+	return names, batch, ignored, nil
+}
+
+// write a table as CSV, with a byte order mark so that Excel recognises UTF-8
+func write_csv_table(fname string, rows [][]string) error {
+	var buf bytes.Buffer
+	buf.WriteString("\ufeff")
+	w := csv.NewWriter(&buf)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	return os.WriteFile(fname, buf.Bytes(), 0644)
+}
+
+// read a CSV table, a byte order mark is skipped and rows may have different lengths
+func read_csv_table(fname string) ([][]string, error) {
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(raw, []byte("\ufeff"))))
+	r.FieldsPerRecord = -1
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	return rows, nil
+}
+
+// a spreadsheet file is recognised by its extension
+func is_sheet_file(fname string) bool {
+	ext := strings.ToLower(filepath.Ext(fname))
+	return ext == ".csv" || ext == ".xlsx"
+}
+
+// the people table of a CSV packages table
+func people_csv_file_name(fname string) string {
+	return strings.TrimSuffix(fname, filepath.Ext(fname)) + sheet_people_csv_suffix
+}
+
+// readYmeta export: write a batch of metadata inputs as a CSV or XLSX table
+func export_command(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	output_flag := fs.String("o", filepath.Join("output", "metadata.xlsx"), "spreadsheet file, .xlsx or .csv (the people are written to <name>"+sheet_people_csv_suffix+")")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: readYmeta export [options] <yoda metadata input> ...")
+		fs.PrintDefaults()
+	}
+	errcntrl(fs.Parse(args))
+	if fs.NArg() == 0 || !is_sheet_file(*output_flag) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	var names []string
+	var batch []Yoda18Metadata
+	for _, arg := range fs.Args() {
+		source, err := open_input_source(arg)
+		errcntrl(err)
+		data, name, err := source.Read()
+		errcntrl(err)
+		fmt.Println("Input metadata:", name)
+		names = append(names, filepath.ToSlash(source.OutputBase()))
+		batch = append(batch, data)
+	}
+	sheets := metadata_sheets(names, batch)
+
+	errcntrl(os.MkdirAll(filepath.Dir(*output_flag), os.ModePerm))
+	if strings.ToLower(filepath.Ext(*output_flag)) == ".xlsx" {
+		errcntrl(write_xlsx(*output_flag, sheets))
+	} else {
+		errcntrl(write_csv_table(*output_flag, sheets[0].Rows))
+		errcntrl(write_csv_table(people_csv_file_name(*output_flag), sheets[1].Rows))
+		fmt.Println("People written to:", people_csv_file_name(*output_flag))
+	}
+	fmt.Printf("%d data packages written to: %s\n", len(batch), *output_flag)
+}
+
+// import a CSV or XLSX table: a yoda-metadata.json per row in <outdir>/<package>/, validated with the profile
+func import_sheet(fname string, outdir string, profile Profile, skip_errors bool) {
+	var packages, people [][]string
+	if strings.ToLower(filepath.Ext(fname)) == ".xlsx" {
+		sheets, err := read_xlsx(fname)
+		errcntrl(err)
+		if len(sheets) == 0 {
+			errcntrl(fmt.Errorf("%s has no sheets", fname))
+		}
+		packages = sheets[0].Rows
+		for _, s := range sheets[1:] {
+			if strings.EqualFold(s.Name, sheet_people_name) {
+				people = s.Rows
+			}
+		}
+	} else {
+		var err error
+		packages, err = read_csv_table(fname)
+		errcntrl(err)
+		if _, err := os.Stat(people_csv_file_name(fname)); err == nil {
+			people, err = read_csv_table(people_csv_file_name(fname))
+			errcntrl(err)
+		}
+	}
+	names, batch, ignored, err := sheet_metadata(packages, people)
+	errcntrl(err)
+	for _, c := range ignored {
+		fmt.Println("Ignoring column:", c)
+	}
+
+	var written, skipped int
+	for i, data := range batch {
+		// the package name is used as a path below the output directory, it cannot point outside it
+		dir := filepath.Join(outdir, filepath.FromSlash(strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(names[i])), "/")))
+		output_file_name := filepath.Join(dir, current_metadata_file_name)
+		REPORT_LANGUAGE = select_language(language_auto, data)
+		report := create_metadata_report(data, output_file_name, profile)
+		print_validation_summary(names[i], report)
+		if skip_errors && report.Counts[SeverityError] > 0 {
+			skipped++
+			continue
+		}
+		errcntrl(os.MkdirAll(dir, os.ModePerm))
+		errcntrl(write_metadata_file(data, output_file_name))
+		written++
+	}
+	fmt.Printf("%d metadata files written to: %s", written, outdir)
+	if skipped > 0 {
+		fmt.Printf(", %d skipped because of errors", skipped)
+	}
+	fmt.Println()
+}
+
+// the finding counts of a report and its errors
+func print_validation_summary(name string, report MetadataReport) {
+	fmt.Printf("%s: %d errors, %d warnings, %d info, %.1f%% complete\n", name,
+		report.Counts[SeverityError], report.Counts[SeverityWarning], report.Counts[SeverityInfo], report.Score.Completeness)
+	for _, f := range report.Findings {
+		if f.Severity == SeverityError {
+			fmt.Printf("  %s: %s\n", f.Field, f.Message)
+		}
+	}
+}
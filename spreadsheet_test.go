@@ -0,0 +1,184 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the values as a table holds them: trimmed, empty lists are left out
+func sheet_canonical(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(strings.TrimSpace(v.String()))
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			sheet_canonical(v.Field(i))
+		}
+	case reflect.Slice:
+		if v.Len() == 0 {
+			v.Set(reflect.Zero(v.Type()))
+		}
+		for i := 0; i < v.Len(); i++ {
+			sheet_canonical(v.Index(i))
+		}
+	}
+}
+
+// the metadata of the test data as exported, without the empty entries a table cannot hold
+func test_sheet_batch(t *testing.T) ([]string, []Yoda18Metadata) {
+	var names []string
+	var batch []Yoda18Metadata
+	for _, name := range []string{"yoda-metadata", "yoda-metadata[douwe]", "yoda-metadata[unicode]", "yoda-metadata[uu011]"} {
+		source, err := open_input_source(filepath.Join("test-data", name+".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _, err := source.Read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var removed []TextChange
+		remove_empty_entries(reflect.ValueOf(&data).Elem(), "", &removed)
+		sheet_canonical(reflect.ValueOf(&data).Elem())
+		names = append(names, name)
+		batch = append(batch, data)
+	}
+	return names, batch
+}
+
+func check_sheet_batch(t *testing.T, format string, names []string, batch []Yoda18Metadata, got_names []string, got []Yoda18Metadata) {
+	if !reflect.DeepEqual(got_names, names) {
+		t.Errorf("%s: packages %v, want %v", format, got_names, names)
+	}
+	if len(got) != len(batch) {
+		t.Fatalf("%s: %d packages, want %d", format, len(got), len(batch))
+	}
+	for i := range batch {
+		if !reflect.DeepEqual(got[i], batch[i]) {
+			t.Errorf("%s: %s does not survive the round trip:\n%+v\nwant\n%+v", format, names[i], got[i], batch[i])
+		}
+	}
+}
+
+func TestSheetRoundTripXlsx(t *testing.T) {
+	names, batch := test_sheet_batch(t)
+	fname := filepath.Join(t.TempDir(), "metadata.xlsx")
+	if err := write_xlsx(fname, metadata_sheets(names, batch)); err != nil {
+		t.Fatal(err)
+	}
+	sheets, err := read_xlsx(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sheets) != 2 || sheets[0].Name != sheet_packages_name || sheets[1].Name != sheet_people_name {
+		t.Fatalf("sheets %v", sheets)
+	}
+	got_names, got, ignored, err := sheet_metadata(sheets[0].Rows, sheets[1].Rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ignored) != 0 {
+		t.Errorf("ignored columns %v", ignored)
+	}
+	check_sheet_batch(t, "xlsx", names, batch, got_names, got)
+}
+
+func TestSheetRoundTripCsv(t *testing.T) {
+	names, batch := test_sheet_batch(t)
+	sheets := metadata_sheets(names, batch)
+	fname := filepath.Join(t.TempDir(), "metadata.csv")
+	if err := write_csv_table(fname, sheets[0].Rows); err != nil {
+		t.Fatal(err)
+	}
+	if err := write_csv_table(people_csv_file_name(fname), sheets[1].Rows); err != nil {
+		t.Fatal(err)
+	}
+	packages, err := read_csv_table(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	people, err := read_csv_table(people_csv_file_name(fname))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(packages, sheets[0].Rows) || !reflect.DeepEqual(people, sheets[1].Rows) {
+		t.Error("the CSV tables read back differ from the tables written")
+	}
+	got_names, got, _, err := sheet_metadata(packages, people)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_sheet_batch(t, "csv", names, batch, got_names, got)
+}
+
+func TestSheetMetadata(t *testing.T) {
+	packages := [][]string{
+		{"Package", "Title", "Tag.1", "Tag.3", "Retention_Period", "Notes", "Creator.1.Name"},
+		{"a", " A ", "x", "z", "10", "ignored", "Jan"},
+		{"", "", "", "", "", "", ""},
+		{"", "B"},
+	}
+	people := [][]string{
+		{"Package", "Role", "Name.Given_Name", "Affiliation.1", "Person_Identifier.1.Name_Identifier"},
+		{"a", "Creator", "Jan", "VU", "0000-0002-1825-0097"},
+		{"a", "Contributor", "Piet"},
+		{"a", "Creator", "Ann"},
+	}
+	names, batch, ignored, err := sheet_metadata(packages, people)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "row-4"}) {
+		t.Errorf("packages %v", names)
+	}
+	if !reflect.DeepEqual(ignored, []string{"Notes", "Creator.1.Name"}) {
+		t.Errorf("ignored columns %v", ignored)
+	}
+	a := batch[0]
+	if a.Title != "A" || !reflect.DeepEqual(a.Tag, []string{"x", "z"}) || a.RetentionPeriod != 10 {
+		t.Errorf("package a: %+v", a)
+	}
+	if len(a.Creator) != 2 || a.Creator[0].Name.GivenName != "Jan" || a.Creator[1].Name.GivenName != "Ann" ||
+		a.Creator[0].PersonIdentifier[0].NameIdentifier != "0000-0002-1825-0097" || len(a.Contributor) != 1 {
+		t.Errorf("people of package a: %+v %+v", a.Creator, a.Contributor)
+	}
+
+	for _, c := range []struct {
+		name     string
+		packages [][]string
+		people   [][]string
+		err      string
+	}{
+		{"empty", nil, nil, "empty"},
+		{"duplicate package", [][]string{{"Package"}, {"a"}, {"a"}}, nil, "more than once"},
+		{"not a number", [][]string{{"Package", "Retention_Period"}, {"a", "ten"}}, nil, "Retention_Period"},
+		{"unknown package", [][]string{{"Package"}, {"a"}}, [][]string{{"Package", "Role"}, {"b", "Creator"}}, "unknown package"},
+		{"unknown role", [][]string{{"Package"}, {"a"}}, [][]string{{"Package", "Role"}, {"a", "Funder"}}, "role"},
+	} {
+		_, _, _, err := sheet_metadata(c.packages, c.people)
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("%s: error %v, want %q", c.name, err, c.err)
+		}
+	}
+}
+
+func TestXlsxColumns(t *testing.T) {
+	for _, c := range []struct {
+		index int
+		name  string
+	}{
+		{0, "A"}, {25, "Z"}, {26, "AA"}, {27, "AB"}, {51, "AZ"}, {52, "BA"}, {701, "ZZ"}, {702, "AAA"},
+	} {
+		if got := xlsx_column_name(c.index); got != c.name {
+			t.Errorf("xlsx_column_name(%d) = %s, want %s", c.index, got, c.name)
+		}
+		if got := xlsx_column_index(c.name + "12"); got != c.index {
+			t.Errorf("xlsx_column_index(%s12) = %d, want %d", c.name, got, c.index)
+		}
+	}
+	if got := xlsx_escape("a<b> & \"c\"\x01\td"); got != "a&lt;b&gt; &amp; &#34;c&#34;&#x9;d" {
+		t.Errorf("xlsx_escape = %q", got)
+	}
+}
@@ -0,0 +1,261 @@
+/*
+tables.go table layout for creators, contributors and funding. Long lists of people are written
+as a table (name, role, affiliations, identifiers, issues) instead of a stack of rows per person.
+The layout switches to tables automatically above -table-above entries, or is fixed with -layout.
+Table headers are repeated at the top of every page a table runs onto, and a row that is longer
+than a page is continued on the next page. Maroto's TableList is not used as it writes all cells
+in one colour (the issues and placeholders are coloured by severity here), takes a single string
+per cell, does not repeat its header and lets a row that is longer than a page run off the page.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+// -layout values
+const (
+	layout_auto  string = "auto"
+	layout_rows  string = "rows"
+	layout_table string = "table"
+)
+
+// height of a line of table text and the padding of a table row in mm
+const table_line_height float64 = 3.6
+const table_padding float64 = 2
+
+// page height in mm taken by the page header, footer and table header, not available to a table row
+const table_reserved_height float64 = 40
+
+// header of the table being written, repeated by the page header when the table runs onto a new page
+var table_header_repeat func()
+
+// a table cell: lines of text in one colour
+type table_cell struct {
+	Lines  []string
+	Colour color.Color
+}
+
+// check a -layout value
+func check_layout(layout string) error {
+	switch layout {
+	case layout_auto, layout_rows, layout_table:
+		return nil
+	}
+	return fmt.Errorf("unknown layout \"%s\", use %s, %s or %s", layout, layout_auto, layout_rows, layout_table)
+}
+
+// write a list of n entries as a table
+func use_table_layout(n int) bool {
+	switch *layout_flag {
+	case layout_table:
+		return true
+	case layout_rows:
+		return false
+	}
+	return n > *table_above_flag
+}
+
+// a cell with normal text
+func text_cell(lines ...string) table_cell {
+	return table_cell{Lines: lines, Colour: pdfBlack()}
+}
+
+// a cell with a single value, a missing value is shown as placeholder in the warning colour
+func value_cell(value string, placeholder string) table_cell {
+	if is_blank(value) {
+		return table_cell{Lines: []string{placeholder}, Colour: pdfWarningColour()}
+	}
+	return text_cell(strings.TrimSpace(value))
+}
+
+// the findings about one entry, e.g. "Creator[2]", as a cell in the colour of the most severe finding
+func issues_cell(findings []Finding, field string) table_cell {
+	cell := text_cell()
+	for _, sev := range marker_severities {
+		for _, f := range findings {
+			if f.Severity == sev && (f.Field == field || strings.HasPrefix(f.Field, field+".")) {
+				if len(cell.Lines) == 0 {
+					cell.Colour = severity_rgb(sev)
+				}
+				cell.Lines = append(cell.Lines, tr("severity."+string(sev))+": "+f.Message)
+			}
+		}
+	}
+	return cell
+}
+
+// identifiers as "(scheme) identifier", unresolvable identifiers are marked
+func identifier_lines(schemes []string, identifiers []string) []string {
+	var lines []string
+	for i := range schemes {
+		line := fmt.Sprintf("(%s) %s", strings.TrimSpace(schemes[i]), strings.TrimSpace(identifiers[i]))
+		if _, ok := identifier_url(schemes[i], identifiers[i]); !ok {
+			line += tr("link.unresolved")
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// write a table, rows are as high as their longest cell and the header is repeated on every new page
+func pdf_write_table(m pdf.Maroto, header []string, grid []uint, rows [][]table_cell, rowheight float64) {
+	size := fontsize - 1
+	write_header := func() {
+		m.Row(rowheight+1, func() {
+			for i, h := range header {
+				m.Col(grid[i], func() {
+					m.Text(h, props.Text{Size: size, Style: consts.Bold, Color: pdfBlack()})
+				})
+			}
+		})
+		m.Line(1)
+	}
+	write_header()
+	table_header_repeat = write_header
+	defer func() { table_header_repeat = nil }()
+
+	// rows that do not fit on a page are written in parts of a page each
+	_, pageheight := m.GetPageSize()
+	_, top, _, _ := m.GetPageMargins()
+	maxlines := int((pageheight - 2*top - table_reserved_height) / table_line_height)
+
+	for _, row := range rows {
+		wrapped := make([][]string, len(row))
+		lines := 1
+		mark := pdfBlack()
+		for i, cell := range row {
+			for _, l := range cell.Lines {
+				wrapped[i] = append(wrapped[i], wrap_text(m, l, grid_width(m, grid[i])-table_padding, size, consts.Normal)...)
+			}
+			if len(wrapped[i]) > lines {
+				lines = len(wrapped[i])
+			}
+			if sev, ok := colour_severity(cell.Colour); ok {
+				if current, marked := colour_severity(mark); !marked || severity_rank(sev) < severity_rank(current) {
+					mark = cell.Colour
+				}
+			}
+		}
+		for first := 0; first < lines; first += maxlines {
+			last := first + maxlines
+			if last > lines {
+				last = lines
+			}
+			height := float64(last-first)*table_line_height + table_padding
+			m.Row(height, func() {
+				for i := range row {
+					m.Col(grid[i], func() {
+						for j := first; j < last && j < len(wrapped[i]); j++ {
+							m.Text(wrapped[i][j], props.Text{Top: table_padding/2 + float64(j-first)*table_line_height, Size: size, Extrapolate: true, Color: row[i].Colour})
+						}
+					})
+				}
+			})
+			pdf_mark_row(m, mark, height)
+		}
+		m.Line(1)
+	}
+}
+
+// position of a severity in marker_severities, the most severe first
+func severity_rank(sev Severity) int {
+	for i, s := range marker_severities {
+		if s == sev {
+			return i
+		}
+	}
+	return len(marker_severities)
+}
+
+// creators as a table
+func pdf_write_creators_table(m pdf.Maroto, data Yoda18Metadata, findings []Finding, rowheight float64, colwidth uint) {
+	pdf_bookmark("label.creators", 0)
+	pdf_write_row(m, tr("label.creators"), rowheight, colwidth, consts.Bold, pdfBlack())
+	var rows [][]table_cell
+	for i, c := range data.Creator {
+		var schemes, identifiers []string
+		for _, pid := range c.PersonIdentifier {
+			schemes = append(schemes, pid.NameIdentifierScheme)
+			identifiers = append(identifiers, pid.NameIdentifier)
+		}
+		rows = append(rows, []table_cell{
+			person_name_cell(c.Name.GivenName, c.Name.FamilyName),
+			text_cell(tr("role.Creator")),
+			text_cell(c.Affiliation...),
+			text_cell(identifier_lines(schemes, identifiers)...),
+			issues_cell(findings, fmt.Sprintf("Creator[%d]", i+1)),
+		})
+	}
+	pdf_write_table(m, people_table_header(), people_table_grid(), rows, rowheight)
+}
+
+// contributors as a table
+func pdf_write_contributors_table(m pdf.Maroto, data Yoda18Metadata, findings []Finding, rowheight float64, colwidth uint) {
+	pdf_bookmark("label.contributors", 0)
+	pdf_write_row(m, tr("label.contributors"), rowheight, colwidth, consts.Bold, pdfBlack())
+	var rows [][]table_cell
+	for i, c := range data.Contributor {
+		var schemes, identifiers []string
+		for _, pid := range c.PersonIdentifier {
+			schemes = append(schemes, pid.NameIdentifierScheme)
+			identifiers = append(identifiers, pid.NameIdentifier)
+		}
+		rows = append(rows, []table_cell{
+			person_name_cell(c.Name.GivenName, c.Name.FamilyName),
+			value_cell(c.ContributorType, tr("missing.contributor_type")),
+			text_cell(c.Affiliation...),
+			text_cell(identifier_lines(schemes, identifiers)...),
+			issues_cell(findings, fmt.Sprintf("Contributor[%d]", i+1)),
+		})
+	}
+	pdf_write_table(m, people_table_header(), people_table_grid(), rows, rowheight)
+}
+
+// funding references as a table
+func pdf_write_funding_table(m pdf.Maroto, data Yoda18Metadata, findings []Finding, rowheight float64, colwidth uint) {
+	pdf_bookmark("label.funding", 0)
+	pdf_write_row(m, tr("label.funding_references"), rowheight, colwidth, consts.Bold, pdfBlack())
+	var rows [][]table_cell
+	for i, f := range data.FundingReference {
+		rows = append(rows, []table_cell{
+			value_cell(f.FunderName, tr("missing.funder_name")),
+			value_cell(f.AwardNumber, nullstring),
+			issues_cell(findings, fmt.Sprintf("Funding_Reference[%d]", i+1)),
+		})
+	}
+	pdf_write_table(m, []string{tr("table.funder"), tr("table.award_number"), tr("table.issues")}, []uint{5, 3, 4}, rows, rowheight)
+}
+
+// name of a person, missing name parts are shown as placeholders in the warning colour
+func person_name_cell(given string, family string) table_cell {
+	cell := text_cell()
+	if is_blank(given) {
+		given = tr("missing.given_name")
+		cell.Colour = pdfWarningColour()
+	}
+	if is_blank(family) {
+		family = tr("missing.family_name")
+		cell.Colour = pdfWarningColour()
+	}
+	cell.Lines = []string{strings.TrimSpace(given) + " " + strings.TrimSpace(family)}
+	return cell
+}
+
+// header of the creator and contributor tables
+func people_table_header() []string {
+	return []string{tr("table.name"), tr("table.role"), tr("table.affiliations"), tr("table.identifiers"), tr("table.issues")}
+}
+
+// column widths of the creator and contributor tables
+func people_table_grid() []uint {
+	return []uint{2, 2, 2, 3, 3}
+}
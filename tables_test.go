@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+func TestWriteTableLongRow(t *testing.T) {
+	m, err := setup_fonts(pdf.NewMaroto(consts.Portrait, consts.A4), font_name_core, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetCompression(false)
+	var affiliations []string
+	for i := 1; i <= 150; i++ {
+		affiliations = append(affiliations, fmt.Sprintf("Affiliation %d", i))
+	}
+	rows := [][]table_cell{
+		{text_cell("Ada Lovelace"), text_cell(affiliations...)},
+		{text_cell("Charles Babbage"), text_cell("Cambridge")},
+	}
+	pdf_write_table(m, []string{"Name", "Affiliations"}, []uint{4, 8}, rows, 5)
+	if m.GetCurrentPage() == 0 {
+		t.Error("a row longer than a page was written on one page")
+	}
+
+	out, err := m.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, text := range []string{"(Affiliation 1)", "(Affiliation 150)", "(Charles Babbage)"} {
+		if !bytes.Contains(out.Bytes(), []byte(text)) {
+			t.Errorf("%s is not in the PDF", text)
+		}
+	}
+	if bytes.Contains(out.Bytes(), []byte("(...)")) {
+		t.Error("a cell was cut off")
+	}
+}
@@ -0,0 +1,267 @@
+/*
+template.go custom reports from Go templates. A report template is a text/template file, or an
+html/template file when its name ends in .html.tmpl, that is given the metadata (.Metadata) and
+the validation findings and scores (.Report) together with helper functions to join names, link
+identifiers, format dates, look up the severity of the findings for a field, count findings and
+list the timeline of the data package with the dates that do not fit together.
+The Markdown report is written with the builtin readme template, a templates/readme.md.tmpl in the
+current directory replaces it. Extra reports are selected with -template <name|path>,... and are
+written to <name>.<template name>.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// templates shipped with readYmeta, selectable by name
+//
+//go:embed templates/*.tmpl
+var builtin_templates embed.FS
+
+const template_extension string = ".tmpl"
+
+// template of the Markdown report
+const default_template_name string = "readme"
+
+// source of a report template, Name is the file name without .tmpl, e.g. summary.html
+type ReportTemplate struct {
+	Name   string
+	Source string
+}
+
+// the values a report template is executed with
+type TemplateData struct {
+	Metadata Yoda18Metadata
+	Report   MetadataReport
+}
+
+// template of the Markdown report and the -template reports, set in main
+var MARKDOWN_TEMPLATE ReportTemplate
+var REPORT_TEMPLATES []ReportTemplate
+
+// a parsed text or HTML template
+type template_executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// load a template by path, by name from ./templates or by name from the builtin templates, a
+// name may leave out the output extension (readme for readme.md.tmpl)
+func load_report_template(name_or_path string) (ReportTemplate, error) {
+	// an existing file always wins
+	if info, err := os.Stat(name_or_path); err == nil && !info.IsDir() {
+		src, err := os.ReadFile(name_or_path)
+		if err != nil {
+			return ReportTemplate{}, err
+		}
+		return ReportTemplate{Name: report_template_name(name_or_path), Source: string(src)}, nil
+	}
+
+	// a name, try the local templates directory first
+	if entries, err := os.ReadDir("templates"); err == nil {
+		for _, e := range entries {
+			if !e.IsDir() && template_name_matches(e.Name(), name_or_path) {
+				src, err := os.ReadFile(filepath.Join("templates", e.Name()))
+				if err != nil {
+					return ReportTemplate{}, err
+				}
+				return ReportTemplate{Name: report_template_name(e.Name()), Source: string(src)}, nil
+			}
+		}
+	}
+
+	// fall back on the templates compiled into readYmeta
+	entries, _ := builtin_templates.ReadDir("templates")
+	for _, e := range entries {
+		if template_name_matches(e.Name(), name_or_path) {
+			src, err := builtin_templates.ReadFile("templates/" + e.Name())
+			if err != nil {
+				return ReportTemplate{}, err
+			}
+			return ReportTemplate{Name: report_template_name(e.Name()), Source: string(src)}, nil
+		}
+	}
+	return ReportTemplate{}, fmt.Errorf("unknown template \"%s\", available templates: %s", name_or_path,
+		strings.Join(list_builtin_templates(), ", "))
+}
+
+// template name of a file: the base name without .tmpl
+func report_template_name(fname string) string {
+	return strings.TrimSuffix(filepath.Base(fname), template_extension)
+}
+
+// whether a template file has the given name, with or without its output extension
+func template_name_matches(fname string, name string) bool {
+	if !strings.HasSuffix(fname, template_extension) {
+		return false
+	}
+	base := report_template_name(fname)
+	return base == name || strings.TrimSuffix(base, filepath.Ext(base)) == name
+}
+
+// names of the builtin templates
+func list_builtin_templates() []string {
+	var names []string
+	entries, _ := builtin_templates.ReadDir("templates")
+	for _, e := range entries {
+		names = append(names, report_template_name(e.Name()))
+	}
+	return names
+}
+
+// whether the template writes HTML and its output has to be escaped as such
+func (t ReportTemplate) is_html() bool {
+	ext := strings.ToLower(filepath.Ext(t.Name))
+	return ext == ".html" || ext == ".htm"
+}
+
+// parse the template, the field helpers look up the findings of report
+func (t ReportTemplate) parse(report MetadataReport) (template_executor, error) {
+	funcs := template_functions(report)
+	if t.is_html() {
+		return htmltemplate.New(t.Name).Funcs(htmltemplate.FuncMap(funcs)).Parse(t.Source)
+	}
+	return texttemplate.New(t.Name).Funcs(texttemplate.FuncMap(funcs)).Parse(t.Source)
+}
+
+// execute the template for the metadata and its report
+func (t ReportTemplate) render(data Yoda18Metadata, report MetadataReport) (string, error) {
+	return t.execute(TemplateData{Metadata: data, Report: report}, report)
+}
+
+// execute the template with other values than a metadata report, e.g. the diff change list
+func (t ReportTemplate) execute(values interface{}, report MetadataReport) (string, error) {
+	tmpl, err := t.parse(report)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, values); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// the helper functions available in report templates
+func template_functions(report MetadataReport) map[string]interface{} {
+	return map[string]interface{}{
+		"tr":             tr,
+		"join":           func(sep string, list []string) string { return strings.Join(list, sep) },
+		"person_name":    person_name,
+		"join_names":     join_names,
+		"orcid_url":      orcid_url,
+		"identifier_url": identifier_link,
+		"md_identifier":  md_identifier,
+		"license_url":    license_link,
+		"format_date":    format_date,
+		"md_geolocation": md_geolocation,
+		"findings":       func(field string) []Finding { return field_findings(report, field) },
+		"severity":       func(field string) Severity { return field_severity(report, field) },
+		"count":          func(severity string) int { return report.Counts[Severity(severity)] },
+		"timeline":       timeline_entries,
+		"timeline_notes": func(data Yoda18Metadata) []timeline_problem { return timeline_problems(data, time.Now()) },
+	}
+}
+
+// "Given Family" of a creator or contributor
+func person_name(person interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(person))
+	if v.Kind() != reflect.Struct || !v.FieldByName("Name").IsValid() {
+		return ""
+	}
+	name := v.FieldByName("Name")
+	return Person{GivenName: name.FieldByName("GivenName").String(), FamilyName: name.FieldByName("FamilyName").String()}.name()
+}
+
+// the names of a list of creators or contributors, separated by commas
+func join_names(people interface{}) string {
+	v := reflect.ValueOf(people)
+	if v.Kind() != reflect.Slice {
+		return ""
+	}
+	var names []string
+	for i := 0; i < v.Len(); i++ {
+		if name := person_name(v.Index(i).Interface()); name != "" {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// URL of an identifier, empty if it cannot be resolved
+func identifier_link(scheme string, identifier string) string {
+	link, _ := identifier_url(scheme, identifier)
+	return link
+}
+
+// URL of a licence, empty if it is not in the vocabulary
+func license_link(license string) string {
+	link, _ := license_url(license)
+	return link
+}
+
+// https://orcid.org/ URL of an ORCID iD, empty if it is not a valid ORCID iD
+func orcid_url(identifier string) string {
+	return identifier_link("ORCID", identifier)
+}
+
+// format a date with a Go time layout (e.g. "2 January 2006"), dates that cannot be read are
+// returned as they are
+func format_date(layout string, date string) string {
+	if t, ok := parse_date(date); ok {
+		return t.Format(layout)
+	}
+	return date
+}
+
+// the findings for a field and the fields below it, e.g. Creator covers Creator[1].Name
+func field_findings(report MetadataReport, field string) []Finding {
+	var out []Finding
+	for _, f := range report.Findings {
+		if f.Field == field || strings.HasPrefix(f.Field, field+".") || strings.HasPrefix(f.Field, field+"[") {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// the most severe finding for a field, empty if there are none
+func field_severity(report MetadataReport, field string) Severity {
+	findings := field_findings(report, field)
+	for _, sev := range marker_severities {
+		for _, f := range findings {
+			if f.Severity == sev {
+				return sev
+			}
+		}
+	}
+	return ""
+}
+
+// load the -template reports and check that they parse
+func load_report_templates(names []string) ([]ReportTemplate, error) {
+	var templates []ReportTemplate
+	for _, name := range names {
+		t, err := load_report_template(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = t.parse(MetadataReport{}); err != nil {
+			return nil, fmt.Errorf("template %s: %w", name, err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, nil
+}
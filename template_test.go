@@ -0,0 +1,70 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHtmlReportTimeline(t *testing.T) {
+	report, err := load_report_template("report.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := test_metadata(t, `{"Title": "<Dates>", "Collected": {"Start_Date": "2022-08-03", "End_Date": "2022-08-02"}, "Retention_Period": 10}`)
+	out, err := report.render(data, MetadataReport{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"<title>&lt;Dates&gt;</title>",
+		"<td>2022-08-03 &ndash; 2022-08-02</td>",
+		"<td>2032-08-02</td>",
+		`<li class="error">` + tr("timeline.ends_before_start", tr("label.collected")) + "</li>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report does not contain %s", want)
+		}
+	}
+}
+
+func TestReportUnresolvedIdentifiers(t *testing.T) {
+	data := test_metadata(t, `{
+		"Creator": [{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"},
+			"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}, {"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "ada"}]}],
+		"Contributor": [{"Name": {"Given_Name": "Bob", "Family_Name": "Jones"}, "Contributor_Type": "Editor",
+			"Person_Identifier": [{"Name_Identifier_Scheme": "ISNI", "Name_Identifier": "bob"}]}],
+		"Related_Datapackage": [{"Relation_Type": "IsCitedBy", "Title": "Paper",
+			"Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.5281/zenodo.123"}},
+			{"Relation_Type": "IsPartOf", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "zenodo.456"}}]}`)
+	tests := []struct {
+		template string
+		want     []string
+	}{
+		{"report.html", []string{
+			`<a href="https://orcid.org/0000-0002-1825-0097">`,
+			"(ORCID: ada" + tr("link.unresolved") + ")",
+			"(ISNI: bob" + tr("link.unresolved") + ")",
+			`<a href="https://doi.org/10.5281/zenodo.123">`,
+			"(DOI: zenodo.456" + tr("link.unresolved") + ")",
+		}},
+		{"readme.md", []string{
+			"[0000-0002-1825-0097](https://orcid.org/0000-0002-1825-0097)",
+			"(ORCID: ada" + tr("link.unresolved") + ")",
+		}},
+	}
+	for _, tt := range tests {
+		report, err := load_report_template(tt.template)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, err := report.render(data, MetadataReport{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s does not contain %s", tt.template, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+/*
+textdiff.go line based unified diff, used to show the changes made by readYmeta fix.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// number of unchanged lines shown around a change
+const diff_context_lines int = 3
+
+// a single diff line, op is ' ', '-' or '+'
+type diff_line struct {
+	op   byte
+	text string
+	a, b int
+}
+
+// unified diff of two texts, returns "" when they are equal
+func unified_diff(a string, b string, from_name string, to_name string) string {
+	if a == b {
+		return ""
+	}
+	lines := diff_lines(strings.Split(strings.TrimSuffix(a, "\n"), "\n"), strings.Split(strings.TrimSuffix(b, "\n"), "\n"))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", from_name, to_name)
+
+	// group the changes into hunks with context
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - diff_context_lines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(lines) {
+			if lines[end].op != ' ' {
+				end++
+				continue
+			}
+			// stop when the next change is further away than twice the context
+			next := end
+			for next < len(lines) && lines[next].op == ' ' {
+				next++
+			}
+			if next == len(lines) || next-end > 2*diff_context_lines {
+				break
+			}
+			end = next
+		}
+		stop := end + diff_context_lines
+		if stop > len(lines) {
+			stop = len(lines)
+		}
+
+		var a_start, a_len, b_start, b_len int
+		a_start, b_start = lines[start].a, lines[start].b
+		for _, l := range lines[start:stop] {
+			if l.op != '+' {
+				a_len++
+			}
+			if l.op != '-' {
+				b_len++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", a_start+1, a_len, b_start+1, b_len)
+		for _, l := range lines[start:stop] {
+			fmt.Fprintf(&out, "%c%s\n", l.op, l.text)
+		}
+		i = stop
+	}
+	return out.String()
+}
+
+// longest common subsequence line diff, a and b positions are zero based
+func diff_lines(a []string, b []string) []diff_line {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var lines []diff_line
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diff_line{' ', a[i], i, j})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			lines = append(lines, diff_line{'-', a[i], i, j})
+			i++
+		default:
+			lines = append(lines, diff_line{'+', b[j], i, j})
+			j++
+		}
+	}
+	return lines
+}
@@ -0,0 +1,141 @@
+/*
+textflow.go flowing free text in the PDF. Long text (Description, Remarks, Retention_Information)
+is measured with the PDF font metrics and wrapped into one row per line, so that it breaks
+across pages like any other row. Paragraphs and bullet lists of the source text are preserved.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+// list item markers: bullets, dashes, asterisks and numbers such as "1." or "a)"
+var bullet_pattern = regexp.MustCompile(`^\s*([•·‣◦▪\-–*]|[0-9]{1,3}[.)]|[a-z][)])\s+(.*)$`)
+
+// grid columns used for the bullet of a list item
+const bullet_indent uint = 1
+
+// write free text as flowing rows, blank lines separate paragraphs
+func pdf_write_text_block(m pdf.Maroto, text string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
+	if is_blank(text) {
+		pdf_write_row(m, text, rowheight, colwidth, fontstyle, textcolour)
+		return
+	}
+
+	text = strings.ReplaceAll(strings.TrimSpace(text), "\r\n", "\n")
+	for _, line := range strings.Split(text, "\n") {
+		if is_blank(line) {
+			pdf_write_row_base(m, "  ", rowheight/2, colwidth, consts.Normal, pdfBlack())
+			continue
+		}
+		if b := bullet_pattern.FindStringSubmatch(line); b != nil {
+			for i, l := range wrap_text(m, b[2], grid_width(m, colwidth-bullet_indent), fontsize, fontstyle) {
+				marker := ""
+				if i == 0 {
+					marker = b[1]
+				}
+				pdf_write_text_line(m, marker, l, rowheight, colwidth, fontstyle, textcolour)
+			}
+			continue
+		}
+		for _, l := range wrap_text(m, line, grid_width(m, colwidth), fontsize, fontstyle) {
+			pdf_write_row_base(m, l, rowheight, colwidth, fontstyle, textcolour)
+		}
+	}
+}
+
+// a single wrapped line of a list item, the marker goes in the indent column
+func pdf_write_text_line(m pdf.Maroto, marker string, line string, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
+	m.Row(rowheight, func() {
+		m.Col(bullet_indent, func() {
+			m.Text(marker, props.Text{
+				Size:  fontsize,
+				Style: fontstyle,
+				Color: textcolour,
+				Align: consts.Right,
+			})
+		})
+		m.Col(colwidth-bullet_indent, func() {
+			m.Text(" "+line, props.Text{
+				Size:        fontsize,
+				Extrapolate: true,
+				Style:       fontstyle,
+				Color:       textcolour,
+			})
+		})
+	})
+	pdf_mark_row(m, textcolour, rowheight)
+}
+
+// width in mm of a number of grid columns
+func grid_width(m pdf.Maroto, cols uint) float64 {
+	width, _ := m.GetPageSize()
+	left, _, right, _ := m.GetPageMargins()
+	return (width - left - right) * float64(cols) / consts.MaxGridSum
+}
+
+// split text into lines that fit width (mm), measured with the current font when the document
+// gives access to its font metrics, otherwise estimated from the font size
+func wrap_text(m pdf.Maroto, text string, width float64, size float64, style consts.Style) []string {
+	// average character width is about half the font size, 1pt = 0.3528mm
+	measure := func(s string) float64 {
+		return float64(len([]rune(s))) * size * 0.5 * 0.3528
+	}
+	if pm, ok := base_maroto(m); ok {
+		measure = func(s string) float64 {
+			return text_width(m, pm, s, style, size)
+		}
+	}
+
+	// keep a small margin, Maroto wraps a line itself when it does not fit
+	width = width * 0.98
+
+	var lines []string
+	var current string
+	for _, word := range strings.Fields(text) {
+		// words that do not fit on a line at all (long URLs) are broken up
+		for measure(word) > width && len([]rune(word)) > 1 {
+			r := []rune(word)
+			n := len(r) - 1
+			for n > 1 && measure(string(r[:n])) > width {
+				n--
+			}
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			lines = append(lines, string(r[:n]))
+			word = string(r[n:])
+		}
+		candidate := word
+		if current != "" {
+			candidate = current + " " + word
+		}
+		if current != "" && measure(candidate) > width {
+			lines = append(lines, current)
+			candidate = word
+		}
+		current = candidate
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// core PDF fonts use a single byte encoding, text has to be translated before measuring
+func is_core_font(family string) bool {
+	switch family {
+	case consts.Arial, consts.Helvetica, consts.Symbol, consts.ZapBats, consts.Courier:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+func TestWrapText(t *testing.T) {
+	m := pdf.NewMaroto(consts.Portrait, consts.A4)
+	pm, _ := base_maroto(m)
+	// the width in which text just fits, wrap_text keeps a margin of 2%
+	fits := func(text string) float64 {
+		return text_width(m, pm, text, consts.Normal, fontsize)/0.98 + 0.01
+	}
+	url := "https://example.org/a/very/long/path/without/spaces"
+	tests := []struct {
+		name  string
+		text  string
+		width float64
+		want  []string
+	}{
+		{"empty", "", 100, nil},
+		{"one line", "short text", 100, []string{"short text"}},
+		{"white space", "  short\ttext \n here ", 100, []string{"short text here"}},
+		{"wrapped", "aaa bbb ccc ddd eee", fits("aaa bbb"), []string{"aaa bbb", "ccc ddd", "eee"}},
+		{"exact fit", "aaa bbb ccc", fits("aaa bbb ccc"), []string{"aaa bbb ccc"}},
+		{"long word", "see " + url, fits("https://example.org/"), nil},
+	}
+	for _, tt := range tests {
+		got := wrap_text(m, tt.text, tt.width, fontsize, consts.Normal)
+		if tt.want != nil || tt.text == "" {
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: %q, want %q", tt.name, got, tt.want)
+			}
+			continue
+		}
+		// a word longer than a line is broken up, every piece fits and nothing is lost
+		if len(got) < 3 || got[0] != "see" || strings.Join(got[1:], "") != url {
+			t.Errorf("%s: %q", tt.name, got)
+		}
+		for _, line := range got {
+			if w := text_width(m, pm, line, consts.Normal, fontsize); w > tt.width*0.98 {
+				t.Errorf("%s: %q is %.1f mm wide, more than %.1f mm", tt.name, line, w, tt.width*0.98)
+			}
+		}
+	}
+}
+
+func TestBulletPattern(t *testing.T) {
+	tests := []struct {
+		line   string
+		marker string
+		text   string
+	}{
+		{"- item", "-", "item"},
+		{"  * item", "*", "item"},
+		{"• item", "•", "item"},
+		{"1. first", "1.", "first"},
+		{"12) twelfth", "12)", "twelfth"},
+		{"a) first", "a)", "first"},
+		{"-item", "", ""},
+		{"2023 was a year", "", ""},
+		{"Plain text", "", ""},
+	}
+	for _, tt := range tests {
+		b := bullet_pattern.FindStringSubmatch(tt.line)
+		switch {
+		case tt.marker == "" && b != nil:
+			t.Errorf("%q is a list item %q", tt.line, b[1])
+		case tt.marker != "" && (b == nil || b[1] != tt.marker || b[2] != tt.text):
+			t.Errorf("%q: %q, want %q %q", tt.line, b, tt.marker, tt.text)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+/*
+theme.go report themes, so that Yoda sites can write reports in their own house style. A theme sets
+the logo, header and footer text, fonts, the info/warning/error colour palette, page size and margins.
+Themes are YAML (.yaml/.yml) or TOML (.toml) files and are selected on the command line with
+-theme <name|path>, like rule profiles.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"embed"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"gopkg.in/yaml.v3"
+)
+
+// themes shipped with readYmeta, selectable by name
+//
+//go:embed themes/*.yaml
+var builtin_themes embed.FS
+
+const default_theme_name string = "default"
+
+// height of the header row holding the logo in mm and its width in grid columns
+const theme_logo_height float64 = 12
+const theme_logo_colwidth uint = 3
+
+// Colours as "#rrggbb", an empty colour keeps the readYmeta default
+type ThemeColours struct {
+	Info      string `yaml:"info" toml:"info"`
+	Warning   string `yaml:"warning" toml:"warning"`
+	Error     string `yaml:"error" toml:"error"`
+	Link      string `yaml:"link" toml:"link"`
+	Highlight string `yaml:"highlight" toml:"highlight"`
+	Box       string `yaml:"box" toml:"box"`
+}
+
+// Page margins in mm, zero keeps the default of 10 mm
+type ThemeMargins struct {
+	Left  float64 `yaml:"left" toml:"left"`
+	Top   float64 `yaml:"top" toml:"top"`
+	Right float64 `yaml:"right" toml:"right"`
+}
+
+// A report theme, Header and Footer may use the placeholders {file}, {date} and {version}
+type Theme struct {
+	Name         string       `yaml:"name" toml:"name"`
+	Description  string       `yaml:"description" toml:"description"`
+	Logo         string       `yaml:"logo" toml:"logo"`
+	Header       string       `yaml:"header" toml:"header"`
+	Footer       string       `yaml:"footer" toml:"footer"`
+	Font         string       `yaml:"font" toml:"font"`
+	FontFallback []string     `yaml:"font_fallback" toml:"font_fallback"`
+	Colours      ThemeColours `yaml:"colours" toml:"colours"`
+	PageSize     string       `yaml:"page_size" toml:"page_size"`
+	Margins      ThemeMargins `yaml:"margins" toml:"margins"`
+}
+
+// theme used for the reports, set from -theme
+var REPORT_THEME Theme
+
+// load a theme by path, by name from ./themes or by name from the builtin themes
+func load_theme(name_or_path string) (Theme, error) {
+	var theme Theme
+
+	if name_or_path == "" {
+		name_or_path = default_theme_name
+	}
+
+	// an existing file always wins
+	if info, err := os.Stat(name_or_path); err == nil && !info.IsDir() {
+		return read_theme_file(name_or_path)
+	}
+
+	// a name, try the local themes directory first
+	for _, ext := range []string{".yaml", ".yml", ".toml"} {
+		fname := filepath.Join("themes", name_or_path+ext)
+		if _, err := os.Stat(fname); err == nil {
+			return read_theme_file(fname)
+		}
+	}
+
+	// fall back on the themes compiled into readYmeta
+	data, err := builtin_themes.ReadFile("themes/" + name_or_path + ".yaml")
+	if err != nil {
+		return theme, fmt.Errorf("unknown theme \"%s\", available themes: %s", name_or_path,
+			strings.Join(list_builtin_themes(), ", "))
+	}
+	theme, err = parse_theme(data, ".yaml", "")
+	if err != nil {
+		return theme, fmt.Errorf("builtin theme \"%s\": %w", name_or_path, err)
+	}
+	return theme, nil
+}
+
+// read and parse a theme file, files it refers to are relative to the theme file
+func read_theme_file(fname string) (Theme, error) {
+	var theme Theme
+
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return theme, err
+	}
+	theme, err = parse_theme(data, strings.ToLower(filepath.Ext(fname)), filepath.Dir(fname))
+	if err != nil {
+		return theme, fmt.Errorf("theme file \"%s\": %w", fname, err)
+	}
+	if theme.Name == "" {
+		theme.Name = strings.TrimSuffix(filepath.Base(fname), filepath.Ext(fname))
+	}
+	return theme, nil
+}
+
+// decode YAML or TOML theme data, resolve its files against dir and check the result
+func parse_theme(data []byte, ext string, dir string) (Theme, error) {
+	var theme Theme
+	var err error
+
+	switch ext {
+	case ".toml":
+		_, err = toml.Decode(string(data), &theme)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &theme)
+	default:
+		err = fmt.Errorf("unsupported theme format \"%s\", use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return theme, err
+	}
+
+	resolve := func(fname string) string {
+		if fname == "" || filepath.IsAbs(fname) || dir == "" {
+			return fname
+		}
+		return filepath.Join(dir, fname)
+	}
+	theme.Logo = resolve(theme.Logo)
+	if theme.Font != "" && theme.Font != font_name_builtin && theme.Font != font_name_core {
+		theme.Font = resolve(theme.Font)
+	}
+	for i := range theme.FontFallback {
+		theme.FontFallback[i] = resolve(theme.FontFallback[i])
+	}
+	return theme, check_theme(theme)
+}
+
+// check that the logo exists and the page size and colours are valid
+func check_theme(theme Theme) error {
+	if theme.Logo != "" {
+		switch strings.ToLower(filepath.Ext(theme.Logo)) {
+		case ".png", ".jpg", ".jpeg":
+		default:
+			return fmt.Errorf("logo \"%s\" must be a PNG or JPEG image", theme.Logo)
+		}
+		if _, err := os.Stat(theme.Logo); err != nil {
+			return fmt.Errorf("logo: %w", err)
+		}
+	}
+	if _, ok := theme_page_size(theme.PageSize); !ok {
+		return fmt.Errorf("invalid page size \"%s\", use A3, A4, A5, Letter or Legal", theme.PageSize)
+	}
+	colours := map[string]string{
+		"info": theme.Colours.Info, "warning": theme.Colours.Warning, "error": theme.Colours.Error,
+		"link": theme.Colours.Link, "highlight": theme.Colours.Highlight, "box": theme.Colours.Box,
+	}
+	if theme.Colours.Info != "" && (theme.Colours.Info == theme.Colours.Warning || theme.Colours.Info == theme.Colours.Error) ||
+		theme.Colours.Warning != "" && theme.Colours.Warning == theme.Colours.Error {
+		return fmt.Errorf("the info, warning and error colours must differ")
+	}
+	for name, value := range colours {
+		if value == "" {
+			continue
+		}
+		c, ok := parse_hex_colour(value)
+		if !ok {
+			return fmt.Errorf("colour %s \"%s\" is not a \"#rrggbb\" colour", name, value)
+		}
+		// the report tells findings from normal text by their colour
+		if c == pdfBlack() && name != "box" {
+			return fmt.Errorf("colour %s cannot be black, the text colour", name)
+		}
+	}
+	// the severity markers are found by the text colour, so links and highlights must not use a severity colour
+	severities := []struct {
+		name   string
+		colour color.Color
+	}{
+		{"info", theme_colour(theme.Colours.Info, pdfOrange())},
+		{"warning", theme_colour(theme.Colours.Warning, pdfBlue())},
+		{"error", theme_colour(theme.Colours.Error, pdfRed())},
+	}
+	for _, c := range []struct {
+		name   string
+		colour color.Color
+	}{
+		{"link", theme_colour(theme.Colours.Link, pdfLinkColourDefault())},
+		{"highlight", theme_colour(theme.Colours.Highlight, pdfGreen())},
+	} {
+		for _, sev := range severities {
+			if c.colour == sev.colour {
+				return fmt.Errorf("colour %s cannot be the %s colour, the colour of %s findings", c.name, sev.name, sev.name)
+			}
+		}
+	}
+	return nil
+}
+
+// names of the builtin themes
+func list_builtin_themes() []string {
+	var names []string
+	entries, _ := builtin_themes.ReadDir("themes")
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".yaml"))
+	}
+	return names
+}
+
+// parse a "#rrggbb" colour
+func parse_hex_colour(value string) (color.Color, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "#")
+	if len(value) != 6 {
+		return color.Color{}, false
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.Color{}, false
+	}
+	return color.Color{Red: int(rgb >> 16), Green: int(rgb >> 8 & 0xff), Blue: int(rgb & 0xff)}, true
+}
+
+// a theme colour, or fallback when the theme does not set it
+func theme_colour(value string, fallback color.Color) color.Color {
+	if c, ok := parse_hex_colour(value); ok {
+		return c
+	}
+	return fallback
+}
+
+// maroto page size of a theme, A4 when not set
+func theme_page_size(size string) (consts.PageSize, bool) {
+	switch strings.ToLower(strings.TrimSpace(size)) {
+	case "", "a4":
+		return consts.A4, true
+	case "a3":
+		return consts.A3, true
+	case "a5":
+		return consts.A5, true
+	case "letter":
+		return consts.Letter, true
+	case "legal":
+		return consts.Legal, true
+	}
+	return consts.A4, false
+}
+
+// page margins of a theme, left, top and right
+func (t Theme) margins() (float64, float64, float64) {
+	margin := func(v float64) float64 {
+		if v <= 0 {
+			return 10
+		}
+		return v
+	}
+	return margin(t.Margins.Left), margin(t.Margins.Top), margin(t.Margins.Right)
+}
+
+// header or footer text with its placeholders filled in, text is used when the template is empty
+func (t Theme) fill_template(template string, text string, fname string, date string) string {
+	if strings.TrimSpace(template) == "" {
+		return text
+	}
+	return strings.NewReplacer("{file}", fname, "{date}", date, "{version}", _MYVERSION_).Replace(template)
+}
+
+// use the theme fonts unless fonts are given on the command line (options)
+func apply_theme_fonts(theme Theme, options *flag.FlagSet) {
+	set := map[string]bool{}
+	options.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if !set["font"] && theme.Font != "" {
+		*font_flag = theme.Font
+	}
+	if !set["font-fallback"] && len(theme.FontFallback) > 0 {
+		*font_fallback_flag = strings.Join(theme.FontFallback, ",")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"site.yaml": `name: site
+header: "{file} - {date}"
+font_fallback: [fonts/cjk.ttf]
+page_size: Letter
+margins: {left: 15, top: 12}
+colours:
+  error: "#cc0000"
+  link: "#003366"
+`,
+		"site.toml": `name = "site"
+header = "{file} - {date}"
+font_fallback = ["fonts/cjk.ttf"]
+page_size = "Letter"
+
+[margins]
+left = 15
+top = 12
+
+[colours]
+error = "#cc0000"
+link = "#003366"
+`,
+	}
+	for name, content := range files {
+		fname := filepath.Join(dir, name)
+		if err := os.WriteFile(fname, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		theme, err := load_theme(fname)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if theme.Name != "site" || theme.Header != "{file} - {date}" || theme.PageSize != "Letter" ||
+			theme.Margins.Left != 15 || theme.Margins.Top != 12 || theme.Margins.Right != 0 ||
+			theme.Colours.Error != "#cc0000" || theme.Colours.Link != "#003366" || theme.Colours.Info != "" {
+			t.Errorf("%s: %+v", name, theme)
+		}
+		// files are relative to the theme file
+		if len(theme.FontFallback) != 1 || theme.FontFallback[0] != filepath.Join(dir, "fonts", "cjk.ttf") {
+			t.Errorf("%s: font fallback %v", name, theme.FontFallback)
+		}
+	}
+	if _, err := load_theme("no-such-theme"); err == nil || !strings.Contains(err.Error(), default_theme_name) {
+		t.Errorf("unknown theme: %v", err)
+	}
+}
+
+func TestCheckTheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		colours ThemeColours
+		err     string
+	}{
+		{"default colours", ThemeColours{}, ""},
+		{"own colours", ThemeColours{Error: "#cc0000", Link: "#003366", Highlight: "#008000"}, ""},
+		{"not a colour", ThemeColours{Link: "blue"}, "not a \"#rrggbb\" colour"},
+		{"black", ThemeColours{Warning: "#000000"}, "cannot be black"},
+		{"same severities", ThemeColours{Info: "#cc0000", Error: "#cc0000"}, "must differ"},
+		{"link is the error colour", ThemeColours{Error: "#cc0000", Link: "#cc0000"}, "colour link cannot be the error colour"},
+		{"link is the default warning colour", ThemeColours{Link: "#0000FF"}, "colour link cannot be the warning colour"},
+		{"highlight is the info colour", ThemeColours{Info: "#00ff00"}, "colour highlight cannot be the info colour"},
+		{"box may be a severity colour", ThemeColours{Box: "#ff0000"}, ""},
+	}
+	for _, tt := range tests {
+		err := check_theme(Theme{Colours: tt.colours})
+		if (err == nil) != (tt.err == "") || (err != nil && !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+/*
+timeline.go timeline chart of the dates of a data package. The collection window, the covered
+period, the end of the embargo and the end of the retention period (Retention_Period years after
+the end of collection) are drawn on one time axis, with a line for today, so that a reviewer can
+see at a glance whether the periods make sense together. Inconsistent dates are listed below it.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// height of a timeline row and of its bar in mm
+const timeline_row_height float64 = 6
+const timeline_bar_height float64 = 3
+
+// height of the time axis with its year labels in mm
+const timeline_axis_height float64 = 6
+
+// grid columns used for the labels left of the chart
+const timeline_label_cols uint = 3
+
+// space kept free at the bottom of a page for the footer in mm, the chart is not split over pages
+const timeline_footer_space float64 = 20
+
+// one row of the timeline, a period from Start to End or a single date (End is zero)
+type timeline_entry struct {
+	Label string
+	Start time.Time
+	End   time.Time
+}
+
+// parse a date in one of the layouts accepted by readYmeta fix, ambiguous dates are not read
+func parse_date(date string) (time.Time, bool) {
+	t, ok, _ := read_date(date)
+	return t, ok
+}
+
+// a period as a timeline entry, a period of which only one end is known is shown as that date
+func timeline_period(label string, start string, end string) (timeline_entry, bool) {
+	s, sok := parse_date(start)
+	e, eok := parse_date(end)
+	switch {
+	case sok && eok:
+		return timeline_entry{Label: label, Start: s, End: e}, true
+	case sok:
+		return timeline_entry{Label: label, Start: s}, true
+	case eok:
+		return timeline_entry{Label: label, Start: e}, true
+	}
+	return timeline_entry{}, false
+}
+
+// end of the retention period, counted from the end (or else the start) of collection
+func retention_expiry(data Yoda18Metadata) (time.Time, bool) {
+	if data.RetentionPeriod <= 0 {
+		return time.Time{}, false
+	}
+	from, ok := parse_date(data.Collected.EndDate)
+	if !ok {
+		from, ok = parse_date(data.Collected.StartDate)
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+	return from.AddDate(data.RetentionPeriod, 0, 0), true
+}
+
+// the rows of the timeline
+func timeline_entries(data Yoda18Metadata) []timeline_entry {
+	var entries []timeline_entry
+	if e, ok := timeline_period(tr("label.collected"), data.Collected.StartDate, data.Collected.EndDate); ok {
+		entries = append(entries, e)
+	}
+	if e, ok := timeline_period(tr("label.covered_period"), data.CoveredPeriod.StartDate, data.CoveredPeriod.EndDate); ok {
+		entries = append(entries, e)
+	}
+	if t, ok := parse_date(data.EmbargoEndDate); ok {
+		entries = append(entries, timeline_entry{Label: tr("timeline.embargo_end"), Start: t})
+	}
+	if t, ok := retention_expiry(data); ok {
+		entries = append(entries, timeline_entry{Label: tr("timeline.retention_expiry", data.RetentionPeriod), Start: t})
+	}
+	return entries
+}
+
+// a note about dates that do not fit together
+type timeline_problem struct {
+	Message  string
+	Severity Severity
+}
+
+// dates that do not fit together
+func timeline_problems(data Yoda18Metadata, today time.Time) []timeline_problem {
+	var problems []timeline_problem
+	for _, p := range []struct {
+		label      string
+		start, end string
+	}{
+		{tr("label.collected"), data.Collected.StartDate, data.Collected.EndDate},
+		{tr("label.covered_period"), data.CoveredPeriod.StartDate, data.CoveredPeriod.EndDate},
+	} {
+		s, sok := parse_date(p.start)
+		e, eok := parse_date(p.end)
+		if sok && eok && e.Before(s) {
+			problems = append(problems, timeline_problem{tr("timeline.ends_before_start", p.label), SeverityError})
+		}
+	}
+	expiry, rok := retention_expiry(data)
+	embargo, eok := parse_date(data.EmbargoEndDate)
+	if rok && eok && embargo.After(expiry) {
+		problems = append(problems, timeline_problem{tr("timeline.embargo_after_retention"), SeverityWarning})
+	}
+	if rok && expiry.Before(today) {
+		problems = append(problems, timeline_problem{tr("timeline.retention_expired", expiry.Format("2006-01-02")), SeverityWarning})
+	}
+	if collected, ok := parse_date(data.Collected.StartDate); ok && collected.After(today) {
+		problems = append(problems, timeline_problem{tr("timeline.collection_in_future"), SeverityWarning})
+	}
+	return problems
+}
+
+// first and last year of the axis, covering all dates and today
+func timeline_range(entries []timeline_entry, today time.Time) (int, int) {
+	first, last := today.Year(), today.Year()
+	for _, e := range entries {
+		for _, t := range []time.Time{e.Start, e.End} {
+			if t.IsZero() {
+				continue
+			}
+			if t.Year() < first {
+				first = t.Year()
+			}
+			if t.Year() > last {
+				last = t.Year()
+			}
+		}
+	}
+	return first, last + 1
+}
+
+// draw a date as a diamond centred at x, y
+func pdf_draw_diamond(pm *pdf.PdfMaroto, x float64, y float64, size float64) {
+	pm.Pdf.Polygon([]gofpdf.PointType{{X: x, Y: y - size/2}, {X: x + size/2, Y: y}, {X: x, Y: y + size/2}, {X: x - size/2, Y: y}}, "FD")
+}
+
+// timeline chart with a row per period or date, a time axis and the problems found in the dates
+func pdf_write_timeline(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint) {
+	today := time.Now()
+	entries := timeline_entries(data)
+	pdf_bookmark("label.timeline", 0)
+	pdf_write_row(m, tr("label.timeline"), rowheight, colwidth, consts.Bold, pdfBlack())
+	pm, ok := base_maroto(m)
+	if len(entries) == 0 || !ok {
+		pdf_write_row_indent(m, tr("timeline.none"), rowheight, colwidth, consts.Normal, pdfInfoColour(), 1)
+		return
+	}
+
+	// keep the chart on one page
+	_, pageheight := m.GetPageSize()
+	if pm.Pdf.GetY()+float64(len(entries))*timeline_row_height+timeline_axis_height > pageheight-timeline_footer_space {
+		m.AddPage()
+	}
+
+	left, _, _, _ := m.GetPageMargins()
+	x0 := left + grid_width(m, timeline_label_cols)
+	width := grid_width(m, colwidth-timeline_label_cols)
+	first, last := timeline_range(entries, today)
+	start := time.Date(first, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(last, 1, 1, 0, 0, 0, 0, time.UTC)
+	xpos := func(t time.Time) float64 {
+		return x0 + t.Sub(start).Hours()/end.Sub(start).Hours()*width
+	}
+
+	fr, fg, fb := pm.Pdf.GetFillColor()
+	dr, dg, db := pm.Pdf.GetDrawColor()
+	lw := pm.Pdf.GetLineWidth()
+	top := pm.Pdf.GetY()
+	bar := pdfLinkColour()
+	for _, e := range entries {
+		m.Row(timeline_row_height, func() {
+			m.Col(timeline_label_cols, func() {
+				m.Text(e.Label, props.Text{Top: 1, Size: fontsize - 1, Color: pdfBlack()})
+			})
+			m.ColSpace(colwidth - timeline_label_cols)
+		})
+		y := pm.Pdf.GetY() - timeline_row_height/2
+		pm.Pdf.SetDrawColor(0, 0, 0)
+		pm.Pdf.SetLineWidth(0.2)
+		switch {
+		case e.End.IsZero():
+			pm.Pdf.SetFillColor(bar.Red, bar.Green, bar.Blue)
+			pdf_draw_diamond(pm, xpos(e.Start), y, timeline_bar_height)
+		case e.End.Before(e.Start):
+			c := severity_rgb(SeverityError)
+			pm.Pdf.SetFillColor(c.Red, c.Green, c.Blue)
+			pm.Pdf.Rect(xpos(e.End), y-timeline_bar_height/2, xpos(e.Start)-xpos(e.End), timeline_bar_height, "FD")
+		default:
+			pm.Pdf.SetFillColor(bar.Red, bar.Green, bar.Blue)
+			pm.Pdf.Rect(xpos(e.Start), y-timeline_bar_height/2, math.Max(xpos(e.End)-xpos(e.Start), 0.5), timeline_bar_height, "FD")
+		}
+	}
+	bottom := pm.Pdf.GetY()
+
+	// axis with a tick per year, labelled every few years so that the labels do not overlap
+	m.Row(timeline_axis_height, func() {})
+	pm.Pdf.SetDrawColor(0, 0, 0)
+	pm.Pdf.SetLineWidth(0.2)
+	pm.Pdf.Line(x0, bottom, x0+width, bottom)
+	step := int(math.Ceil(float64(last-first) / 12))
+	pm.Pdf.SetFont(m.GetDefaultFontFamily(), "", fontsize-3)
+	pm.Pdf.SetTextColor(0, 0, 0)
+	for year := first; year <= last; year++ {
+		x := xpos(time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC))
+		pm.Pdf.Line(x, bottom, x, bottom+1)
+		if (year-first)%step == 0 {
+			label := fmt.Sprint(year)
+			pm.Pdf.Text(x-pm.Pdf.GetStringWidth(label)/2, bottom+4, label)
+		}
+	}
+
+	// today
+	pm.Pdf.SetDrawColor(120, 120, 120)
+	pm.Pdf.SetDashPattern([]float64{1, 1}, 0)
+	pm.Pdf.Line(xpos(today), top, xpos(today), bottom)
+	pm.Pdf.SetDashPattern([]float64{}, 0)
+	label := tr("timeline.today")
+	if x := xpos(today) + 0.5; x+pm.Pdf.GetStringWidth(label) < x0+width {
+		pm.Pdf.Text(x, top+2, label)
+	} else {
+		pm.Pdf.Text(xpos(today)-0.5-pm.Pdf.GetStringWidth(label), top+2, label)
+	}
+
+	pm.Pdf.SetFillColor(fr, fg, fb)
+	pm.Pdf.SetDrawColor(dr, dg, db)
+	pm.Pdf.SetLineWidth(lw)
+
+	for _, p := range timeline_problems(data, today) {
+		pdf_write_row_indent(m, p.Message, rowheight, colwidth, consts.Normal, severity_rgb(p.Severity), 1)
+	}
+}
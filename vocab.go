@@ -0,0 +1,300 @@
+/*
+vocab.go controlled vocabularies of the Yoda 1.8 metadata schema, used to check and correct
+vocabulary terms.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"strings"
+)
+
+var vocab_data_access_restriction = []string{
+	"Open - freely retrievable",
+	"Restricted - available upon request",
+	"Closed",
+}
+
+var vocab_data_classification = []string{
+	"Public",
+	"Basic",
+	"Sensitive",
+	"Critical",
+}
+
+var vocab_data_type = []string{
+	"Dataset",
+	"DataPaper",
+	"Software",
+	"Text",
+	"Image",
+	"Audiovisual",
+	"Sound",
+	"Model",
+	"Workflow",
+	"Collection",
+	"InteractiveResource",
+	"PhysicalObject",
+	"Other",
+}
+
+var vocab_contributor_type = []string{
+	"ContactPerson",
+	"DataCollector",
+	"DataCurator",
+	"DataManager",
+	"Distributor",
+	"Editor",
+	"HostingInstitution",
+	"Producer",
+	"ProjectLeader",
+	"ProjectManager",
+	"ProjectMember",
+	"RegistrationAgency",
+	"RegistrationAuthority",
+	"RelatedPerson",
+	"Researcher",
+	"ResearchGroup",
+	"RightsHolder",
+	"Sponsor",
+	"Supervisor",
+	"WorkPackageLeader",
+	"Other",
+}
+
+var vocab_name_identifier_scheme = []string{
+	"ORCID",
+	"DAI",
+	"Author identifier (Scopus)",
+	"ResearcherID (Web of Science)",
+	"ISNI",
+}
+
+var vocab_identifier_scheme = []string{
+	"ARK",
+	"arXiv",
+	"bibcode",
+	"DOI",
+	"EAN13",
+	"EISSN",
+	"Handle",
+	"IGSN",
+	"ISBN",
+	"ISSN",
+	"ISTC",
+	"LISSN",
+	"LSID",
+	"PMID",
+	"PURL",
+	"UPC",
+	"URL",
+	"URN",
+}
+
+// relation types, the code before the colon identifies the term
+var vocab_relation_type = []string{
+	"IsSupplementTo: Current datapackage is supplement to",
+	"IsSupplementedBy: Current datapackage is supplemented by",
+	"IsPreviousVersionOf: Current datapackage is previous version of",
+	"IsNewVersionOf: Current datapackage is new version of",
+	"IsPartOf: Current datapackage is part of",
+	"HasPart: Current datapackage has part",
+	"IsReferencedBy: Current datapackage is referenced by",
+	"References: Current datapackage references",
+	"IsCitedBy: Current datapackage is cited by",
+	"Cites: Current datapackage cites",
+	"IsDocumentedBy: Current datapackage is documented by",
+	"Documents: Current datapackage documents",
+	"IsDerivedFrom: Current datapackage is derived from",
+	"IsSourceOf: Current datapackage is source of",
+	"IsIdenticalTo: Current datapackage is identical to",
+	"Continues: Continues this current dataset",
+	"IsContinuedBy: Current datadatapackage is continued by",
+}
+
+// disciplines, the OECD Fields of Science and Technology (FOS 2007)
+var vocab_discipline = []string{
+	"Natural Sciences - Mathematics (1.1)",
+	"Natural Sciences - Computer and information sciences (1.2)",
+	"Natural Sciences - Physical sciences (1.3)",
+	"Natural Sciences - Chemical sciences (1.4)",
+	"Natural Sciences - Earth and related environmental sciences (1.5)",
+	"Natural Sciences - Biological sciences (1.6)",
+	"Natural Sciences - Other natural sciences (1.7)",
+	"Engineering and Technology - Civil engineering (2.1)",
+	"Engineering and Technology - Electrical engineering, electronic engineering, information engineering (2.2)",
+	"Engineering and Technology - Mechanical engineering (2.3)",
+	"Engineering and Technology - Chemical engineering (2.4)",
+	"Engineering and Technology - Materials engineering (2.5)",
+	"Engineering and Technology - Medical engineering (2.6)",
+	"Engineering and Technology - Environmental engineering (2.7)",
+	"Engineering and Technology - Environmental biotechnology (2.8)",
+	"Engineering and Technology - Industrial biotechnology (2.9)",
+	"Engineering and Technology - Nano-technology (2.10)",
+	"Engineering and Technology - Other engineering and technologies (2.11)",
+	"Medical and Health Sciences - Basic medicine (3.1)",
+	"Medical and Health Sciences - Clinical medicine (3.2)",
+	"Medical and Health Sciences - Health sciences (3.3)",
+	"Medical and Health Sciences - Medical biotechnology (3.4)",
+	"Medical and Health Sciences - Other medical sciences (3.5)",
+	"Agricultural Sciences - Agriculture, forestry, and fisheries (4.1)",
+	"Agricultural Sciences - Animal and dairy science (4.2)",
+	"Agricultural Sciences - Veterinary science (4.3)",
+	"Agricultural Sciences - Agricultural biotechnology (4.4)",
+	"Agricultural Sciences - Other agricultural sciences (4.5)",
+	"Social Sciences - Psychology (5.1)",
+	"Social Sciences - Economics and business (5.2)",
+	"Social Sciences - Educational sciences (5.3)",
+	"Social Sciences - Sociology (5.4)",
+	"Social Sciences - Law (5.5)",
+	"Social Sciences - Political Science (5.6)",
+	"Social Sciences - Social and economic geography (5.7)",
+	"Social Sciences - Media and communications (5.8)",
+	"Social Sciences - Other social sciences (5.9)",
+	"Humanities - History and archaeology (6.1)",
+	"Humanities - Languages and literature (6.2)",
+	"Humanities - Philosophy, ethics and religion (6.3)",
+	"Humanities - Art (arts, history of arts, performing arts, music) (6.4)",
+	"Humanities - Other humanities (6.5)",
+}
+
+// licences, short names are accepted as aliases
+var vocab_license = []string{
+	"Creative Commons Attribution 4.0 International Public License",
+	"Creative Commons Attribution-ShareAlike 4.0 International Public License",
+	"Creative Commons Attribution-NonCommercial 4.0 International Public License",
+	"Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International Public License",
+	"Creative Commons Attribution-NoDerivatives 4.0 International Public License",
+	"Creative Commons Zero v1.0 Universal",
+	"Open Data Commons Attribution License (ODC-By) v1.0",
+	"Open Data Commons Open Database License (ODbL) v1.0",
+	"Open Data Commons Public Domain Dedication and License (PDDL) v1.0",
+	"GNU General Public License v3.0",
+	"MIT License",
+	"Custom",
+}
+
+var license_aliases = map[string]string{
+	"cc-by":           "Creative Commons Attribution 4.0 International Public License",
+	"cc-by-4.0":       "Creative Commons Attribution 4.0 International Public License",
+	"cc by 4.0":       "Creative Commons Attribution 4.0 International Public License",
+	"cc-by-sa-4.0":    "Creative Commons Attribution-ShareAlike 4.0 International Public License",
+	"cc by-sa 4.0":    "Creative Commons Attribution-ShareAlike 4.0 International Public License",
+	"cc-by-nc-4.0":    "Creative Commons Attribution-NonCommercial 4.0 International Public License",
+	"cc-by-nc-sa-4.0": "Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International Public License",
+	"cc-by-nd-4.0":    "Creative Commons Attribution-NoDerivatives 4.0 International Public License",
+	"cc0":             "Creative Commons Zero v1.0 Universal",
+	"cc0-1.0":         "Creative Commons Zero v1.0 Universal",
+	"odc-by-1.0":      "Open Data Commons Attribution License (ODC-By) v1.0",
+	"odbl-1.0":        "Open Data Commons Open Database License (ODbL) v1.0",
+	"pddl-1.0":        "Open Data Commons Public Domain Dedication and License (PDDL) v1.0",
+	"gpl-3.0":         "GNU General Public License v3.0",
+	"mit":             "MIT License",
+}
+
+// ISO 639-1 languages, stored by Yoda as "<code> - <English name>"
+var vocab_language = map[string]string{
+	"ar": "Arabic",
+	"bg": "Bulgarian",
+	"cs": "Czech",
+	"da": "Danish",
+	"de": "German",
+	"el": "Greek",
+	"en": "English",
+	"es": "Spanish",
+	"et": "Estonian",
+	"fi": "Finnish",
+	"fr": "French",
+	"fy": "Western Frisian",
+	"ga": "Irish",
+	"he": "Hebrew",
+	"hi": "Hindi",
+	"hr": "Croatian",
+	"hu": "Hungarian",
+	"id": "Indonesian",
+	"it": "Italian",
+	"ja": "Japanese",
+	"ko": "Korean",
+	"lt": "Lithuanian",
+	"lv": "Latvian",
+	"nl": "Dutch",
+	"no": "Norwegian",
+	"pl": "Polish",
+	"pt": "Portuguese",
+	"ro": "Romanian",
+	"ru": "Russian",
+	"sk": "Slovak",
+	"sl": "Slovenian",
+	"sv": "Swedish",
+	"tr": "Turkish",
+	"uk": "Ukrainian",
+	"zh": "Chinese",
+}
+
+// return the canonical spelling of term in vocab (case and whitespace insensitive)
+func vocab_lookup(vocab []string, term string) (string, bool) {
+	key := vocab_key(term)
+	for _, v := range vocab {
+		if vocab_key(v) == key {
+			return v, true
+		}
+	}
+	return term, false
+}
+
+// correct a relation type using the code before the colon, "isnewversionof" -> "IsNewVersionOf: ..."
+func vocab_lookup_relation_type(term string) (string, bool) {
+	code := vocab_key(strings.Split(term, ":")[0])
+	for _, v := range vocab_relation_type {
+		if vocab_key(strings.Split(v, ":")[0]) == code {
+			return v, true
+		}
+	}
+	return term, false
+}
+
+// correct a licence, full names and common short names are accepted
+func vocab_lookup_license(term string) (string, bool) {
+	if v, ok := vocab_lookup(vocab_license, term); ok {
+		return v, true
+	}
+	if v, ok := license_aliases[strings.ToLower(strings.TrimSpace(term))]; ok {
+		return v, true
+	}
+	return term, false
+}
+
+// find a discipline by its full name or by its field, e.g. "FOS: Biological sciences" or "Biological sciences"
+func vocab_lookup_discipline(term string) (string, bool) {
+	if v, ok := vocab_lookup(vocab_discipline, term); ok {
+		return v, true
+	}
+	key := vocab_key(strings.TrimPrefix(strings.TrimSpace(term), "FOS:"))
+	for _, v := range vocab_discipline {
+		field := strings.SplitN(v, " - ", 2)[1]
+		if vocab_key(field[:strings.LastIndex(field, " (")]) == key {
+			return v, true
+		}
+	}
+	return term, false
+}
+
+// correct a language, "en", "English" and "EN - english" all become "en - English"
+func vocab_lookup_language(term string) (string, bool) {
+	code := strings.ToLower(strings.TrimSpace(strings.Split(term, " - ")[0]))
+	if name, ok := vocab_language[code]; ok {
+		return code + " - " + name, true
+	}
+	for code, name := range vocab_language {
+		if strings.EqualFold(strings.TrimSpace(term), name) {
+			return code + " - " + name, true
+		}
+	}
+	return term, false
+}
+
+// comparison key for vocabulary terms
+func vocab_key(term string) string {
+	return strings.ToLower(collapse_whitespace(term))
+}
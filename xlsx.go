@@ -0,0 +1,279 @@
+/*
+xlsx.go a minimal reader and writer for Office Open XML spreadsheets (.xlsx), enough to exchange
+tables of text with Excel and LibreOffice: every cell is written as an inline string and the first
+row of a sheet is bold. Shared strings, inline strings, rich text and numbers are read.
+(C) Brett G. Olivier, Vrije Universiteit Amsterdam, Amsterdam, The Netherlands, 2023.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// a named table of a workbook, the first row is the header
+type Sheet struct {
+	Name string
+	Rows [][]string
+}
+
+const xlsx_content_types string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+%s</Types>`
+
+const xlsx_root_rels string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+// style 1 is the bold header
+const xlsx_styles string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>
+</styleSheet>`
+
+// column letters of a zero based column index: A, B, ..., Z, AA, AB, ...
+func xlsx_column_name(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// zero based column index of a cell reference such as AB12
+func xlsx_column_index(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A') + 1
+	}
+	return col - 1
+}
+
+// escape text for XML, characters that XML 1.0 does not allow are left out
+func xlsx_escape(text string) string {
+	var b strings.Builder
+	text = strings.Map(func(r rune) rune {
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, text)
+	_ = xml.EscapeText(&b, []byte(text))
+	return b.String()
+}
+
+// write the sheets as an xlsx workbook
+func write_xlsx(fname string, sheets []Sheet) error {
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	var overrides, entries, rels strings.Builder
+	for i, s := range sheets {
+		fmt.Fprintf(&overrides, "<Override PartName=\"/xl/worksheets/sheet%d.xml\" ContentType=\"application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml\"/>\n", i+1)
+		fmt.Fprintf(&entries, "<sheet name=\"%s\" sheetId=\"%d\" r:id=\"rId%d\"/>", xlsx_escape(s.Name), i+1, i+1)
+		fmt.Fprintf(&rels, "<Relationship Id=\"rId%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet\" Target=\"worksheets/sheet%d.xml\"/>\n", i+1, i+1)
+	}
+	fmt.Fprintf(&rels, "<Relationship Id=\"rId%d\" Type=\"http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles\" Target=\"styles.xml\"/>\n", len(sheets)+1)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(xlsx_content_types, overrides.String())},
+		{"_rels/.rels", xlsx_root_rels},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			entries.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+` + rels.String() + `</Relationships>`},
+		{"xl/styles.xml", xlsx_styles},
+	}
+	for i, s := range sheets {
+		parts = append(parts, struct{ name, content string }{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), xlsx_sheet_xml(s)})
+	}
+	for _, p := range parts {
+		w, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(w, p.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// the worksheet XML of a sheet, the header row is frozen
+func xlsx_sheet_xml(s Sheet) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews><sheetData>`)
+	for r, row := range s.Rows {
+		fmt.Fprintf(&b, "<row r=\"%d\">", r+1)
+		for c, value := range row {
+			if value == "" {
+				continue
+			}
+			style := ""
+			if r == 0 {
+				style = ` s="1"`
+			}
+			fmt.Fprintf(&b, "<c r=\"%s%d\"%s t=\"inlineStr\"><is><t xml:space=\"preserve\">%s</t></is></c>", xlsx_column_name(c), r+1, style, xlsx_escape(value))
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData></worksheet>")
+	return b.String()
+}
+
+// text of a shared or inline string, plain or as rich text runs
+type xlsx_text struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsx_text) String() string {
+	text := t.T
+	for _, r := range t.Runs {
+		text += r.T
+	}
+	return text
+}
+
+// read a part of the workbook as XML, missing parts are skipped when optional
+func xlsx_read_part(files map[string]*zip.File, name string, v interface{}, optional bool) error {
+	f, ok := files[name]
+	if !ok {
+		if optional {
+			return nil
+		}
+		return fmt.Errorf("%s is missing", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	return xml.NewDecoder(rc).Decode(v)
+}
+
+// read the sheets of an xlsx workbook, in workbook order
+func read_xlsx(fname string) ([]Sheet, error) {
+	zr, err := zip.OpenReader(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	files := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	var workbook struct {
+		Sheets []struct {
+			Name string `xml:"name,attr"`
+			ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	var shared struct {
+		Items []xlsx_text `xml:"si"`
+	}
+	if err = xlsx_read_part(files, "xl/workbook.xml", &workbook, false); err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	if err = xlsx_read_part(files, "xl/_rels/workbook.xml.rels", &rels, false); err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	if err = xlsx_read_part(files, "xl/sharedStrings.xml", &shared, true); err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	targets := make(map[string]string)
+	for _, r := range rels.Relationships {
+		target := strings.TrimPrefix(r.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[r.ID] = target
+	}
+
+	var sheets []Sheet
+	for _, s := range workbook.Sheets {
+		var data struct {
+			Rows []struct {
+				R     int `xml:"r,attr"`
+				Cells []struct {
+					Ref    string    `xml:"r,attr"`
+					Type   string    `xml:"t,attr"`
+					Value  string    `xml:"v"`
+					Inline xlsx_text `xml:"is"`
+				} `xml:"c"`
+			} `xml:"sheetData>row"`
+		}
+		if err = xlsx_read_part(files, targets[s.ID], &data, false); err != nil {
+			return nil, fmt.Errorf("%s, sheet %s: %w", fname, s.Name, err)
+		}
+		sheet := Sheet{Name: s.Name}
+		for i, row := range data.Rows {
+			r := row.R - 1
+			if row.R == 0 {
+				r = i
+			}
+			for len(sheet.Rows) <= r {
+				sheet.Rows = append(sheet.Rows, nil)
+			}
+			for j, c := range row.Cells {
+				col := j
+				if c.Ref != "" {
+					col = xlsx_column_index(c.Ref)
+				}
+				value := c.Value
+				switch c.Type {
+				case "s":
+					n, err := strconv.Atoi(c.Value)
+					if err != nil || n < 0 || n >= len(shared.Items) {
+						return nil, fmt.Errorf("%s, sheet %s, cell %s: bad shared string %q", fname, s.Name, c.Ref, c.Value)
+					}
+					value = shared.Items[n].String()
+				case "inlineStr":
+					value = c.Inline.String()
+				}
+				for len(sheet.Rows[r]) <= col {
+					sheet.Rows[r] = append(sheet.Rows[r], "")
+				}
+				sheet.Rows[r][col] = value
+			}
+		}
+		sheets = append(sheets, sheet)
+	}
+	return sheets, nil
+}